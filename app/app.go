@@ -28,7 +28,12 @@ type CosmosApp struct {
 	homePath        string
 	chainRest       string
 
-	cmd *exec.Cmd
+	cmd     *exec.Cmd
+	running bool
+
+	// snapshotP2P is set once the snapshot p2p server was started, it is
+	// restarted with the engine so that it always serves from the current app
+	snapshotP2P bool
 
 	Genesis         *genesis.Genesis
 	Source          *source.Source
@@ -124,7 +129,7 @@ func (app *CosmosApp) AutoSelectBinaryVersion(height int64) error {
 	}
 
 	if _, err := os.Stat(upgradePath); err != nil {
-		return fmt.Errorf("upgrade \"%s\" not installed in cosmovisor", upgradeName)
+		return utils.NewClassErrorf(utils.ErrUpgradeRequired, "upgrade \"%s\" not installed in cosmovisor", upgradeName)
 	}
 
 	symlinkPath := fmt.Sprintf("%s/cosmovisor/current", app.homePath)
@@ -146,6 +151,8 @@ func (app *CosmosApp) AutoSelectBinaryVersion(height int64) error {
 }
 
 func (app *CosmosApp) StartAll(snapshotInterval int64) error {
+	app.running = true
+
 	// we close the dbs again before starting the actual cosmos app
 	// because on some versions the cosmos app accesses the blockstore.db,
 	// during the boot phase, although it should not do that. So if we would not
@@ -169,13 +176,31 @@ func (app *CosmosApp) StartAll(snapshotInterval int64) error {
 		return fmt.Errorf("failed to open dbs in engine: %w", err)
 	}
 
+	if app.snapshotP2P {
+		if err := app.ConsensusEngine.StartSnapshotP2PServer(); err != nil {
+			return fmt.Errorf("failed to start snapshot p2p server: %w", err)
+		}
+	}
+
 	return nil
 }
 
 func (app *CosmosApp) StopAll() {
+	// the app might already be stopped before the deferred stop
+	// is called, e.g. when handing the node off after the sync
+	if !app.running {
+		return
+	}
+
+	app.running = false
+
 	// we do not return on error here since we are shutting the
 	// application down anyway and ensure that everything else
 	// can get closed
+	if err := app.ConsensusEngine.StopSnapshotP2PServer(); err != nil {
+		logger.Logger.Error().Msgf("failed to stop snapshot p2p server: %s", err)
+	}
+
 	if err := app.ConsensusEngine.StopProxyApp(); err != nil {
 		logger.Logger.Error().Msgf("failed to stop proxy app: %s", err)
 	}
@@ -187,6 +212,17 @@ func (app *CosmosApp) StopAll() {
 	app.StopBinary()
 }
 
+// StartSnapshotP2PServer serves the snapshots of the app over P2P. Since the
+// server is bound to the proxy app it is stopped and started again with the app
+func (app *CosmosApp) StartSnapshotP2PServer() error {
+	if err := app.ConsensusEngine.StartSnapshotP2PServer(); err != nil {
+		return err
+	}
+
+	app.snapshotP2P = true
+	return nil
+}
+
 func (app *CosmosApp) RestartAll(snapshotInterval int64) error {
 	app.StopAll()
 	return app.StartAll(snapshotInterval)
@@ -438,23 +474,7 @@ func (app *CosmosApp) LoadHomePath() error {
 }
 
 func (app *CosmosApp) LoadChainRest() (err error) {
-	app.chainRest, err = func() (string, error) {
-		if flags.ChainRest != "" {
-			return strings.TrimSuffix(flags.ChainRest, "/"), nil
-		}
-
-		switch flags.ChainId {
-		case utils.ChainIdMainnet:
-			return utils.RestEndpointMainnet, nil
-		case utils.ChainIdKaon:
-			return utils.RestEndpointKaon, nil
-		case utils.ChainIdKorellia:
-			return utils.RestEndpointKorellia, nil
-		default:
-			return "", fmt.Errorf("flag --chain-id has to be either \"%s\", \"%s\" or \"%s\"", utils.ChainIdMainnet, utils.ChainIdKaon, utils.ChainIdKorellia)
-		}
-	}()
-
+	app.chainRest, err = utils.GetChainRest()
 	if err != nil {
 		return err
 	}
@@ -571,6 +591,22 @@ func (app *CosmosApp) LoadConsensusEngine() error {
 	return nil
 }
 
+// GetProtocolVersion returns the p2p protocol versions of the engine which runs the
+// given consensus of the chain registry. If the consensus is unknown we fall back
+// to the latest engine
+func GetProtocolVersion(consensusType, consensusVersion string) types.ProtocolVersion {
+	switch {
+	case strings.Contains(consensusType, "celestia"):
+		return celestia_core_v34.GetProtocolVersion()
+	case strings.Contains(consensusVersion, "0.34."):
+		return tendermint_v34.GetProtocolVersion()
+	case strings.Contains(consensusVersion, "0.37."):
+		return cometbft_v37.GetProtocolVersion()
+	default:
+		return cometbft_v38.GetProtocolVersion()
+	}
+}
+
 func (app *CosmosApp) getLDLibraryPath() string {
 	if app.isCosmovisor {
 		upgradeFolder, err := os.Readlink(fmt.Sprintf("%s/cosmovisor/current", app.homePath))
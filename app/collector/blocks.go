@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	tmJson "github.com/KYVENetwork/cometbft/v34/libs/json"
+	"github.com/KYVENetwork/ksync/logger"
 	"github.com/KYVENetwork/ksync/types"
 	"github.com/KYVENetwork/ksync/utils"
 	"strconv"
@@ -62,6 +63,15 @@ func (collector *RpcBlockCollector) GetBlock(height int64) ([]byte, error) {
 	return block, nil
 }
 
+func (collector *RpcBlockCollector) GetBlocks(height int64) (int64, [][]byte, error) {
+	block, err := collector.GetBlock(height)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return height, [][]byte{block}, nil
+}
+
 func (collector *RpcBlockCollector) StreamBlocks(blockCh chan<- *types.BlockItem, errorCh chan<- error, continuationHeight, targetHeight int64) {
 	for {
 		blockResponse, err := utils.GetFromUrl(fmt.Sprintf("%s/block?height=%d", collector.rpc, continuationHeight))
@@ -110,6 +120,11 @@ type KyveBlockCollector struct {
 	chainRest               string
 	earliestAvailableHeight int64
 	latestAvailableHeight   int64
+	index                   *bundleIndex
+
+	// page holds the last fetched page of finalized bundles, so
+	// without an index we only query once for every page
+	page []types.FinalizedBundle
 }
 
 func NewKyveBlockCollector(poolId int64, chainRest string) (*KyveBlockCollector, error) {
@@ -118,6 +133,10 @@ func NewKyveBlockCollector(poolId int64, chainRest string) (*KyveBlockCollector,
 		return nil, fmt.Errorf("fail to get pool with id %d: %w", poolId, err)
 	}
 
+	if err := verifyPool(poolResponse); err != nil {
+		return nil, fmt.Errorf("failed to verify pool with id %d: %w", poolId, err)
+	}
+
 	if poolResponse.Pool.Data.Runtime != utils.RuntimeTendermint && poolResponse.Pool.Data.Runtime != utils.RuntimeTendermintBsync {
 		return nil, fmt.Errorf("found invalid runtime on block pool %d: Expected = %s or %s Found = %s", poolId, utils.RuntimeTendermint, utils.RuntimeTendermintBsync, poolResponse.Pool.Data.Runtime)
 	}
@@ -136,12 +155,20 @@ func NewKyveBlockCollector(poolId int64, chainRest string) (*KyveBlockCollector,
 		return nil, fmt.Errorf("failed to parse end height %s from pool: %w", poolResponse.Pool.Data.CurrentKey, err)
 	}
 
+	// the bundle index is optional, e.g. if another KSYNC process syncing from the
+	// same pool already holds it we fall back to querying every bundle
+	index, err := openBundleIndex(chainRest, poolId)
+	if err != nil {
+		logger.Logger.Debug().Msgf("continuing without bundle index: %s", err)
+	}
+
 	return &KyveBlockCollector{
 		poolId:                  poolId,
 		runtime:                 poolResponse.Pool.Data.Runtime,
 		chainRest:               chainRest,
 		earliestAvailableHeight: startHeight,
 		latestAvailableHeight:   currentHeight,
+		index:                   index,
 	}, nil
 }
 
@@ -159,131 +186,262 @@ func (collector *KyveBlockCollector) GetBlock(height int64) ([]byte, error) {
 		return nil, fmt.Errorf("failed to get finalized bundle for block height %d: %w", height, err)
 	}
 
-	deflated, err := utils.GetDataFromFinalizedBundle(*finalizedBundle)
+	bundle, heights, err := collector.getValidatedBundle(finalizedBundle)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get data from finalized bundle with storage id %s: %w", finalizedBundle.StorageId, err)
+		return nil, err
 	}
 
-	// parse bundle
-	var bundle types.Bundle
+	for i, dataItem := range bundle {
+		if heights[i] != height {
+			continue
+		}
 
-	if err := json.Unmarshal(deflated, &bundle); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal bundle: %w", err)
+		return collector.extractBlock(finalizedBundle, heights[i], dataItem)
 	}
 
-	for _, dataItem := range bundle {
-		h, err := strconv.ParseInt(dataItem.Key, 10, 64)
-		if err != nil {
-			return nil, fmt.Errorf("failed parse block height from key %s: %w", dataItem.Key, err)
-		}
+	return nil, newBundleError(collector.poolId, finalizedBundle.Id, finalizedBundle.StorageId, ErrKeyMismatch, "block %d is not in the bundle with keys %s - %s", height, finalizedBundle.FromKey, finalizedBundle.ToKey)
+}
 
-		// skip blocks until we reach start height
-		if h < height {
-			continue
-		}
+// GetBlocks gets all blocks of the bundle which contains the block for the given height
+func (collector *KyveBlockCollector) GetBlocks(height int64) (int64, [][]byte, error) {
+	finalizedBundle, err := collector.getFinalizedBundleForBlockHeight(height)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get finalized bundle for block height %d: %w", height, err)
+	}
 
-		// depending on the runtime the actual block can be nested in the value, before
-		// passing the value to the block executor we extract it
-		block, err := collector.extractRawBlockFromDataItemValue(dataItem.Value)
-		if err != nil {
-			return nil, fmt.Errorf("failed to extract block %d from data item value: %w", height, err)
-		}
+	bundle, heights, err := collector.getValidatedBundle(finalizedBundle)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	blocks := make([][]byte, len(bundle))
 
-		return block, nil
+	for i, dataItem := range bundle {
+		if blocks[i], err = collector.extractBlock(finalizedBundle, heights[i], dataItem); err != nil {
+			return 0, nil, err
+		}
 	}
 
-	return nil, fmt.Errorf("failed to find block %d in finalized bundle %s", height, finalizedBundle.StorageId)
+	return heights[0], blocks, nil
 }
 
 func (collector *KyveBlockCollector) StreamBlocks(blockCh chan<- *types.BlockItem, errorCh chan<- error, continuationHeight, targetHeight int64) {
-	// from the height where the collector should start downloading blocks we derive the pagination
-	// key of the bundles page so we can start from there
-	paginationKey, err := collector.getPaginationKeyForBlockHeight(continuationHeight)
+	// from the height where the collector should start downloading blocks we derive the
+	// bundle id of the first bundle so we can start from there
+	startBundle, err := collector.getFinalizedBundleForBlockHeight(continuationHeight)
 	if err != nil {
-		errorCh <- fmt.Errorf("failed to get pagination key for continuation height %d: %w", continuationHeight, err)
+		errorCh <- fmt.Errorf("failed to get finalized bundle for continuation height %d: %w", continuationHeight, err)
 		return
 	}
 
+	bundleId, err := strconv.ParseInt(startBundle.Id, 10, 64)
+	if err != nil {
+		errorCh <- fmt.Errorf("failed to parse bundle id %s: %w", startBundle.Id, err)
+		return
+	}
+
+	// the to key of the previous bundle, every bundle has to start at the height after it
+	var previousToHeight int64 = -1
+
 BundleCollector:
 	for {
-		bundlesPage, nextKey, err := utils.GetFinalizedBundlesPage(collector.chainRest, collector.poolId, utils.BundlesPageLimit, paginationKey, false)
+		finalizedBundle, err := collector.getFinalizedBundleById(bundleId)
 		if err != nil {
-			errorCh <- fmt.Errorf("failed to get finalized bundles page: %w", err)
+			errorCh <- fmt.Errorf("failed to get finalized bundle with id %d: %w", bundleId, err)
 			return
 		}
 
-		for _, finalizedBundle := range bundlesPage {
-			maxBundleHeight, err := strconv.ParseInt(finalizedBundle.ToKey, 10, 64)
-			if err != nil {
-				errorCh <- fmt.Errorf("failed to parse bundle to key to int64: %w", err)
+		if finalizedBundle == nil {
+			// if we are at the end of the pool we wait for new finalized bundles
+			time.Sleep(30 * time.Second)
+			continue
+		}
+
+		bundleId++
+
+		fromHeight, toHeight, err := collector.parseBundleKeys(finalizedBundle)
+		if err != nil {
+			errorCh <- err
+			return
+		}
+
+		if previousToHeight < 0 {
+			if fromHeight > continuationHeight {
+				errorCh <- newBundleError(collector.poolId, finalizedBundle.Id, finalizedBundle.StorageId, ErrHeightGap, "first bundle starts at height %d after continuation height %d", fromHeight, continuationHeight)
 				return
 			}
+		} else if err := collector.checkBundleContinuity(finalizedBundle, previousToHeight, fromHeight); err != nil {
+			errorCh <- err
+			return
+		}
+
+		previousToHeight = toHeight
 
-			// if the highest height in the bundle is still smaller than our continuation height
-			// we can skip this bundle
-			if maxBundleHeight < continuationHeight {
+		// if the highest height in the bundle is still smaller than our continuation height
+		// we can skip this bundle
+		if toHeight < continuationHeight {
+			continue
+		}
+
+		bundle, heights, err := collector.getValidatedBundle(finalizedBundle)
+		if err != nil {
+			errorCh <- err
+			return
+		}
+
+		for i, dataItem := range bundle {
+			height := heights[i]
+
+			// skip blocks until we reach start height
+			if height < continuationHeight {
 				continue
 			}
 
-			deflated, err := utils.GetDataFromFinalizedBundle(finalizedBundle)
-			if err != nil {
-				errorCh <- fmt.Errorf("failed to get data from finalized bundle with storage id %s: %w", finalizedBundle.StorageId, err)
+			// the bundles are contiguous, so the first block we send has to be the continuation height
+			if height != continuationHeight {
+				errorCh <- newBundleError(collector.poolId, finalizedBundle.Id, finalizedBundle.StorageId, ErrHeightGap, "expected block %d, found %d", continuationHeight, height)
 				return
 			}
 
-			// parse bundle
-			var bundle types.Bundle
-
-			if err := json.Unmarshal(deflated, &bundle); err != nil {
-				errorCh <- fmt.Errorf("failed to unmarshal bundle: %w", err)
+			block, err := collector.extractBlock(finalizedBundle, height, dataItem)
+			if err != nil {
+				errorCh <- err
 				return
 			}
 
-			for _, dataItem := range bundle {
-				height, err := strconv.ParseInt(dataItem.Key, 10, 64)
-				if err != nil {
-					errorCh <- fmt.Errorf("failed parse block height from key %s: %w", dataItem.Key, err)
-					return
-				}
-
-				// skip blocks until we reach start height
-				if height < continuationHeight {
-					continue
-				}
-
-				// depending on the runtime the actual block can be nested in the value, before
-				// passing the value to the block executor we extract it
-				block, err := collector.extractRawBlockFromDataItemValue(dataItem.Value)
-				if err != nil {
-					errorCh <- fmt.Errorf("failed to extract block %d from data item value: %w", height, err)
-				}
-
-				// send block to block executor
-				blockCh <- &types.BlockItem{
-					Height: height,
-					Block:  block,
-				}
-
-				// update continuation height
-				continuationHeight = height + 1
-
-				// exit if target height is reached
-				if targetHeight > 0 && height >= targetHeight+1 {
-					break BundleCollector
-				}
+			// send block to block executor
+			blockCh <- &types.BlockItem{
+				Height: height,
+				Block:  block,
+			}
+
+			// update continuation height
+			continuationHeight = height + 1
+
+			// exit if target height is reached
+			if targetHeight > 0 && height >= targetHeight+1 {
+				break BundleCollector
 			}
 		}
+	}
+}
 
-		if nextKey == "" {
-			// if we are at the end of the page we continue and wait for
-			// new finalized bundles
-			time.Sleep(30 * time.Second)
-			continue
+// getValidatedBundle downloads the bundle and checks that its data items are exactly the contiguous
+// heights from the from key to the to key of the finalized bundle. The heights of the data items
+// are returned along with the bundle
+func (collector *KyveBlockCollector) getValidatedBundle(finalizedBundle *types.FinalizedBundle) (types.Bundle, []int64, error) {
+	fromHeight, toHeight, err := collector.parseBundleKeys(finalizedBundle)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deflated, err := getDataFromFinalizedBundle(collector.poolId, *finalizedBundle)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get data from finalized bundle with storage id %s: %w", finalizedBundle.StorageId, err)
+	}
+
+	// parse bundle
+	var bundle types.Bundle
+
+	if err := json.Unmarshal(deflated, &bundle); err != nil {
+		return nil, nil, utils.NewClassErrorf(utils.ErrDecode, "failed to unmarshal bundle with storage id %s: %w", finalizedBundle.StorageId, err)
+	}
+
+	heights, err := collector.validateBundle(finalizedBundle, bundle, fromHeight, toHeight)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return bundle, heights, nil
+}
+
+func (collector *KyveBlockCollector) validateBundle(finalizedBundle *types.FinalizedBundle, bundle types.Bundle, fromHeight, toHeight int64) ([]int64, error) {
+	bundleError := func(kind error, format string, args ...any) error {
+		return newBundleError(collector.poolId, finalizedBundle.Id, finalizedBundle.StorageId, kind, format, args...)
+	}
+
+	if len(bundle) == 0 {
+		return nil, bundleError(ErrKeyMismatch, "bundle with keys %s - %s has no data items", finalizedBundle.FromKey, finalizedBundle.ToKey)
+	}
+
+	heights := make([]int64, len(bundle))
+
+	for i, dataItem := range bundle {
+		height, err := strconv.ParseInt(dataItem.Key, 10, 64)
+		if err != nil {
+			return nil, bundleError(ErrKeyMismatch, "failed to parse block height from data item key %s", dataItem.Key)
 		}
 
-		time.Sleep(utils.RequestTimeoutMS)
-		paginationKey = nextKey
+		if i == 0 {
+			if height != fromHeight {
+				return nil, bundleError(ErrKeyMismatch, "first data item has height %d but the from key is %s", height, finalizedBundle.FromKey)
+			}
+		} else if previous := heights[i-1]; height <= previous {
+			return nil, bundleError(ErrDuplicateHeight, "data item %d has height %d after height %d", i, height, previous)
+		} else if height > previous+1 {
+			return nil, bundleError(ErrHeightGap, "%s missing between data items %d and %d", formatHeightRange(previous+1, height-1), i-1, i)
+		}
+
+		heights[i] = height
+	}
+
+	if last := heights[len(heights)-1]; last != toHeight {
+		return nil, bundleError(ErrKeyMismatch, "last data item has height %d but the to key is %s", last, finalizedBundle.ToKey)
+	}
+
+	return heights, nil
+}
+
+// checkBundleContinuity checks that the bundle starts at the height after the previous bundle
+func (collector *KyveBlockCollector) checkBundleContinuity(finalizedBundle *types.FinalizedBundle, previousToHeight, fromHeight int64) error {
+	switch {
+	case fromHeight > previousToHeight+1:
+		return newBundleError(collector.poolId, finalizedBundle.Id, finalizedBundle.StorageId, ErrHeightGap, "%s missing between this and the previous bundle", formatHeightRange(previousToHeight+1, fromHeight-1))
+	case fromHeight <= previousToHeight:
+		return newBundleError(collector.poolId, finalizedBundle.Id, finalizedBundle.StorageId, ErrDuplicateHeight, "bundle starts at height %d which was already contained in the previous bundle ending at %d", fromHeight, previousToHeight)
+	default:
+		return nil
+	}
+}
+
+func formatHeightRange(from, to int64) string {
+	if from == to {
+		return fmt.Sprintf("height %d is", from)
+	}
+	return fmt.Sprintf("heights %d to %d are", from, to)
+}
+
+func (collector *KyveBlockCollector) parseBundleKeys(finalizedBundle *types.FinalizedBundle) (int64, int64, error) {
+	fromHeight, err := strconv.ParseInt(finalizedBundle.FromKey, 10, 64)
+	if err != nil {
+		return 0, 0, newBundleError(collector.poolId, finalizedBundle.Id, finalizedBundle.StorageId, ErrKeyMismatch, "failed to parse from key %s", finalizedBundle.FromKey)
+	}
+
+	toHeight, err := strconv.ParseInt(finalizedBundle.ToKey, 10, 64)
+	if err != nil {
+		return 0, 0, newBundleError(collector.poolId, finalizedBundle.Id, finalizedBundle.StorageId, ErrKeyMismatch, "failed to parse to key %s", finalizedBundle.ToKey)
+	}
+
+	if toHeight < fromHeight {
+		return 0, 0, newBundleError(collector.poolId, finalizedBundle.Id, finalizedBundle.StorageId, ErrKeyMismatch, "to key %s is before from key %s", finalizedBundle.ToKey, finalizedBundle.FromKey)
+	}
+
+	return fromHeight, toHeight, nil
+}
+
+// extractBlock extracts the block from the data item, depending on the runtime the actual block
+// can be nested in the value. Data items without a block can not be executed and fail here
+func (collector *KyveBlockCollector) extractBlock(finalizedBundle *types.FinalizedBundle, height int64, dataItem types.DataItem) ([]byte, error) {
+	block, err := collector.extractRawBlockFromDataItemValue(dataItem.Value)
+	if err != nil {
+		return nil, newBundleError(collector.poolId, finalizedBundle.Id, finalizedBundle.StorageId, ErrInvalidBlock, "failed to extract block %d from data item value: %s", height, err)
+	}
+
+	if len(block) == 0 || string(block) == "null" {
+		return nil, newBundleError(collector.poolId, finalizedBundle.Id, finalizedBundle.StorageId, ErrInvalidBlock, "data item of block %d contains no block", height)
 	}
+
+	return block, nil
 }
 
 func (collector *KyveBlockCollector) extractRawBlockFromDataItemValue(value []byte) ([]byte, error) {
@@ -310,57 +468,90 @@ func (collector *KyveBlockCollector) extractRawBlockFromDataItemValue(value []by
 
 // getFinalizedBundleForBlockHeight gets the bundle which contains the block for the given height
 func (collector *KyveBlockCollector) getFinalizedBundleForBlockHeight(height int64) (*types.FinalizedBundle, error) {
+	if collector.index != nil {
+		finalizedBundle, err := collector.index.getForHeight(height)
+		if err != nil {
+			logger.Logger.Debug().Msgf("failed to look up block height %d in bundle index: %s", height, err)
+		} else if finalizedBundle != nil {
+			return finalizedBundle, nil
+		}
+	}
+
 	// the index is an incremental id for each data item. Since the index starts from zero
 	// and the start key is usually 1 we subtract it from the specified height so we get
 	// the correct index
-	index := height - collector.earliestAvailableHeight
-
-	raw, err := utils.GetFromUrl(fmt.Sprintf(
-		"%s/kyve/v1/bundles/%d?index=%d",
-		collector.chainRest,
-		collector.poolId,
-		index,
-	))
+	finalizedBundle, err := utils.GetFinalizedBundleByIndex(collector.chainRest, collector.poolId, height-collector.earliestAvailableHeight)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get finalized bundle for index %d: %w", index, err)
+		return nil, fmt.Errorf("failed to find finalized bundle for block height %d: %w", height, err)
 	}
 
-	var bundlesResponse types.FinalizedBundlesResponse
+	collector.indexBundles([]types.FinalizedBundle{*finalizedBundle})
+	return finalizedBundle, nil
+}
 
-	if err := json.Unmarshal(raw, &bundlesResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal finalized bundles response: %w", err)
+// getFinalizedBundleById gets the bundle with the given id. If the bundle is not indexed yet
+// we fetch a whole page of bundles starting from it and add them to the index, so following
+// bundles are already known. If the bundle is not finalized yet nil is returned
+func (collector *KyveBlockCollector) getFinalizedBundleById(bundleId int64) (*types.FinalizedBundle, error) {
+	if collector.index != nil {
+		finalizedBundle, err := collector.index.get(bundleId)
+		if err != nil {
+			logger.Logger.Debug().Msgf("failed to look up bundle %d in bundle index: %s", bundleId, err)
+		} else if finalizedBundle != nil {
+			return finalizedBundle, nil
+		}
+	}
+
+	if finalizedBundle := collector.getFromPage(bundleId); finalizedBundle != nil {
+		return finalizedBundle, nil
+	}
+
+	bundlesPage, _, err := utils.GetFinalizedBundlesPageWithOffset(collector.chainRest, collector.poolId, utils.BundlesPageLimit, bundleId, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get finalized bundles page: %w", err)
+	}
+
+	if len(bundlesPage) == 0 {
+		return nil, nil
 	}
 
-	if len(bundlesResponse.FinalizedBundles) == 1 {
-		return &bundlesResponse.FinalizedBundles[0], nil
+	if bundlesPage[0].Id != strconv.FormatInt(bundleId, 10) {
+		return nil, fmt.Errorf("expected finalized bundle with id %d, found %s", bundleId, bundlesPage[0].Id)
 	}
 
-	return nil, fmt.Errorf("failed to find finalized bundle for block height %d: %w", height, err)
+	collector.page = bundlesPage
+	collector.indexBundles(bundlesPage)
+	return &bundlesPage[0], nil
 }
 
-// getPaginationKeyForBlockHeight gets the pagination key right for the bundle so the StartBlockCollector can
-// directly start at the correct bundle. Therefore, it does not need to search through all the bundles until
-// it finds the correct one
-func (collector *KyveBlockCollector) getPaginationKeyForBlockHeight(height int64) (string, error) {
-	finalizedBundle, err := collector.getFinalizedBundleForBlockHeight(height)
-	if err != nil {
-		return "", fmt.Errorf("failed to get finalized bundle for block height %d: %w", height, err)
+// getFromPage returns the bundle from the last fetched page, since the page starts at the
+// requested bundle id and finalized bundles have consecutive ids we can look it up directly
+func (collector *KyveBlockCollector) getFromPage(bundleId int64) *types.FinalizedBundle {
+	if len(collector.page) == 0 {
+		return nil
 	}
 
-	bundleId, err := strconv.ParseInt(finalizedBundle.Id, 10, 64)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse bundle id %s: %w", finalizedBundle.Id, err)
+	firstId, err := strconv.ParseInt(collector.page[0].Id, 10, 64)
+	if err != nil || bundleId < firstId || bundleId-firstId >= int64(len(collector.page)) {
+		return nil
 	}
 
-	// if bundleId is zero we start from the beginning, meaning the paginationKey should be empty
-	if bundleId == 0 {
-		return "", nil
+	finalizedBundle := &collector.page[bundleId-firstId]
+	if finalizedBundle.Id != strconv.FormatInt(bundleId, 10) {
+		return nil
 	}
 
-	_, paginationKey, err := utils.GetFinalizedBundlesPageWithOffset(collector.chainRest, collector.poolId, 1, bundleId-1, "", false)
-	if err != nil {
-		return "", fmt.Errorf("failed to get finalized bundles: %w", err)
+	return finalizedBundle
+}
+
+// indexBundles adds the bundles to the index, errors are only logged since
+// the index is just a cache
+func (collector *KyveBlockCollector) indexBundles(bundles []types.FinalizedBundle) {
+	if collector.index == nil {
+		return
 	}
 
-	return paginationKey, nil
+	if err := collector.index.put(bundles...); err != nil {
+		logger.Logger.Debug().Msgf("failed to add bundles to bundle index: %s", err)
+	}
 }
@@ -0,0 +1,199 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+)
+
+func newTestBlockCollector(pool *fakePool) *KyveBlockCollector {
+	return &KyveBlockCollector{
+		poolId:                  0,
+		runtime:                 utils.RuntimeTendermintBsync,
+		chainRest:               pool.server.URL,
+		earliestAvailableHeight: 1,
+		latestAvailableHeight:   1,
+	}
+}
+
+// streamBlocks streams the blocks until the collector reports an error
+// and returns the heights which were sent before
+func streamBlocks(t *testing.T, collector *KyveBlockCollector, continuationHeight, targetHeight int64) ([]int64, error) {
+	t.Helper()
+
+	blockCh := make(chan *types.BlockItem, utils.BlockBuffer)
+	errorCh := make(chan error)
+
+	go collector.StreamBlocks(blockCh, errorCh, continuationHeight, targetHeight)
+
+	var heights []int64
+
+	for {
+		select {
+		case block := <-blockCh:
+			if block.Block == nil {
+				t.Fatalf("received nil block for height %d", block.Height)
+			}
+			heights = append(heights, block.Height)
+		case err := <-errorCh:
+			// the blocks sent before the error can still be buffered
+			for len(blockCh) > 0 {
+				heights = append(heights, (<-blockCh).Height)
+			}
+			return heights, err
+		case <-time.After(10 * time.Second):
+			t.Fatalf("collector did neither send blocks nor fail, received heights %v", heights)
+		}
+	}
+}
+
+func TestStreamBlocksContinuity(t *testing.T) {
+	tests := []struct {
+		name               string
+		bundles            []fakeBundle
+		continuationHeight int64
+		expectedHeights    []int64
+		expectedKind       error
+	}{
+		{
+			name: "gap between bundles",
+			bundles: []fakeBundle{
+				{items: blockItems(1, 2, 3)},
+				{items: blockItems(5, 6)},
+			},
+			continuationHeight: 1,
+			expectedHeights:    []int64{1, 2, 3},
+			expectedKind:       ErrHeightGap,
+		},
+		{
+			name: "gap inside the first bundle",
+			bundles: []fakeBundle{
+				{items: blockItems(1, 3, 4)},
+			},
+			continuationHeight: 2,
+			expectedKind:       ErrHeightGap,
+		},
+		{
+			name: "overlapping bundles",
+			bundles: []fakeBundle{
+				{items: blockItems(1, 2, 3)},
+				{items: blockItems(3, 4)},
+			},
+			continuationHeight: 1,
+			expectedHeights:    []int64{1, 2, 3},
+			expectedKind:       ErrDuplicateHeight,
+		},
+		{
+			name: "duplicate height inside a bundle",
+			bundles: []fakeBundle{
+				{items: blockItems(1, 2, 2, 3)},
+			},
+			continuationHeight: 1,
+			expectedKind:       ErrDuplicateHeight,
+		},
+		{
+			name: "to key does not match the content",
+			bundles: []fakeBundle{
+				{toKey: "4", items: blockItems(1, 2, 3)},
+			},
+			continuationHeight: 1,
+			expectedKind:       ErrKeyMismatch,
+		},
+		{
+			name: "data item without block",
+			bundles: []fakeBundle{
+				{items: []types.DataItem{{Key: "1", Value: []byte("null")}}},
+			},
+			continuationHeight: 1,
+			expectedKind:       ErrInvalidBlock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newFakePool(t, utils.RuntimeTendermintBsync, "{}", tt.bundles)
+
+			heights, err := streamBlocks(t, newTestBlockCollector(pool), tt.continuationHeight, 0)
+
+			if !errors.Is(err, tt.expectedKind) || !errors.Is(err, utils.ErrBlockValidation) {
+				t.Fatalf("expected %v, found %v", tt.expectedKind, err)
+			}
+
+			var bundleError *BundleError
+			if !errors.As(err, &bundleError) || bundleError.PoolId != 0 || bundleError.StorageId == "" {
+				t.Fatalf("expected bundle error with pool and storage id, found %#v", err)
+			}
+
+			if len(heights) != len(tt.expectedHeights) {
+				t.Fatalf("expected heights %v, found %v", tt.expectedHeights, heights)
+			}
+			for i := range heights {
+				if heights[i] != tt.expectedHeights[i] {
+					t.Fatalf("expected heights %v, found %v", tt.expectedHeights, heights)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamBlocksUntilTarget(t *testing.T) {
+	pool := newFakePool(t, utils.RuntimeTendermintBsync, "{}", []fakeBundle{
+		{items: blockItems(1, 2, 3)},
+		{items: blockItems(4, 5, 6)},
+		{items: blockItems(7, 8, 9)},
+	})
+
+	blockCh := make(chan *types.BlockItem, utils.BlockBuffer)
+	errorCh := make(chan error, 1)
+
+	newTestBlockCollector(pool).StreamBlocks(blockCh, errorCh, 2, 6)
+	close(blockCh)
+
+	select {
+	case err := <-errorCh:
+		t.Fatal(err)
+	default:
+	}
+
+	var heights []int64
+	for block := range blockCh {
+		heights = append(heights, block.Height)
+	}
+
+	// the block after the target height is needed to apply the target height
+	if len(heights) != 6 || heights[0] != 2 || heights[5] != 7 {
+		t.Fatalf("expected heights 2 to 7, found %v", heights)
+	}
+
+	// without an index all bundles are taken from the first page
+	if pages := pool.pageCount(); pages != 1 {
+		t.Fatalf("expected 1 page of finalized bundles to be requested, found %d", pages)
+	}
+}
+
+func TestGetBlocksReturnsBundle(t *testing.T) {
+	pool := newFakePool(t, utils.RuntimeTendermintBsync, "{}", []fakeBundle{
+		{items: blockItems(1, 2, 3)},
+		{items: blockItems(4, 5, 6)},
+		{items: blockItems(7, 8, 9)},
+	})
+
+	from, blocks, err := newTestBlockCollector(pool).GetBlocks(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if from != 4 || len(blocks) != 3 {
+		t.Fatalf("expected blocks 4 to 6, found %d blocks from %d", len(blocks), from)
+	}
+
+	for i, block := range blocks {
+		if expected := fmt.Sprintf(`{"header":{"height":"%d"}}`, from+int64(i)); string(block) != expected {
+			t.Fatalf("expected block %s, found %s", expected, block)
+		}
+	}
+}
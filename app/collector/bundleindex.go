@@ -0,0 +1,180 @@
+package collector
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+	dbm "github.com/cometbft/cometbft-db"
+	"strconv"
+	"sync"
+)
+
+var (
+	bundleKeyPrefix = []byte("b/")
+	heightKeyPrefix = []byte("h/")
+)
+
+// openIndexes holds the indexes opened by this process, since the underlying
+// database can only be opened once collectors of the same pool share it
+var (
+	openIndexes   = make(map[string]*bundleIndex)
+	openIndexesMu sync.Mutex
+)
+
+// bundleIndex stores the key ranges of the finalized bundles of a block pool in a
+// local key value store. Bundles are stored by their id and additionally referenced
+// by the height of their to key, so the bundle containing a height is found with a
+// single seek. Since finalized bundles never change the index is only ever extended
+type bundleIndex struct {
+	db dbm.DB
+}
+
+// openBundleIndex opens the index of the given pool. The chain rest endpoint is part
+// of the name since pool ids are only unique within a KYVE chain
+func openBundleIndex(chainRest string, poolId int64) (*bundleIndex, error) {
+	dir, err := utils.GetKsyncDir("index")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundle index directory: %w", err)
+	}
+
+	name := fmt.Sprintf("bundles-%s-%d", utils.CreateSha256Checksum([]byte(chainRest))[:16], poolId)
+
+	openIndexesMu.Lock()
+	defer openIndexesMu.Unlock()
+
+	if index, found := openIndexes[name]; found {
+		return index, nil
+	}
+
+	db, err := dbm.NewDB(name, dbm.GoLevelDBBackend, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle index %s: %w", name, err)
+	}
+
+	openIndexes[name] = &bundleIndex{db: db}
+	return openIndexes[name], nil
+}
+
+// CloseBundleIndexes closes the indexes opened by this process, afterward
+// collectors which were created before continue without their index
+func CloseBundleIndexes() {
+	openIndexesMu.Lock()
+	defer openIndexesMu.Unlock()
+
+	for name, index := range openIndexes {
+		if err := index.db.Close(); err != nil {
+			logger.Logger.Debug().Msgf("failed to close bundle index %s: %s", name, err)
+		}
+		delete(openIndexes, name)
+	}
+}
+
+// get returns the bundle with the given id if it is indexed
+func (index *bundleIndex) get(bundleId int64) (*types.FinalizedBundle, error) {
+	value, err := index.db.Get(bundleKey(bundleId))
+	if err != nil || value == nil {
+		return nil, err
+	}
+
+	var bundle types.FinalizedBundle
+	if err := json.Unmarshal(value, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal indexed bundle %d: %w", bundleId, err)
+	}
+
+	return &bundle, nil
+}
+
+// getForHeight returns the indexed bundle which contains the given height
+func (index *bundleIndex) getForHeight(height int64) (*types.FinalizedBundle, error) {
+	it, err := index.db.Iterator(heightKey(height), prefixEnd(heightKeyPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle index iterator: %w", err)
+	}
+	defer it.Close()
+
+	if !it.Valid() {
+		return nil, it.Error()
+	}
+
+	bundle, err := index.get(int64(binary.BigEndian.Uint64(it.Value())))
+	if err != nil || bundle == nil {
+		return nil, err
+	}
+
+	// the bundle with the next to key does not necessarily contain the height
+	// if the bundles in between are not indexed yet
+	fromHeight, err := strconv.ParseInt(bundle.FromKey, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse bundle from key %s: %w", bundle.FromKey, err)
+	}
+
+	if fromHeight > height {
+		return nil, nil
+	}
+
+	return bundle, nil
+}
+
+// put adds the bundles to the index
+func (index *bundleIndex) put(bundles ...types.FinalizedBundle) error {
+	batch := index.db.NewBatch()
+	defer batch.Close()
+
+	for _, bundle := range bundles {
+		bundleId, err := strconv.ParseInt(bundle.Id, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse bundle id %s: %w", bundle.Id, err)
+		}
+
+		toHeight, err := strconv.ParseInt(bundle.ToKey, 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse bundle to key %s: %w", bundle.ToKey, err)
+		}
+
+		// only keep the fields required to retrieve and verify the bundle data
+		value, err := json.Marshal(types.FinalizedBundle{
+			PoolId:            bundle.PoolId,
+			Id:                bundle.Id,
+			StorageId:         bundle.StorageId,
+			StorageProviderId: bundle.StorageProviderId,
+			CompressionId:     bundle.CompressionId,
+			FromKey:           bundle.FromKey,
+			ToKey:             bundle.ToKey,
+			DataHash:          bundle.DataHash,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal bundle %s: %w", bundle.Id, err)
+		}
+
+		if err := batch.Set(bundleKey(bundleId), value); err != nil {
+			return fmt.Errorf("failed to index bundle %s: %w", bundle.Id, err)
+		}
+
+		idValue := make([]byte, 8)
+		binary.BigEndian.PutUint64(idValue, uint64(bundleId))
+
+		if err := batch.Set(heightKey(toHeight), idValue); err != nil {
+			return fmt.Errorf("failed to index bundle %s: %w", bundle.Id, err)
+		}
+	}
+
+	return batch.Write()
+}
+
+func bundleKey(bundleId int64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, bundleKeyPrefix...), uint64(bundleId))
+}
+
+func heightKey(height int64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, heightKeyPrefix...), uint64(height))
+}
+
+// prefixEnd returns the first key after all keys with the given prefix
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	end[len(end)-1]++
+	return end
+}
@@ -0,0 +1,44 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KYVENetwork/ksync/utils"
+)
+
+// kinds of invalid pool data, wrapped in a BundleError so they can be checked with errors.Is
+var (
+	ErrHeightGap       = errors.New("height gap")
+	ErrDuplicateHeight = errors.New("duplicate height")
+	ErrKeyMismatch     = errors.New("key mismatch")
+	ErrInvalidBlock    = errors.New("invalid block")
+)
+
+// BundleError describes invalid data in a finalized bundle of a pool
+type BundleError struct {
+	PoolId    int64
+	BundleId  string
+	StorageId string
+	Kind      error
+	Detail    string
+}
+
+func newBundleError(poolId int64, bundleId, storageId string, kind error, format string, args ...any) *BundleError {
+	return &BundleError{
+		PoolId:    poolId,
+		BundleId:  bundleId,
+		StorageId: storageId,
+		Kind:      kind,
+		Detail:    fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *BundleError) Error() string {
+	return fmt.Sprintf("%s in bundle %s of pool %d with storage id %s: %s", e.Kind, e.BundleId, e.PoolId, e.StorageId, e.Detail)
+}
+
+// Unwrap makes the kind and the class of the error available for errors.Is, all
+// kinds of invalid pool data are block validation errors
+func (e *BundleError) Unwrap() []error {
+	return []error{e.Kind, utils.ErrBlockValidation}
+}
@@ -0,0 +1,210 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	cmtjson "github.com/KYVENetwork/cometbft/v34/libs/json"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+)
+
+// fakeBundle is a finalized bundle of the fake pool, the keys default
+// to the keys of the first and last data item
+type fakeBundle struct {
+	fromKey string
+	toKey   string
+	summary string
+	items   []types.DataItem
+}
+
+// fakePool serves the REST endpoints of the KYVE chain and the storage
+// provider for a single pool
+type fakePool struct {
+	server  *httptest.Server
+	pool    types.PoolResponse
+	bundles []types.FinalizedBundle
+	data    map[string][]byte
+
+	mu            sync.Mutex
+	pages         int
+	bundleQueries int
+}
+
+func newFakePool(t *testing.T, runtime, config string, bundles []fakeBundle) *fakePool {
+	t.Helper()
+
+	pool := &fakePool{
+		data: make(map[string][]byte),
+	}
+
+	for i, bundle := range bundles {
+		raw, err := json.Marshal(bundle.items)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var compressed bytes.Buffer
+		w := gzip.NewWriter(&compressed)
+		_, _ = w.Write(raw)
+		_ = w.Close()
+
+		fromKey, toKey := bundle.fromKey, bundle.toKey
+		if fromKey == "" && len(bundle.items) > 0 {
+			fromKey = bundle.items[0].Key
+		}
+		if toKey == "" && len(bundle.items) > 0 {
+			toKey = bundle.items[len(bundle.items)-1].Key
+		}
+
+		storageId := fmt.Sprintf("storage-%d", i)
+		pool.data[storageId] = compressed.Bytes()
+		pool.bundles = append(pool.bundles, types.FinalizedBundle{
+			PoolId:            "0",
+			Id:                strconv.Itoa(i),
+			StorageId:         storageId,
+			StorageProviderId: "3",
+			CompressionId:     "1",
+			FromKey:           fromKey,
+			ToKey:             toKey,
+			DataHash:          utils.CreateSha256Checksum(compressed.Bytes()),
+			BundleSummary:     bundle.summary,
+		})
+	}
+
+	pool.pool.Pool.Data.Runtime = runtime
+	pool.pool.Pool.Data.Config = config
+	pool.pool.Pool.Data.TotalBundles = int64(len(pool.bundles))
+	if len(pool.bundles) > 0 {
+		pool.pool.Pool.Data.StartKey = pool.bundles[0].FromKey
+		pool.pool.Pool.Data.CurrentKey = pool.bundles[len(pool.bundles)-1].ToKey
+		pool.pool.Pool.Data.CurrentSummary = pool.bundles[len(pool.bundles)-1].BundleSummary
+	}
+
+	pool.server = httptest.NewServer(http.HandlerFunc(pool.serveHTTP))
+	t.Cleanup(pool.server.Close)
+
+	storageRest, chainGrpc := flags.StorageRest, flags.ChainGrpc
+	flags.StorageRest, flags.ChainGrpc = pool.server.URL+"/storage", ""
+	t.Cleanup(func() { flags.StorageRest, flags.ChainGrpc = storageRest, chainGrpc })
+
+	return pool
+}
+
+// pageCount returns how many pages of finalized bundles were requested
+func (pool *fakePool) pageCount() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.pages
+}
+
+// bundleQueryCount returns how many finalized bundles were requested by id
+func (pool *fakePool) bundleQueryCount() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.bundleQueries
+}
+
+func (pool *fakePool) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	query := r.URL.Query()
+
+	switch {
+	case len(path) == 2 && path[0] == "storage":
+		data, found := pool.data[path[1]]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	case strings.HasPrefix(r.URL.Path, "/kyve/query/v1beta1/pool/"):
+		pool.writeJson(w, pool.pool)
+	case len(path) == 5 && path[1] == "v1" && path[2] == "bundles":
+		pool.mu.Lock()
+		pool.bundleQueries++
+		pool.mu.Unlock()
+
+		id, _ := strconv.Atoi(path[4])
+		if id >= len(pool.bundles) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		pool.writeJson(w, pool.bundles[id])
+	case len(path) == 4 && path[1] == "v1" && path[2] == "bundles" && query.Has("index"):
+		index, _ := strconv.ParseInt(query.Get("index"), 10, 64)
+		bundle := pool.findBundleForIndex(index)
+		if bundle == nil {
+			pool.writeJson(w, types.FinalizedBundlesResponse{FinalizedBundles: []types.FinalizedBundle{}})
+			return
+		}
+		pool.writeJson(w, types.FinalizedBundlesResponse{FinalizedBundles: []types.FinalizedBundle{*bundle}})
+	case len(path) == 4 && path[1] == "v1" && path[2] == "bundles":
+		pool.mu.Lock()
+		pool.pages++
+		pool.mu.Unlock()
+
+		limit, _ := strconv.Atoi(query.Get("pagination.limit"))
+		offset, _ := strconv.Atoi(query.Get("pagination.offset"))
+
+		page := make([]types.FinalizedBundle, 0)
+		for i := offset; i < len(pool.bundles) && len(page) < limit; i++ {
+			page = append(page, pool.bundles[i])
+		}
+		pool.writeJson(w, types.FinalizedBundlesResponse{FinalizedBundles: page})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// findBundleForIndex returns the bundle which contains the data item with the index,
+// like the chain the index is derived from the keys of the bundles
+func (pool *fakePool) findBundleForIndex(index int64) *types.FinalizedBundle {
+	if len(pool.bundles) == 0 {
+		return nil
+	}
+
+	start, _ := strconv.ParseInt(pool.bundles[0].FromKey, 10, 64)
+
+	for i := range pool.bundles {
+		to, err := strconv.ParseInt(pool.bundles[i].ToKey, 10, 64)
+		if err == nil && start+index <= to {
+			return &pool.bundles[i]
+		}
+	}
+	return nil
+}
+
+// writeJson encodes like the chain, integers are encoded as strings
+func (pool *fakePool) writeJson(w http.ResponseWriter, v any) {
+	data, err := cmtjson.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+// blockItems creates the data items of a block pool for the heights
+func blockItems(heights ...int64) []types.DataItem {
+	items := make([]types.DataItem, 0, len(heights))
+	for _, height := range heights {
+		items = append(items, types.DataItem{
+			Key:   strconv.FormatInt(height, 10),
+			Value: json.RawMessage(fmt.Sprintf(`{"header":{"height":"%d"}}`, height)),
+		})
+	}
+	return items
+}
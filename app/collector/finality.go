@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/KYVENetwork/ksync/app/finality"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+)
+
+// verifyPool verifies the pool against the KYVE chain state if the
+// finality of bundles should be verified
+func verifyPool(poolResponse *types.PoolResponse) error {
+	verifier, err := finality.GetVerifier()
+	if err != nil {
+		return fmt.Errorf("failed to create finality verifier: %w", err)
+	}
+
+	if verifier == nil {
+		return nil
+	}
+
+	return verifier.VerifyPool(poolResponse)
+}
+
+// getDataFromFinalizedBundle downloads the data of the bundle. If the finality of bundles
+// should be verified the bundle is verified against the KYVE chain state first, so the
+// data hash the downloaded data gets checked against can be trusted
+func getDataFromFinalizedBundle(poolId int64, bundle types.FinalizedBundle) ([]byte, error) {
+	verifier, err := finality.GetVerifier()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create finality verifier: %w", err)
+	}
+
+	if verifier != nil {
+		if err := verifier.VerifyBundle(poolId, bundle); err != nil {
+			return nil, fmt.Errorf("failed to verify finality of bundle %s: %w", bundle.Id, err)
+		}
+	}
+
+	return utils.GetDataFromFinalizedBundle(bundle)
+}
@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/utils"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// snapshotIndex maps snapshot heights to the bundle id of their first chunk. Since
+// finalized bundles never change the index is persisted in the KSYNC directory and
+// shared across runs, so known snapshots can be found without any request
+type snapshotIndex struct {
+	path    string
+	Heights map[int64]int64 `json:"heights"`
+}
+
+// loadSnapshotIndex loads the index of the given pool. The chain rest endpoint is part
+// of the file name since pool ids are only unique within a KYVE chain. If the index can
+// not be loaded we continue with an empty index which is not persisted
+func loadSnapshotIndex(chainRest string, poolId int64) *snapshotIndex {
+	index := &snapshotIndex{Heights: make(map[int64]int64)}
+
+	dir, err := utils.GetKsyncDir("index")
+	if err != nil {
+		logger.Logger.Debug().Msgf("failed to get snapshot index directory: %s", err)
+		return index
+	}
+
+	index.path = filepath.Join(dir, fmt.Sprintf("snapshots-%s-%d.json", utils.CreateSha256Checksum([]byte(chainRest))[:16], poolId))
+
+	data, err := os.ReadFile(index.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Logger.Debug().Msgf("failed to read snapshot index %s: %s", index.path, err)
+		}
+		return index
+	}
+
+	if err := json.Unmarshal(data, index); err != nil || index.Heights == nil {
+		logger.Logger.Debug().Msgf("failed to unmarshal snapshot index %s, starting with an empty index", index.path)
+		index.Heights = make(map[int64]int64)
+	}
+
+	return index
+}
+
+func (index *snapshotIndex) get(height int64) (int64, bool) {
+	bundleId, found := index.Heights[height]
+	return bundleId, found
+}
+
+func (index *snapshotIndex) set(height, bundleId int64) {
+	index.Heights[height] = bundleId
+}
+
+// neighbours returns the closest indexed snapshots below and above the height
+// as (height, bundle id) pairs. The returned flags indicate if one was found
+func (index *snapshotIndex) neighbours(height int64) (lower [2]int64, foundLower bool, upper [2]int64, foundUpper bool) {
+	for h, bundleId := range index.Heights {
+		if h < height && (!foundLower || h > lower[0]) {
+			lower, foundLower = [2]int64{h, bundleId}, true
+		}
+
+		if h > height && (!foundUpper || h < upper[0]) {
+			upper, foundUpper = [2]int64{h, bundleId}, true
+		}
+	}
+
+	return
+}
+
+// save writes the index atomically, errors are only logged since
+// the index is just a cache
+func (index *snapshotIndex) save() {
+	if index.path == "" {
+		return
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		logger.Logger.Debug().Msgf("failed to marshal snapshot index: %s", err)
+		return
+	}
+
+	tmpPath := index.path + "." + strconv.Itoa(os.Getpid()) + ".tmp"
+
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		logger.Logger.Debug().Msgf("failed to write snapshot index %s: %s", tmpPath, err)
+		return
+	}
+
+	if err := os.Rename(tmpPath, index.path); err != nil {
+		logger.Logger.Debug().Msgf("failed to move snapshot index to %s: %s", index.path, err)
+	}
+}
@@ -3,10 +3,15 @@ package collector
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/KYVENetwork/ksync/logger"
 	"github.com/KYVENetwork/ksync/types"
 	"github.com/KYVENetwork/ksync/utils"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -18,6 +23,8 @@ type KyveSnapshotCollector struct {
 	latestAvailableHeight   int64
 	interval                int64
 	totalBundles            int64
+	currentHeight           int64
+	currentChunkIndex       int64
 }
 
 func NewKyveSnapshotCollector(poolId int64, chainRest string) (*KyveSnapshotCollector, error) {
@@ -26,6 +33,10 @@ func NewKyveSnapshotCollector(poolId int64, chainRest string) (*KyveSnapshotColl
 		return nil, fmt.Errorf("fail to get pool with id %d: %w", poolId, err)
 	}
 
+	if err := verifyPool(poolResponse); err != nil {
+		return nil, fmt.Errorf("failed to verify pool with id %d: %w", poolId, err)
+	}
+
 	if poolResponse.Pool.Data.Runtime != utils.RuntimeTendermintSsync {
 		return nil, fmt.Errorf("found invalid runtime on snapshot pool %d: Expected = %s Found = %s", poolId, utils.RuntimeTendermintSsync, poolResponse.Pool.Data.Runtime)
 	}
@@ -68,6 +79,8 @@ func NewKyveSnapshotCollector(poolId int64, chainRest string) (*KyveSnapshotColl
 		latestAvailableHeight:   latestAvailableHeight,
 		interval:                config.Interval,
 		totalBundles:            poolResponse.Pool.Data.TotalBundles,
+		currentHeight:           currentHeight,
+		currentChunkIndex:       chunkIndex,
 	}, nil
 }
 
@@ -120,18 +133,18 @@ func (collector *KyveSnapshotCollector) GetSnapshotFromBundleId(bundleId int64)
 		return nil, fmt.Errorf("failed getting finalized bundle by id %d: %w", bundleId, err)
 	}
 
-	data, err := utils.GetDataFromFinalizedBundle(*chunkBundleFinalized)
+	data, err := getDataFromFinalizedBundle(collector.poolId, *chunkBundleFinalized)
 	if err != nil {
 		return nil, fmt.Errorf("failed getting data from finalized bundle: %w", err)
 	}
 
 	var bundle types.SnapshotBundle
 	if err := json.Unmarshal(data, &bundle); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal snapshot bundle: %w", err)
+		return nil, utils.NewClassErrorf(utils.ErrDecode, "failed to unmarshal snapshot bundle: %w", err)
 	}
 
 	if len(bundle) != 1 {
-		return nil, fmt.Errorf("found multiple bundles in snapshot bundle")
+		return nil, utils.NewClassErrorf(utils.ErrDecode, "found multiple bundles in snapshot bundle")
 	}
 
 	return &bundle[0], nil
@@ -143,18 +156,18 @@ func (collector *KyveSnapshotCollector) DownloadChunkFromBundleId(bundleId int64
 		return nil, fmt.Errorf("failed getting finalized bundle by id %d: %w", bundleId, err)
 	}
 
-	data, err := utils.GetDataFromFinalizedBundle(*chunkBundleFinalized)
+	data, err := getDataFromFinalizedBundle(collector.poolId, *chunkBundleFinalized)
 	if err != nil {
 		return nil, fmt.Errorf("failed getting data from finalized bundle: %w", err)
 	}
 
 	var bundle types.SnapshotBundle
 	if err := json.Unmarshal(data, &bundle); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal snapshot bundle: %w", err)
+		return nil, utils.NewClassErrorf(utils.ErrDecode, "failed to unmarshal snapshot bundle: %w", err)
 	}
 
 	if len(bundle) != 1 {
-		return nil, fmt.Errorf("found multiple bundles in snapshot bundle")
+		return nil, utils.NewClassErrorf(utils.ErrDecode, "found multiple bundles in snapshot bundle")
 	}
 
 	return bundle[0].Value.Chunk, nil
@@ -181,16 +194,45 @@ func (collector *KyveSnapshotCollector) FindSnapshotBundleIdForHeight(height int
 		}
 	}
 
-	// if the height is not the latest height we try to find it with binary search
-	// TODO: consider interpolation search
-	low := int64(0)
-	high := latestBundleId
+	if height > collector.currentHeight {
+		return 0, fmt.Errorf("snapshot height %d is greater than current height %d of pool", height, collector.currentHeight)
+	}
+
+	index := loadSnapshotIndex(collector.chainRest, collector.poolId)
+	if bundleId, found := index.get(height); found {
+		return bundleId, nil
+	}
+	defer index.save()
+
+	// if the height is not the latest height we search it with interpolation search. The
+	// anchors are snapshots with known heights and bundle ids of their first chunk. Since
+	// snapshots are created on a fixed interval and usually have a similar number of chunks
+	// the bundle id of a height can be estimated very precisely from the anchors
+	low, high := int64(0), latestBundleId
+	lowAnchor := [2]int64{collector.earliestAvailableHeight, 0}
+	highAnchor := [2]int64{collector.currentHeight, latestBundleId - collector.currentChunkIndex}
+
+	// snapshots found in previous searches narrow down the search range
+	if lower, found, upper, foundUpper := index.neighbours(height); found || foundUpper {
+		if found {
+			low, lowAnchor = max(low, lower[1]+1), lower
+		}
+
+		if foundUpper {
+			high, highAnchor = min(high, upper[1]-1), upper
+		}
+	}
 
-	// stop when low and high meet
-	for low <= high {
-		// check in the middle
+	for probes := 0; low <= high; probes++ {
 		mid := (low + high) / 2
 
+		// we fall back to bisection every third probe, so we also find the height
+		// in logarithmic time if the number of chunks varies a lot between snapshots
+		if probes%3 != 2 && highAnchor[0] > lowAnchor[0] {
+			mid = lowAnchor[1] + (height-lowAnchor[0])*(highAnchor[1]-lowAnchor[1])/(highAnchor[0]-lowAnchor[0])
+			mid = min(max(mid, low), high)
+		}
+
 		finalizedBundle, err := utils.GetFinalizedBundleById(collector.chainRest, collector.poolId, mid)
 		if err != nil {
 			return 0, fmt.Errorf("failed to get finalized bundle with id %d: %w", mid, err)
@@ -201,16 +243,29 @@ func (collector *KyveSnapshotCollector) FindSnapshotBundleIdForHeight(height int
 			return 0, fmt.Errorf("failed to parse snapshot key %s: %w", finalizedBundle.ToKey, err)
 		}
 
+		// the bundle id where the first chunk index of the snapshot is located
+		start := mid - chunkIndex
+		index.set(h, start)
+
+		logger.Logger.Debug().Int64("bundle_id", mid).Int64("height", h).Int64("chunk_index", chunkIndex).Msgf("searching snapshot bundle for height %d", height)
+
+		if h == height {
+			return start, nil
+		}
+
 		if h < height {
-			// target height is in the right half
-			low = mid + 1
-		} else if h > height {
-			// target height is in the left half
-			high = mid - 1
+			// target height is after this snapshot, if the total number of chunks
+			// is known from the bundle summary we can skip the remaining chunks
+			low, lowAnchor = mid+1, [2]int64{h, start}
+
+			if summary := strings.Split(finalizedBundle.BundleSummary, "/"); len(summary) == 4 {
+				if totalChunks, err := strconv.ParseInt(summary[3], 10, 64); err == nil {
+					low = max(low, start+totalChunks)
+				}
+			}
 		} else {
-			// found it, now we just go back to the bundle where the first chunk index
-			// is located
-			return mid - chunkIndex, nil
+			// target height is before this snapshot
+			high, highAnchor = start-1, [2]int64{h, start}
 		}
 
 		time.Sleep(utils.RequestTimeoutMS)
@@ -218,3 +273,230 @@ func (collector *KyveSnapshotCollector) FindSnapshotBundleIdForHeight(height int
 
 	return 0, fmt.Errorf("failed to find snapshot bundle id for height %d", height)
 }
+
+// localChunksPerSnapshot is the maximum number of chunks of a local snapshot. Each
+// chunk gets the virtual bundle id "height * localChunksPerSnapshot + chunkIndex"
+// so that the ids stay the same if snapshots are added or removed while syncing
+const localChunksPerSnapshot = 1 << 20
+
+// localSnapshot describes a snapshot folder in the local snapshot directory. Since
+// every chunk is stored in its own file we assign each chunk a virtual bundle id
+// so that the chunks can be looked up like in a KYVE snapshot pool
+type localSnapshot struct {
+	height int64
+	chunks int64
+}
+
+type LocalSnapshotCollector struct {
+	dir string
+
+	mu        sync.RWMutex
+	snapshots []localSnapshot
+	interval  int64
+}
+
+func NewLocalSnapshotCollector(dir string) (*LocalSnapshotCollector, error) {
+	collector := &LocalSnapshotCollector{
+		dir: dir,
+	}
+
+	if err := collector.loadSnapshots(); err != nil {
+		return nil, err
+	}
+
+	return collector, nil
+}
+
+// loadSnapshots scans the snapshot directory for folders named after the snapshot
+// height which contain the chunk files "<chunkIndex>.json". Folders which are
+// still being written have a ".tmp" suffix and are skipped
+func (collector *LocalSnapshotCollector) loadSnapshots() error {
+	entries, err := os.ReadDir(collector.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot directory %s: %w", collector.dir, err)
+	}
+
+	var heights []int64
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		height, err := strconv.ParseInt(entry.Name(), 10, 64)
+		if err != nil || height <= 0 {
+			continue
+		}
+
+		heights = append(heights, height)
+	}
+
+	sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+
+	snapshots := make([]localSnapshot, 0, len(heights))
+
+	for _, height := range heights {
+		chunks := int64(0)
+		for chunks < localChunksPerSnapshot {
+			if _, err := os.Stat(collector.getChunkPath(height, chunks)); err != nil {
+				break
+			}
+			chunks++
+		}
+
+		if chunks == 0 {
+			continue
+		}
+
+		snapshots = append(snapshots, localSnapshot{
+			height: height,
+			chunks: chunks,
+		})
+	}
+
+	if len(snapshots) == 0 {
+		return fmt.Errorf("found no snapshots in snapshot directory %s", collector.dir)
+	}
+
+	// we assume that the snapshots were exported on a fixed interval, therefore all
+	// snapshot heights are a multiple of it. With only a single snapshot this is the
+	// snapshot height itself
+	interval := int64(0)
+	for _, snapshot := range snapshots {
+		interval = gcd(interval, snapshot.height)
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.snapshots = snapshots
+	collector.interval = interval
+
+	return nil
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func (collector *LocalSnapshotCollector) getChunkPath(height, chunkIndex int64) string {
+	return filepath.Join(collector.dir, strconv.FormatInt(height, 10), fmt.Sprintf("%d.json", chunkIndex))
+}
+
+func (collector *LocalSnapshotCollector) GetEarliestAvailableHeight() int64 {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+
+	return collector.snapshots[0].height
+}
+
+func (collector *LocalSnapshotCollector) GetLatestAvailableHeight() int64 {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+
+	return collector.snapshots[len(collector.snapshots)-1].height
+}
+
+func (collector *LocalSnapshotCollector) GetInterval() int64 {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+
+	return collector.interval
+}
+
+func (collector *LocalSnapshotCollector) GetSnapshotHeight(targetHeight int64, isServeSnapshot bool) int64 {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+
+	latest := collector.snapshots[len(collector.snapshots)-1].height
+
+	if targetHeight == 0 || targetHeight >= latest {
+		// like for KYVE snapshot pools we do not want to sync to the latest snapshot with
+		// serve-snapshots, else the node has to wait for the next snapshot to be created
+		if isServeSnapshot && len(collector.snapshots) > 1 {
+			return collector.snapshots[len(collector.snapshots)-2].height
+		}
+		return latest
+	}
+
+	// since the snapshots do not need to be on a strict interval we look for the
+	// nearest available snapshot below the target height
+	snapshotHeight := int64(0)
+	for _, snapshot := range collector.snapshots {
+		if snapshot.height > targetHeight {
+			break
+		}
+		snapshotHeight = snapshot.height
+	}
+
+	return snapshotHeight
+}
+
+func (collector *LocalSnapshotCollector) GetCurrentHeight() (int64, error) {
+	if err := collector.loadSnapshots(); err != nil {
+		return 0, err
+	}
+
+	return collector.GetLatestAvailableHeight(), nil
+}
+
+func (collector *LocalSnapshotCollector) GetSnapshotFromBundleId(bundleId int64) (*types.SnapshotDataItem, error) {
+	height, chunkIndex, err := collector.getChunkFromBundleId(bundleId)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(collector.getChunkPath(height, chunkIndex))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot chunk %d at height %d: %w", chunkIndex, height, err)
+	}
+
+	var dataItem types.SnapshotDataItem
+	if err := json.Unmarshal(data, &dataItem); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal snapshot chunk %d at height %d: %w", chunkIndex, height, err)
+	}
+
+	return &dataItem, nil
+}
+
+func (collector *LocalSnapshotCollector) DownloadChunkFromBundleId(bundleId int64) ([]byte, error) {
+	dataItem, err := collector.GetSnapshotFromBundleId(bundleId)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataItem.Value.Chunk, nil
+}
+
+func (collector *LocalSnapshotCollector) FindSnapshotBundleIdForHeight(height int64) (int64, error) {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+
+	for _, snapshot := range collector.snapshots {
+		if snapshot.height == height {
+			return snapshot.height * localChunksPerSnapshot, nil
+		}
+	}
+
+	return 0, fmt.Errorf("failed to find snapshot at height %d in snapshot directory %s", height, collector.dir)
+}
+
+// getChunkFromBundleId maps the virtual bundle id back to the snapshot height
+// and the chunk index
+func (collector *LocalSnapshotCollector) getChunkFromBundleId(bundleId int64) (int64, int64, error) {
+	height, chunkIndex := bundleId/localChunksPerSnapshot, bundleId%localChunksPerSnapshot
+
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+
+	for _, snapshot := range collector.snapshots {
+		if snapshot.height == height && chunkIndex < snapshot.chunks {
+			return height, chunkIndex, nil
+		}
+	}
+
+	return 0, 0, fmt.Errorf("failed to find snapshot chunk for bundle id %d in snapshot directory %s", bundleId, collector.dir)
+}
@@ -0,0 +1,323 @@
+package collector
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/utils"
+)
+
+const testSnapshotInterval = 100
+
+// snapshotBundles creates the bundles of a snapshot pool where every chunk is archived in its
+// own bundle. The snapshots start at the interval and the bundle ids of their first chunk are
+// returned by height
+func snapshotBundles(chunks []int64, withSummary bool) ([]fakeBundle, map[int64]int64) {
+	bundles := make([]fakeBundle, 0)
+	firstBundleIds := make(map[int64]int64)
+
+	for i, totalChunks := range chunks {
+		height := int64(i+1) * testSnapshotInterval
+		firstBundleIds[height] = int64(len(bundles))
+
+		for chunkIndex := int64(0); chunkIndex < totalChunks; chunkIndex++ {
+			key := fmt.Sprintf("%d/%d", height, chunkIndex)
+
+			bundle := fakeBundle{fromKey: key, toKey: key}
+			if withSummary {
+				bundle.summary = fmt.Sprintf("%d/3/%d/%d", height, chunkIndex, totalChunks)
+			}
+
+			bundles = append(bundles, bundle)
+		}
+	}
+
+	return bundles, firstBundleIds
+}
+
+func repeatChunks(totalChunks int64, snapshots int) []int64 {
+	chunks := make([]int64, snapshots)
+	for i := range chunks {
+		chunks[i] = totalChunks
+	}
+	return chunks
+}
+
+func newTestSnapshotCollector(t *testing.T, chunks []int64, withSummary bool) (*KyveSnapshotCollector, *fakePool, map[int64]int64) {
+	t.Helper()
+
+	// the snapshot index is stored in the home directory
+	t.Setenv("HOME", t.TempDir())
+
+	bundles, firstBundleIds := snapshotBundles(chunks, withSummary)
+	pool := newFakePool(t, utils.RuntimeTendermintSsync, fmt.Sprintf(`{"interval":%d}`, testSnapshotInterval), bundles)
+
+	collector, err := NewKyveSnapshotCollector(0, pool.server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return collector, pool, firstBundleIds
+}
+
+func TestFindSnapshotBundleIdForHeight(t *testing.T) {
+	tests := []struct {
+		name        string
+		chunks      []int64
+		withSummary bool
+	}{
+		{
+			name:        "same number of chunks",
+			chunks:      repeatChunks(3, 40),
+			withSummary: true,
+		},
+		{
+			name:        "single chunk snapshots",
+			chunks:      repeatChunks(1, 40),
+			withSummary: true,
+		},
+		{
+			name:        "varying number of chunks",
+			chunks:      []int64{1, 7, 2, 12, 3, 3, 25, 1, 4, 9, 2, 2, 18, 5, 1, 6},
+			withSummary: true,
+		},
+		{
+			name:   "varying number of chunks without bundle summaries",
+			chunks: []int64{1, 7, 2, 12, 3, 3, 25, 1, 4, 9, 2, 2, 18, 5, 1, 6},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector, _, firstBundleIds := newTestSnapshotCollector(t, tt.chunks, tt.withSummary)
+
+			for height, expected := range firstBundleIds {
+				bundleId, err := collector.FindSnapshotBundleIdForHeight(height)
+				if err != nil {
+					t.Fatalf("failed to find snapshot %d: %s", height, err)
+				}
+
+				if bundleId != expected {
+					t.Fatalf("expected bundle id %d for snapshot %d, found %d", expected, height, bundleId)
+				}
+			}
+		})
+	}
+}
+
+func TestFindSnapshotBundleIdForHeightNotInPool(t *testing.T) {
+	tests := []struct {
+		name   string
+		height int64
+	}{
+		{name: "height between snapshots", height: 5*testSnapshotInterval + testSnapshotInterval/2},
+		{name: "height before the first snapshot", height: testSnapshotInterval / 2},
+		{name: "height after the current snapshot", height: 11 * testSnapshotInterval},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector, _, _ := newTestSnapshotCollector(t, repeatChunks(3, 10), true)
+
+			if bundleId, err := collector.FindSnapshotBundleIdForHeight(tt.height); err == nil {
+				t.Fatalf("expected height %d not to be found, found bundle id %d", tt.height, bundleId)
+			}
+		})
+	}
+}
+
+func TestFindSnapshotBundleIdForLatestHeight(t *testing.T) {
+	collector, pool, firstBundleIds := newTestSnapshotCollector(t, repeatChunks(3, 10), true)
+
+	bundleId, err := collector.FindSnapshotBundleIdForHeight(collector.GetLatestAvailableHeight())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bundleId != firstBundleIds[collector.GetLatestAvailableHeight()] {
+		t.Fatalf("expected bundle id %d, found %d", firstBundleIds[collector.GetLatestAvailableHeight()], bundleId)
+	}
+
+	// the bundle id is calculated from the latest bundle
+	if queries := pool.bundleQueryCount(); queries != 1 {
+		t.Fatalf("expected 1 bundle query, found %d", queries)
+	}
+}
+
+func TestFindSnapshotBundleIdForHeightFallsBackToBisection(t *testing.T) {
+	// the first snapshot is so large that interpolating from it always estimates a bundle inside
+	// of it, without the total number of chunks every probe would only skip a single chunk
+	chunks := append([]int64{1000}, repeatChunks(1, 99)...)
+
+	collector, pool, firstBundleIds := newTestSnapshotCollector(t, chunks, false)
+
+	height := int64(50 * testSnapshotInterval)
+
+	bundleId, err := collector.FindSnapshotBundleIdForHeight(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bundleId != firstBundleIds[height] {
+		t.Fatalf("expected bundle id %d, found %d", firstBundleIds[height], bundleId)
+	}
+
+	// every third probe bisects, so the search stays logarithmic in the number of bundles
+	if queries := pool.bundleQueryCount(); queries > 40 {
+		t.Fatalf("expected at most 40 bundle queries, found %d", queries)
+	}
+}
+
+// writeLocalSnapshot writes the chunk files of a snapshot like export-snapshots does
+func writeLocalSnapshot(t *testing.T, dir, folder string, chunks int) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Join(dir, folder), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for chunkIndex := 0; chunkIndex < chunks; chunkIndex++ {
+		data := fmt.Sprintf(`{"key":"%s/%d","value":{"chunkIndex":%d,"chunk":"%s"}}`, folder, chunkIndex, chunkIndex, base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s/%d", folder, chunkIndex))))
+		if err := os.WriteFile(filepath.Join(dir, folder, fmt.Sprintf("%d.json", chunkIndex)), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func expectLocalChunk(t *testing.T, collector *LocalSnapshotCollector, bundleId int64, expected string) {
+	t.Helper()
+
+	chunk, err := collector.DownloadChunkFromBundleId(bundleId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(chunk) != expected {
+		t.Fatalf("expected chunk %s for bundle id %d, found %s", expected, bundleId, chunk)
+	}
+}
+
+func TestLocalSnapshotCollector(t *testing.T) {
+	dir := t.TempDir()
+
+	writeLocalSnapshot(t, dir, "100", 2)
+	writeLocalSnapshot(t, dir, "200", 3)
+	writeLocalSnapshot(t, dir, "400", 1)
+	writeLocalSnapshot(t, dir, "500.tmp", 2)
+	writeLocalSnapshot(t, dir, "600", 0)
+	writeLocalSnapshot(t, dir, "snapshots", 1)
+
+	collector, err := NewLocalSnapshotCollector(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if earliest, latest := collector.GetEarliestAvailableHeight(), collector.GetLatestAvailableHeight(); earliest != 100 || latest != 400 {
+		t.Fatalf("expected snapshots from 100 to 400, found %d to %d", earliest, latest)
+	}
+
+	if interval := collector.GetInterval(); interval != 100 {
+		t.Fatalf("expected interval 100, found %d", interval)
+	}
+
+	if _, err := collector.FindSnapshotBundleIdForHeight(500); err == nil {
+		t.Fatal("expected snapshot which is still being written to be skipped")
+	}
+
+	bundleId, err := collector.FindSnapshotBundleIdForHeight(200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for chunkIndex := int64(0); chunkIndex < 3; chunkIndex++ {
+		expectLocalChunk(t, collector, bundleId+chunkIndex, fmt.Sprintf("200/%d", chunkIndex))
+	}
+
+	if _, err := collector.DownloadChunkFromBundleId(bundleId + 3); err == nil {
+		t.Fatal("expected bundle id after the last chunk to fail")
+	}
+
+	// snapshots which are added or finished while syncing must not change the
+	// bundle ids which were already handed out
+	writeLocalSnapshot(t, dir, "50", 4)
+	if err := os.Rename(filepath.Join(dir, "500.tmp"), filepath.Join(dir, "500")); err != nil {
+		t.Fatal(err)
+	}
+
+	currentHeight, err := collector.GetCurrentHeight()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if currentHeight != 500 {
+		t.Fatalf("expected current height 500, found %d", currentHeight)
+	}
+
+	for chunkIndex := int64(0); chunkIndex < 3; chunkIndex++ {
+		expectLocalChunk(t, collector, bundleId+chunkIndex, fmt.Sprintf("200/%d", chunkIndex))
+	}
+
+	if interval := collector.GetInterval(); interval != 50 {
+		t.Fatalf("expected interval 50 after reload, found %d", interval)
+	}
+}
+
+func TestLocalSnapshotCollectorSingleSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	writeLocalSnapshot(t, dir, "3000", 1)
+
+	collector, err := NewLocalSnapshotCollector(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if interval := collector.GetInterval(); interval <= 0 || 3000%interval != 0 {
+		t.Fatalf("expected interval to divide the snapshot height, found %d", interval)
+	}
+}
+
+func TestLocalSnapshotCollectorEmptyDir(t *testing.T) {
+	dir := t.TempDir()
+	writeLocalSnapshot(t, dir, "100.tmp", 1)
+
+	if _, err := NewLocalSnapshotCollector(dir); err == nil {
+		t.Fatal("expected snapshot directory without finished snapshots to fail")
+	}
+}
+
+func TestLocalGetSnapshotHeight(t *testing.T) {
+	dir := t.TempDir()
+	for _, height := range []string{"100", "200", "400"} {
+		writeLocalSnapshot(t, dir, height, 1)
+	}
+
+	collector, err := NewLocalSnapshotCollector(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		targetHeight    int64
+		isServeSnapshot bool
+		expected        int64
+	}{
+		{name: "no target height", targetHeight: 0, expected: 400},
+		{name: "no target height with serve-snapshots", targetHeight: 0, isServeSnapshot: true, expected: 200},
+		{name: "target height above latest", targetHeight: 1000, expected: 400},
+		{name: "target height on snapshot", targetHeight: 200, expected: 200},
+		{name: "target height in gap", targetHeight: 399, expected: 200},
+		{name: "target height below earliest", targetHeight: 50, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if height := collector.GetSnapshotHeight(tt.targetHeight, tt.isServeSnapshot); height != tt.expected {
+				t.Fatalf("expected snapshot height %d, found %d", tt.expected, height)
+			}
+		})
+	}
+}
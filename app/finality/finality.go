@@ -0,0 +1,324 @@
+package finality
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"github.com/KYVENetwork/cometbft/v38/libs/log"
+	"github.com/KYVENetwork/cometbft/v38/light"
+	"github.com/KYVENetwork/cometbft/v38/light/provider"
+	lighthttp "github.com/KYVENetwork/cometbft/v38/light/provider/http"
+	lightdb "github.com/KYVENetwork/cometbft/v38/light/store/db"
+	cmtcrypto "github.com/KYVENetwork/cometbft/v38/proto/cometbft/v38/crypto"
+	rpcclient "github.com/KYVENetwork/cometbft/v38/rpc/client"
+	rpchttp "github.com/KYVENetwork/cometbft/v38/rpc/client/http"
+	cmttypes "github.com/KYVENetwork/cometbft/v38/types"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+	dbm "github.com/cometbft/cometbft-db"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// module stores and key prefixes of the KYVE chain state, see StoreKey and the key
+// prefixes in x/bundles/types/keys.go and x/pool/types/keys.go of github.com/KYVENetwork/chain.
+// Ids are appended to the prefixes as big endian uint64
+const (
+	storeBundles = "bundles"
+	storePool    = "pool"
+)
+
+var (
+	finalizedBundlePrefix = []byte{2}
+	poolPrefix            = []byte{1}
+)
+
+const (
+	// unbondingPeriod is the unbonding time of the staking module on kyve-1
+	unbondingPeriod = 21 * 24 * time.Hour
+	// trustingPeriod has to be shorter than the unbonding period, we use two
+	// thirds of it like recommended by the light client specification
+	trustingPeriod = unbondingPeriod * 2 / 3
+	// refreshInterval is the time after which a new KYVE header gets verified
+	refreshInterval = time.Minute
+	requestTimeout  = 30 * time.Second
+)
+
+var (
+	verifier    *Verifier
+	verifierErr error
+	verifierMu  sync.Mutex
+)
+
+// Verifier verifies finalized bundles and pools with ABCI query proofs against the app
+// hash of KYVE headers which are verified with a light client. Therefore, the chain rest
+// endpoint does not have to be trusted since forged bundles fail the verification
+type Verifier struct {
+	client *light.Client
+	rpc    *rpchttp.HTTP
+
+	mu          sync.Mutex
+	header      *cmttypes.LightBlock
+	refreshedAt time.Time
+}
+
+// GetVerifier returns the verifier created from the flags or nil if the finality
+// of bundles should not be verified
+func GetVerifier() (*Verifier, error) {
+	if !flags.VerifyFinality {
+		return nil, nil
+	}
+
+	verifierMu.Lock()
+	defer verifierMu.Unlock()
+
+	if verifier == nil && verifierErr == nil {
+		verifier, verifierErr = newVerifier()
+	}
+
+	return verifier, verifierErr
+}
+
+func newVerifier() (*Verifier, error) {
+	rpc, err := getChainRpc()
+	if err != nil {
+		return nil, err
+	}
+
+	if flags.TrustedHeight <= 0 || flags.TrustedHash == "" {
+		return nil, fmt.Errorf("flags --trusted-height and --trusted-hash of a KYVE block are required to verify bundle finality")
+	}
+
+	trustedHash, err := hex.DecodeString(flags.TrustedHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode trusted hash %s: %w", flags.TrustedHash, err)
+	}
+
+	primary, err := lighthttp.New(flags.ChainId, rpc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create light client provider for %s: %w", rpc, err)
+	}
+
+	// headers of the primary are cross-checked with the witnesses, if none are
+	// provided we can only detect forks of the primary but not attacks of it
+	witnesses := make([]provider.Provider, 0)
+	for _, witness := range strings.Split(flags.ChainRpcWitnesses, ",") {
+		if witness = strings.TrimSpace(witness); witness == "" {
+			continue
+		}
+
+		p, err := lighthttp.New(flags.ChainId, witness)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create light client provider for witness %s: %w", witness, err)
+		}
+		witnesses = append(witnesses, p)
+	}
+
+	if len(witnesses) == 0 {
+		logger.Logger.Warn().Msg("no witnesses for the KYVE light client provided, consider adding independent rpc endpoints with --chain-rpc-witnesses")
+		witnesses = append(witnesses, primary)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	client, err := light.NewClient(
+		ctx,
+		flags.ChainId,
+		light.TrustOptions{Period: trustingPeriod, Height: flags.TrustedHeight, Hash: trustedHash},
+		primary,
+		witnesses,
+		lightdb.New(dbm.NewMemDB(), flags.ChainId),
+		light.Logger(log.NewNopLogger()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create KYVE light client: %w", err)
+	}
+
+	rpcClient, err := rpchttp.New(rpc, "/websocket")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rpc client for %s: %w", rpc, err)
+	}
+
+	logger.Logger.Info().Msgf("verifying bundle finality with KYVE light client on %s", rpc)
+
+	return &Verifier{client: client, rpc: rpcClient}, nil
+}
+
+func getChainRpc() (string, error) {
+	if flags.ChainRpc != "" {
+		return strings.TrimSuffix(flags.ChainRpc, "/"), nil
+	}
+
+	network, err := utils.GetKyveNetwork(flags.ChainId)
+	if err != nil {
+		return "", err
+	}
+
+	if network.Rpc == "" {
+		return "", fmt.Errorf("network %s has no rpc endpoint, provide one with --chain-rpc", flags.ChainId)
+	}
+
+	return network.Rpc, nil
+}
+
+// VerifyBundle verifies that the bundle is finalized on the KYVE chain and that storage id,
+// data hash and keys match the finalized state. Since the data hash is checked against the
+// downloaded data afterward the data is verified too
+func (v *Verifier) VerifyBundle(poolId int64, bundle types.FinalizedBundle) error {
+	bundleId, err := strconv.ParseUint(bundle.Id, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse bundle id %s: %w", bundle.Id, err)
+	}
+
+	key := finalizedBundleKey(uint64(poolId), bundleId)
+
+	value, err := v.queryVerified(storeBundles, key)
+	if err != nil {
+		return fmt.Errorf("failed to query finalized bundle %d of pool %d: %w", bundleId, poolId, err)
+	}
+
+	finalized, err := utils.UnmarshalFinalizedBundle(value)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal finalized bundle %d of pool %d: %w", bundleId, poolId, err)
+	}
+
+	expected := []string{finalized.PoolId, finalized.Id, finalized.StorageId, finalized.StorageProviderId, finalized.CompressionId, finalized.FromKey, finalized.ToKey, finalized.DataHash}
+	found := []string{strconv.FormatInt(poolId, 10), bundle.Id, bundle.StorageId, bundle.StorageProviderId, bundle.CompressionId, bundle.FromKey, bundle.ToKey, bundle.DataHash}
+
+	for i := range expected {
+		if expected[i] != found[i] {
+			return utils.NewClassErrorf(utils.ErrBlockValidation, "finalized bundle %d of pool %d does not match the KYVE chain state: expected = %v found = %v", bundleId, poolId, expected, found)
+		}
+	}
+
+	logger.Logger.Debug().Int64("pool_id", poolId).Str("bundle_id", bundle.Id).Msg("verified bundle finality")
+	return nil
+}
+
+// VerifyPool verifies that runtime, start key and config of the pool match the state of the
+// KYVE chain. The current key and the number of bundles are not verified since they change
+// with every finalized bundle
+func (v *Verifier) VerifyPool(poolResponse *types.PoolResponse) error {
+	key := poolKey(uint64(poolResponse.Pool.Id))
+
+	value, err := v.queryVerified(storePool, key)
+	if err != nil {
+		return fmt.Errorf("failed to query pool %d: %w", poolResponse.Pool.Id, err)
+	}
+
+	pool, err := utils.UnmarshalPool(value)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal pool %d: %w", poolResponse.Pool.Id, err)
+	}
+
+	if pool.Pool.Data.Runtime != poolResponse.Pool.Data.Runtime || pool.Pool.Data.StartKey != poolResponse.Pool.Data.StartKey || pool.Pool.Data.Config != poolResponse.Pool.Data.Config {
+		return utils.NewClassErrorf(utils.ErrBlockValidation, "pool %d does not match the KYVE chain state", poolResponse.Pool.Id)
+	}
+
+	logger.Logger.Debug().Int64("pool_id", poolResponse.Pool.Id).Msg("verified pool")
+	return nil
+}
+
+// queryVerified queries the key of the module store and verifies the returned value with the
+// proof against the app hash of a verified header. The app hash of a height is part of the
+// next header, so we query the state at the height before the latest verified header
+func (v *Verifier) queryVerified(store string, key []byte) ([]byte, error) {
+	header, err := v.getVerifiedHeader(false)
+	if err != nil {
+		return nil, err
+	}
+
+	value, proofOps, err := v.query(store, key, header.Height-1)
+	if err != nil {
+		return nil, err
+	}
+
+	// the key might have been created after the verified header, e.g. if the bundle got
+	// finalized just now, so we retry once with the latest header
+	if len(value) == 0 {
+		if header, err = v.getVerifiedHeader(true); err != nil {
+			return nil, err
+		}
+
+		if value, proofOps, err = v.query(store, key, header.Height-1); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(value) == 0 {
+		return nil, fmt.Errorf("key %x does not exist in store %s at height %d", key, store, header.Height-1)
+	}
+
+	if err := verifyProofOps(proofOps, store, key, value, header.AppHash); err != nil {
+		return nil, utils.NewClassErrorf(utils.ErrBlockValidation, "failed to verify value at height %d: %w", header.Height-1, err)
+	}
+
+	return value, nil
+}
+
+func finalizedBundleKey(poolId, bundleId uint64) []byte {
+	return binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint64(append([]byte{}, finalizedBundlePrefix...), poolId), bundleId)
+}
+
+func poolKey(poolId uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, poolPrefix...), poolId)
+}
+
+func (v *Verifier) query(store string, key []byte, height int64) ([]byte, []cmtcrypto.ProofOp, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	result, err := v.rpc.ABCIQueryWithOptions(ctx, fmt.Sprintf("/store/%s/key", store), key, rpcclient.ABCIQueryOptions{Height: height, Prove: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query store %s at height %d: %w", store, height, err)
+	}
+
+	if result.Response.Code != 0 {
+		return nil, nil, fmt.Errorf("query of store %s at height %d failed with code %d: %s", store, height, result.Response.Code, result.Response.Log)
+	}
+
+	if result.Response.Height != height {
+		return nil, nil, fmt.Errorf("expected query response for height %d, found %d", height, result.Response.Height)
+	}
+
+	if result.Response.ProofOps == nil {
+		return result.Response.Value, nil, nil
+	}
+
+	return result.Response.Value, result.Response.ProofOps.Ops, nil
+}
+
+// getVerifiedHeader returns the latest header verified by the light client. It gets
+// refreshed after the refresh interval passed or if a refresh is forced
+func (v *Verifier) getVerifiedHeader(force bool) (*cmttypes.LightBlock, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.header != nil && !force && time.Since(v.refreshedAt) < refreshInterval {
+		return v.header, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	header, err := v.client.Update(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify latest KYVE header: %w", err)
+	}
+
+	// the light client returns no header if the trusted one is already the latest
+	if header == nil {
+		if header, err = v.client.TrustedLightBlock(0); err != nil {
+			return nil, fmt.Errorf("failed to get trusted KYVE header: %w", err)
+		}
+	}
+
+	v.header, v.refreshedAt = header, time.Now()
+	return v.header, nil
+}
@@ -0,0 +1,304 @@
+package finality
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	abci "github.com/KYVENetwork/cometbft/v38/abci/types"
+	cmtbytes "github.com/KYVENetwork/cometbft/v38/libs/bytes"
+	cmtjson "github.com/KYVENetwork/cometbft/v38/libs/json"
+	cmtcrypto "github.com/KYVENetwork/cometbft/v38/proto/cometbft/v38/crypto"
+	rpchttp "github.com/KYVENetwork/cometbft/v38/rpc/client/http"
+	ctypes "github.com/KYVENetwork/cometbft/v38/rpc/core/types"
+	rpctypes "github.com/KYVENetwork/cometbft/v38/rpc/jsonrpc/types"
+	cmttypes "github.com/KYVENetwork/cometbft/v38/types"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const testStateHeight = 99
+
+var testStoreKeys = [][]byte{[]byte("bank"), []byte(storeBundles), []byte(storePool), []byte("stakers")}
+
+// testChainState is the state of the bundles and pool store of the KYVE chain, every
+// store has to contain a power of two keys
+type testChainState struct {
+	stores map[string][][2][]byte
+}
+
+// prove returns the value of the key with the proofs against the app hash
+func (state *testChainState) prove(t *testing.T, store string, key []byte) ([]byte, []cmtcrypto.ProofOp, []byte) {
+	t.Helper()
+
+	storeIndex, storeRoots := 0, make([][]byte, len(testStoreKeys))
+	var value []byte
+	var iavlProof cmtcrypto.ProofOp
+
+	for i, storeKey := range testStoreKeys {
+		entries, found := state.stores[string(storeKey)]
+		if !found {
+			storeRoots[i] = bytes.Repeat([]byte{byte(i)}, 32)
+			continue
+		}
+
+		keys, values, index := make([][]byte, 0), make([][]byte, 0), 0
+		for j, entry := range entries {
+			keys, values = append(keys, entry[0]), append(values, entry[1])
+			if string(storeKey) == store && bytes.Equal(entry[0], key) {
+				index, value = j, entry[1]
+			}
+		}
+
+		root, proof := iavlTree.prove(t, keys, values, index)
+		storeRoots[i] = root
+
+		if string(storeKey) == store {
+			storeIndex, iavlProof = i, marshalProofOp(t, proofOpIavl, key, proof)
+		}
+	}
+
+	appHash, simpleProof := simpleTree.prove(t, testStoreKeys, storeRoots, storeIndex)
+
+	return value, []cmtcrypto.ProofOp{iavlProof, marshalProofOp(t, proofOpSimple, testStoreKeys[storeIndex], simpleProof)}, appHash
+}
+
+// newTestVerifier returns a verifier which trusts the app hash of the state and queries the
+// values from a KYVE rpc endpoint which serves the state with proofs
+func newTestVerifier(t *testing.T, state *testChainState) *Verifier {
+	_, _, appHash := state.prove(t, storeBundles, nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		var request rpctypes.RPCRequest
+		if err := cmtjson.Unmarshal(body, &request); err != nil {
+			t.Error(err)
+			return
+		}
+
+		var params struct {
+			Path   string            `json:"path"`
+			Data   cmtbytes.HexBytes `json:"data"`
+			Height int64             `json:"height,string"`
+			Prove  bool              `json:"prove"`
+		}
+		if err := cmtjson.Unmarshal(request.Params, &params); err != nil {
+			t.Error(err)
+			return
+		}
+
+		store := strings.TrimSuffix(strings.TrimPrefix(params.Path, "/store/"), "/key")
+		value, proofOps, _ := state.prove(t, store, params.Data)
+
+		response := rpctypes.NewRPCSuccessResponse(request.ID, &ctypes.ResultABCIQuery{Response: abci.ResponseQuery{
+			Key:      params.Data,
+			Value:    value,
+			ProofOps: &cmtcrypto.ProofOps{Ops: proofOps},
+			Height:   params.Height,
+		}})
+
+		data, err := json.Marshal(response)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+
+	rpc, err := rpchttp.New(server.URL, "/websocket")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := &cmttypes.LightBlock{SignedHeader: &cmttypes.SignedHeader{Header: &cmttypes.Header{Height: testStateHeight + 1, AppHash: appHash}}}
+
+	return &Verifier{rpc: rpc, header: header, refreshedAt: time.Now()}
+}
+
+// marshalStoreBundle encodes the bundle like the finalized bundles of the bundles store
+func marshalStoreBundle(bundle types.FinalizedBundle) []byte {
+	number := func(value string) uint64 {
+		n, _ := strconv.ParseUint(value, 10, 64)
+		return n
+	}
+
+	var data []byte
+	data = protowire.AppendVarint(protowire.AppendTag(data, 1, protowire.VarintType), number(bundle.PoolId))
+	data = protowire.AppendVarint(protowire.AppendTag(data, 2, protowire.VarintType), number(bundle.Id))
+	data = protowire.AppendString(protowire.AppendTag(data, 3, protowire.BytesType), bundle.StorageId)
+	data = protowire.AppendString(protowire.AppendTag(data, 7, protowire.BytesType), bundle.ToKey)
+	data = protowire.AppendString(protowire.AppendTag(data, 8, protowire.BytesType), bundle.BundleSummary)
+	data = protowire.AppendString(protowire.AppendTag(data, 9, protowire.BytesType), bundle.DataHash)
+	data = protowire.AppendString(protowire.AppendTag(data, 11, protowire.BytesType), bundle.FromKey)
+	data = protowire.AppendVarint(protowire.AppendTag(data, 12, protowire.VarintType), number(bundle.StorageProviderId))
+	data = protowire.AppendVarint(protowire.AppendTag(data, 13, protowire.VarintType), number(bundle.CompressionId))
+	return data
+}
+
+// marshalStorePool encodes the pool like the pools of the pool store
+func marshalStorePool(pool types.PoolResponse) []byte {
+	var data []byte
+	data = protowire.AppendVarint(protowire.AppendTag(data, 1, protowire.VarintType), uint64(pool.Pool.Id))
+	data = protowire.AppendString(protowire.AppendTag(data, 3, protowire.BytesType), pool.Pool.Data.Runtime)
+	data = protowire.AppendString(protowire.AppendTag(data, 5, protowire.BytesType), pool.Pool.Data.Config)
+	data = protowire.AppendString(protowire.AppendTag(data, 6, protowire.BytesType), pool.Pool.Data.StartKey)
+	data = protowire.AppendString(protowire.AppendTag(data, 7, protowire.BytesType), pool.Pool.Data.CurrentKey)
+	data = protowire.AppendVarint(protowire.AppendTag(data, 10, protowire.VarintType), uint64(pool.Pool.Data.TotalBundles))
+	return data
+}
+
+func testBundle(id string) types.FinalizedBundle {
+	return types.FinalizedBundle{
+		PoolId:            "2",
+		Id:                id,
+		StorageId:         "storage-" + id,
+		StorageProviderId: "2",
+		CompressionId:     "1",
+		FromKey:           id + "01",
+		ToKey:             id + "50",
+		BundleSummary:     id + "50",
+		DataHash:          "hash-" + id,
+	}
+}
+
+func testPool() types.PoolResponse {
+	pool := types.PoolResponse{}
+	pool.Pool.Id = 2
+	pool.Pool.Data.Runtime = utils.RuntimeTendermintBsync
+	pool.Pool.Data.Config = `{"network":"osmosis-1"}`
+	pool.Pool.Data.StartKey = "1"
+	pool.Pool.Data.CurrentKey = "550"
+	pool.Pool.Data.TotalBundles = 11
+	return pool
+}
+
+func newTestChainState() *testChainState {
+	state := &testChainState{stores: map[string][][2][]byte{}}
+
+	for id := uint64(3); id <= 6; id++ {
+		state.stores[storeBundles] = append(state.stores[storeBundles], [2][]byte{finalizedBundleKey(2, id), marshalStoreBundle(testBundle(strconv.FormatUint(id, 10)))})
+	}
+
+	state.stores[storePool] = [][2][]byte{
+		{poolKey(1), []byte("pool 1")},
+		{poolKey(2), marshalStorePool(testPool())},
+	}
+
+	return state
+}
+
+func TestVerifyBundle(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(bundle *types.FinalizedBundle)
+		valid       bool
+		expectedErr string
+	}{
+		{name: "finalized bundle", modify: func(bundle *types.FinalizedBundle) {}, valid: true},
+		{name: "other storage id", modify: func(bundle *types.FinalizedBundle) { bundle.StorageId = "forged" }},
+		{name: "other storage provider", modify: func(bundle *types.FinalizedBundle) { bundle.StorageProviderId = "1" }},
+		{name: "other compression", modify: func(bundle *types.FinalizedBundle) { bundle.CompressionId = "0" }},
+		{name: "other data hash", modify: func(bundle *types.FinalizedBundle) { bundle.DataHash = "forged" }},
+		{name: "other from key", modify: func(bundle *types.FinalizedBundle) { bundle.FromKey = "400" }},
+		{name: "other to key", modify: func(bundle *types.FinalizedBundle) { bundle.ToKey = "600" }},
+		{name: "data of another bundle", modify: func(bundle *types.FinalizedBundle) { *bundle = testBundle("5"); bundle.Id = "4" }},
+		{name: "invalid bundle id", modify: func(bundle *types.FinalizedBundle) { bundle.Id = "four" }, expectedErr: "failed to parse bundle id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := newTestVerifier(t, newTestChainState())
+
+			bundle := testBundle("4")
+			tt.modify(&bundle)
+
+			err := verifier.VerifyBundle(2, bundle)
+
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("expected bundle to be verified, found %s", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected bundle which does not match the chain state to be rejected")
+			}
+
+			if tt.expectedErr != "" {
+				if !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("expected error %q, found %s", tt.expectedErr, err)
+				}
+				return
+			}
+
+			if !errors.Is(err, utils.ErrBlockValidation) || !strings.Contains(err.Error(), "does not match the KYVE chain state") {
+				t.Fatalf("expected block validation error, found %s", err)
+			}
+		})
+	}
+}
+
+func TestVerifyBundleRejectsForgedState(t *testing.T) {
+	state := newTestChainState()
+	verifier := newTestVerifier(t, state)
+
+	// the rpc endpoint serves a forged bundle which is not part of the trusted app hash
+	forged := testBundle("4")
+	forged.DataHash = "forged"
+	state.stores[storeBundles][1][1] = marshalStoreBundle(forged)
+
+	if err := verifier.VerifyBundle(2, forged); err == nil || !errors.Is(err, utils.ErrBlockValidation) || !strings.Contains(err.Error(), "failed to verify value") {
+		t.Fatalf("expected forged chain state to be rejected, found %v", err)
+	}
+}
+
+func TestVerifyPool(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(pool *types.PoolResponse)
+		rejected bool
+	}{
+		{name: "pool", modify: func(pool *types.PoolResponse) {}},
+		{name: "newer current key", modify: func(pool *types.PoolResponse) { pool.Pool.Data.CurrentKey = "600"; pool.Pool.Data.TotalBundles = 12 }},
+		{name: "other runtime", modify: func(pool *types.PoolResponse) { pool.Pool.Data.Runtime = utils.RuntimeTendermintSsync }, rejected: true},
+		{name: "other start key", modify: func(pool *types.PoolResponse) { pool.Pool.Data.StartKey = "2" }, rejected: true},
+		{name: "other config", modify: func(pool *types.PoolResponse) { pool.Pool.Data.Config = `{"network":"other-1"}` }, rejected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := newTestVerifier(t, newTestChainState())
+
+			pool := testPool()
+			tt.modify(&pool)
+
+			err := verifier.VerifyPool(&pool)
+
+			if !tt.rejected {
+				if err != nil {
+					t.Fatalf("expected pool to be verified, found %s", err)
+				}
+				return
+			}
+
+			if err == nil || !errors.Is(err, utils.ErrBlockValidation) {
+				t.Fatalf("expected pool which does not match the chain state to be rejected, found %v", err)
+			}
+		})
+	}
+}
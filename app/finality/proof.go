@@ -0,0 +1,64 @@
+package finality
+
+import (
+	"bytes"
+	"fmt"
+	cmtcrypto "github.com/KYVENetwork/cometbft/v38/proto/cometbft/v38/crypto"
+	ics23 "github.com/cosmos/ics23/go"
+)
+
+// proof op types of the cosmos-sdk multistore, the first op proves the key in the IAVL
+// tree of the module store and the second one the root of that store in the app hash
+const (
+	proofOpIavl   = "ics23:iavl"
+	proofOpSimple = "ics23:simple"
+)
+
+// verifyProofOps verifies that the value of the key in the module store is part of the app hash
+func verifyProofOps(proofOps []cmtcrypto.ProofOp, store string, key, value, appHash []byte) error {
+	if len(proofOps) != 2 || proofOps[0].Type != proofOpIavl || proofOps[1].Type != proofOpSimple {
+		return fmt.Errorf("expected %s and %s proof ops, found %d ops", proofOpIavl, proofOpSimple, len(proofOps))
+	}
+
+	storeRoot, err := verifyExistence(proofOps[0].Data, ics23.IavlSpec, key, value)
+	if err != nil {
+		return fmt.Errorf("failed to verify proof of key %x in store %s: %w", key, store, err)
+	}
+
+	root, err := verifyExistence(proofOps[1].Data, ics23.TendermintSpec, []byte(store), storeRoot)
+	if err != nil {
+		return fmt.Errorf("failed to verify proof of store %s: %w", store, err)
+	}
+
+	if !bytes.Equal(root, appHash) {
+		return fmt.Errorf("proof of key %x in store %s does not match app hash %X", key, store, appHash)
+	}
+
+	return nil
+}
+
+// verifyExistence verifies the proof for the key and value against the spec and returns the calculated root
+func verifyExistence(data []byte, spec *ics23.ProofSpec, key, value []byte) ([]byte, error) {
+	proof := &ics23.CommitmentProof{}
+	if err := proof.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal commitment proof: %w", err)
+	}
+
+	exist := ics23.Decompress(proof).GetExist()
+	if exist == nil {
+		return nil, fmt.Errorf("commitment proof is no existence proof")
+	}
+
+	root, err := exist.Calculate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate root: %w", err)
+	}
+
+	// the root is calculated from the proof itself, so the proof only has a meaning
+	// if the root gets checked against a trusted one by the caller
+	if err := exist.Verify(spec, root, key, value); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
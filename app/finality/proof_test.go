@@ -0,0 +1,275 @@
+package finality
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	cmtcrypto "github.com/KYVENetwork/cometbft/v38/proto/cometbft/v38/crypto"
+	ics23 "github.com/cosmos/ics23/go"
+)
+
+// merkleTree builds proofs in the format of the IAVL trees of the module stores
+// and the simple merkle tree of the multistore for balanced trees
+type merkleTree struct {
+	leaf        *ics23.LeafOp
+	innerPrefix func(height, size int64) []byte
+	childPrefix []byte
+}
+
+var (
+	// IAVL nodes start with the zigzag encoded height, size and version, the hashes of
+	// the children are length prefixed
+	iavlTree = merkleTree{
+		leaf: &ics23.LeafOp{
+			Hash:         ics23.HashOp_SHA256,
+			PrehashKey:   ics23.HashOp_NO_HASH,
+			PrehashValue: ics23.HashOp_SHA256,
+			Length:       ics23.LengthOp_VAR_PROTO,
+			Prefix:       binary.AppendVarint(binary.AppendVarint(binary.AppendVarint(nil, 0), 1), 1),
+		},
+		innerPrefix: func(height, size int64) []byte {
+			return binary.AppendVarint(binary.AppendVarint(binary.AppendVarint(nil, height), size), 1)
+		},
+		childPrefix: []byte{32},
+	}
+	simpleTree = merkleTree{
+		leaf: &ics23.LeafOp{
+			Hash:         ics23.HashOp_SHA256,
+			PrehashKey:   ics23.HashOp_NO_HASH,
+			PrehashValue: ics23.HashOp_SHA256,
+			Length:       ics23.LengthOp_VAR_PROTO,
+			Prefix:       []byte{0},
+		},
+		innerPrefix: func(height, size int64) []byte {
+			return []byte{1}
+		},
+	}
+)
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// prove returns the root of the tree and the existence proof of the key at the index,
+// the number of keys has to be a power of two
+func (tree merkleTree) prove(t *testing.T, keys, values [][]byte, index int) ([]byte, *ics23.ExistenceProof) {
+	t.Helper()
+
+	hashes := make([][]byte, len(keys))
+	for i := range keys {
+		hash, err := tree.leaf.Apply(keys[i], values[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes[i] = hash
+	}
+
+	root, _, path := tree.build(hashes, index)
+
+	return root, &ics23.ExistenceProof{Key: keys[index], Value: values[index], Leaf: tree.leaf, Path: path}
+}
+
+func (tree merkleTree) build(hashes [][]byte, index int) ([]byte, int64, []*ics23.InnerOp) {
+	if len(hashes) == 1 {
+		return hashes[0], 0, nil
+	}
+
+	mid := len(hashes) / 2
+	left, leftHeight, leftPath := tree.build(hashes[:mid], index)
+	right, rightHeight, rightPath := tree.build(hashes[mid:], index-mid)
+
+	prefix := tree.innerPrefix(max(leftHeight, rightHeight)+1, int64(len(hashes)))
+	leftChild, rightChild := concat(tree.childPrefix, left), concat(tree.childPrefix, right)
+	root := sha256.Sum256(concat(prefix, leftChild, rightChild))
+
+	if index < mid {
+		return root[:], max(leftHeight, rightHeight) + 1, append(leftPath, &ics23.InnerOp{Hash: ics23.HashOp_SHA256, Prefix: concat(prefix, tree.childPrefix), Suffix: rightChild})
+	}
+	return root[:], max(leftHeight, rightHeight) + 1, append(rightPath, &ics23.InnerOp{Hash: ics23.HashOp_SHA256, Prefix: concat(prefix, leftChild, tree.childPrefix)})
+}
+
+func marshalProofOp(t *testing.T, typ string, key []byte, exist *ics23.ExistenceProof) cmtcrypto.ProofOp {
+	t.Helper()
+
+	data, err := (&ics23.CommitmentProof{Proof: &ics23.CommitmentProof_Exist{Exist: exist}}).Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cmtcrypto.ProofOp{Type: typ, Key: key, Data: data}
+}
+
+// buildProofOps proves the finalized bundle 5 of pool 2 in the bundles store of a
+// multistore. The proofs can be modified before the app hash gets calculated from them
+func buildProofOps(t *testing.T, modifyIavl, modifySimple func(proof *ics23.ExistenceProof)) ([]cmtcrypto.ProofOp, []byte, []byte) {
+	t.Helper()
+
+	bundleKeys := [][]byte{finalizedBundleKey(2, 3), finalizedBundleKey(2, 4), finalizedBundleKey(2, 5), finalizedBundleKey(2, 6)}
+	bundleValues := [][]byte{[]byte("bundle 3"), []byte("bundle 4"), []byte("bundle 5"), []byte("bundle 6")}
+
+	storeRoot, iavlProof := iavlTree.prove(t, bundleKeys, bundleValues, 2)
+	if modifyIavl != nil {
+		modifyIavl(iavlProof)
+		root, err := iavlProof.Calculate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		storeRoot = root
+	}
+
+	storeKeys := [][]byte{[]byte("bank"), []byte("bundles"), []byte("pool"), []byte("stakers")}
+	storeRoots := [][]byte{bytes.Repeat([]byte{1}, 32), storeRoot, bytes.Repeat([]byte{2}, 32), bytes.Repeat([]byte{3}, 32)}
+
+	appHash, simpleProof := simpleTree.prove(t, storeKeys, storeRoots, 1)
+	if modifySimple != nil {
+		modifySimple(simpleProof)
+		root, err := simpleProof.Calculate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		appHash = root
+	}
+
+	proofOps := []cmtcrypto.ProofOp{
+		marshalProofOp(t, proofOpIavl, bundleKeys[2], iavlProof),
+		marshalProofOp(t, proofOpSimple, storeKeys[1], simpleProof),
+	}
+
+	return proofOps, bundleValues[2], appHash
+}
+
+func TestFinalizedBundleKey(t *testing.T) {
+	// the key layout of the KYVE chain: prefix, pool id and bundle id as big endian
+	expected := "02" + "0000000000000002" + "0000000000000105"
+
+	if key := hex.EncodeToString(finalizedBundleKey(2, 261)); key != expected {
+		t.Fatalf("expected key %s, found %s", expected, key)
+	}
+
+	if key := hex.EncodeToString(poolKey(2)); key != "01"+"0000000000000002" {
+		t.Fatalf("expected pool key 010000000000000002, found %s", key)
+	}
+}
+
+func TestVerifyProofOps(t *testing.T) {
+	proofOps, value, appHash := buildProofOps(t, nil, nil)
+
+	if err := verifyProofOps(proofOps, storeBundles, finalizedBundleKey(2, 5), value, appHash); err != nil {
+		t.Fatalf("expected valid proof, found %s", err)
+	}
+}
+
+func TestVerifyProofOpsRejectsInvalidProofs(t *testing.T) {
+	tests := []struct {
+		name         string
+		modifyIavl   func(proof *ics23.ExistenceProof)
+		modifySimple func(proof *ics23.ExistenceProof)
+		modify       func(proofOps []cmtcrypto.ProofOp, key, value, appHash []byte) ([]cmtcrypto.ProofOp, []byte, []byte, []byte)
+		store        string
+	}{
+		{
+			name: "tampered value",
+			modify: func(proofOps []cmtcrypto.ProofOp, key, value, appHash []byte) ([]cmtcrypto.ProofOp, []byte, []byte, []byte) {
+				return proofOps, key, []byte("bundle 7"), appHash
+			},
+		},
+		{
+			name: "tampered value in proof",
+			modifyIavl: func(proof *ics23.ExistenceProof) {
+				proof.Value = []byte("bundle 7")
+			},
+		},
+		{
+			name: "wrong app hash",
+			modify: func(proofOps []cmtcrypto.ProofOp, key, value, appHash []byte) ([]cmtcrypto.ProofOp, []byte, []byte, []byte) {
+				wrong := sha256.Sum256(appHash)
+				return proofOps, key, value, wrong[:]
+			},
+		},
+		{
+			name: "key of another bundle",
+			modify: func(proofOps []cmtcrypto.ProofOp, key, value, appHash []byte) ([]cmtcrypto.ProofOp, []byte, []byte, []byte) {
+				return proofOps, finalizedBundleKey(2, 6), value, appHash
+			},
+		},
+		{
+			name:  "proof of another store",
+			store: storePool,
+		},
+		{
+			name: "missing store proof",
+			modify: func(proofOps []cmtcrypto.ProofOp, key, value, appHash []byte) ([]cmtcrypto.ProofOp, []byte, []byte, []byte) {
+				return proofOps[:1], key, value, appHash
+			},
+		},
+		{
+			name: "swapped proof ops",
+			modify: func(proofOps []cmtcrypto.ProofOp, key, value, appHash []byte) ([]cmtcrypto.ProofOp, []byte, []byte, []byte) {
+				proofOps[0].Type, proofOps[1].Type = proofOps[1].Type, proofOps[0].Type
+				return proofOps, key, value, appHash
+			},
+		},
+		{
+			name: "corrupted proof data",
+			modify: func(proofOps []cmtcrypto.ProofOp, key, value, appHash []byte) ([]cmtcrypto.ProofOp, []byte, []byte, []byte) {
+				proofOps[0].Data = proofOps[0].Data[:len(proofOps[0].Data)/2]
+				return proofOps, key, value, appHash
+			},
+		},
+		{
+			// an inner node must not pass as a leaf, otherwise any inner node could be
+			// proven as a key value pair
+			name: "leaf with inner prefix",
+			modifySimple: func(proof *ics23.ExistenceProof) {
+				proof.Leaf = &ics23.LeafOp{Hash: proof.Leaf.Hash, PrehashKey: proof.Leaf.PrehashKey, PrehashValue: proof.Leaf.PrehashValue, Length: proof.Leaf.Length, Prefix: []byte{1}}
+			},
+		},
+		{
+			name: "inner node with leaf prefix",
+			modifySimple: func(proof *ics23.ExistenceProof) {
+				proof.Path[0].Prefix = []byte{0}
+			},
+		},
+		{
+			name: "iavl leaf with inner node height",
+			modifyIavl: func(proof *ics23.ExistenceProof) {
+				proof.Leaf = &ics23.LeafOp{Hash: proof.Leaf.Hash, PrehashKey: proof.Leaf.PrehashKey, PrehashValue: proof.Leaf.PrehashValue, Length: proof.Leaf.Length, Prefix: iavlTree.innerPrefix(1, 2)}
+			},
+		},
+		{
+			name: "iavl inner node with leaf prefix",
+			modifyIavl: func(proof *ics23.ExistenceProof) {
+				proof.Path[0].Prefix = concat(iavlTree.leaf.Prefix, proof.Path[0].Prefix[3:])
+			},
+		},
+		{
+			name: "iavl inner node with oversized child",
+			modifyIavl: func(proof *ics23.ExistenceProof) {
+				proof.Path[1].Suffix = append(proof.Path[1].Suffix, 0)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proofOps, value, appHash := buildProofOps(t, tt.modifyIavl, tt.modifySimple)
+			key := finalizedBundleKey(2, 5)
+
+			if tt.modify != nil {
+				proofOps, key, value, appHash = tt.modify(proofOps, key, value, appHash)
+			}
+
+			store := storeBundles
+			if tt.store != "" {
+				store = tt.store
+			}
+
+			if err := verifyProofOps(proofOps, store, key, value, appHash); err == nil {
+				t.Fatal("expected invalid proof to fail the verification")
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"fmt"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/metrics"
+	"github.com/KYVENetwork/ksync/setup/services"
+	"github.com/KYVENetwork/ksync/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	handoffRestartDelay    = 5 * time.Second
+	handoffMaxRestartDelay = 5 * time.Minute
+)
+
+// GetNodeCommand returns the command which runs the app in normal consensus mode with
+// CometBFT enabled. For cosmovisor the library path points to the "current" symlink so
+// that upgrades performed by cosmovisor also switch to the libraries of the upgrade
+func (app *CosmosApp) GetNodeCommand() types.NodeCommand {
+	command := types.NodeCommand{
+		Binary: app.binaryPath,
+		Env:    make([]string, 0),
+	}
+
+	if app.isCosmovisor {
+		command.Args = append(command.Args, "run")
+		command.Env = append(command.Env, fmt.Sprintf("LD_LIBRARY_PATH=%s", filepath.Join(app.homePath, "cosmovisor", "current", "bin")))
+
+		if flags.DaemonName != "" && flags.DaemonHome != "" {
+			command.Env = append(command.Env, fmt.Sprintf("DAEMON_NAME=%s", flags.DaemonName), fmt.Sprintf("DAEMON_HOME=%s", flags.DaemonHome))
+		}
+	} else {
+		command.Env = append(command.Env, fmt.Sprintf("LD_LIBRARY_PATH=%s", app.getLDLibraryPath()))
+	}
+
+	command.Args = append(command.Args, "start", "--home", app.homePath)
+
+	if flags.AppFlags != "" {
+		command.Args = append(command.Args, strings.Split(flags.AppFlags, ",")...)
+	}
+
+	return command
+}
+
+// Handoff stops the sync and starts the node in normal consensus mode, so it
+// continues from the height KSYNC reached without any manual intervention. If
+// a systemd unit path is provided the node is handed off to systemd instead
+func (app *CosmosApp) Handoff() error {
+	if !flags.Handoff {
+		return nil
+	}
+
+	app.StopAll()
+
+	if metrics.GetInterrupt() {
+		return nil
+	}
+
+	if flags.HandoffSystemdUnit != "" {
+		return app.handoffSystemd(flags.HandoffSystemdUnit)
+	}
+
+	logWriter := io.Writer(os.Stdout)
+
+	if flags.HandoffLogFile != "" {
+		logFile, err := os.OpenFile(flags.HandoffLogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open log file %s: %w", flags.HandoffLogFile, err)
+		}
+		defer logFile.Close()
+
+		logWriter = io.MultiWriter(os.Stdout, logFile)
+	}
+
+	restartDelay := handoffRestartDelay
+
+	for {
+		start := time.Now()
+		err := app.runNode(logWriter)
+
+		// the node received the interrupt too, so we are done here
+		if metrics.GetInterrupt() {
+			return nil
+		}
+
+		if !flags.HandoffRestart {
+			if err != nil {
+				return fmt.Errorf("node exited: %w", err)
+			}
+
+			return nil
+		}
+
+		// reset the delay if the node was running stable for a while
+		if time.Since(start) > handoffMaxRestartDelay {
+			restartDelay = handoffRestartDelay
+		}
+
+		logger.Logger.Error().Msgf("node exited with \"%v\", restarting in %s", err, restartDelay)
+		time.Sleep(restartDelay)
+
+		restartDelay = min(2*restartDelay, handoffMaxRestartDelay)
+	}
+}
+
+func (app *CosmosApp) runNode(logWriter io.Writer) error {
+	command := app.GetNodeCommand()
+
+	cmd := exec.Command(command.Binary, command.Args...)
+	cmd.Env = append(os.Environ(), command.Env...)
+	cmd.Stdout = logWriter
+	cmd.Stderr = logWriter
+
+	logger.Logger.Info().Msg("handing off to the node in normal consensus mode")
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start node: %w", err)
+	}
+
+	logger.Logger.Debug().Strs("args", cmd.Args).Strs("env", command.Env).Int("processId", cmd.Process.Pid).Msg("node started")
+
+	return waitForNode(cmd)
+}
+
+// waitForNode waits until the started node exits. The node is not stopped by signals
+// which are only sent to KSYNC, e.g. SIGTERM from docker or kill, so we forward them
+// and let KSYNC exit only after the node stopped
+func waitForNode(cmd *exec.Cmd) error {
+	interrupted := make(chan struct{})
+	exited := make(chan struct{})
+
+	metrics.SetInterruptHandler(func(sig os.Signal) {
+		close(interrupted)
+
+		logger.Logger.Info().Msgf("forwarding %s to the node and waiting for it to stop", sig)
+		if err := cmd.Process.Signal(sig); err != nil {
+			logger.Logger.Debug().Err(err).Msg("failed to forward signal to node")
+		}
+
+		<-exited
+	})
+
+	err := cmd.Wait()
+	close(exited)
+
+	metrics.SetInterruptHandler(nil)
+
+	select {
+	case <-interrupted:
+		// KSYNC exits in the interrupt handler once the metrics have been sent
+		<-(chan int)(nil)
+	default:
+	}
+
+	return err
+}
+
+// handoffSystemd writes a systemd unit for the node and starts it. If the unit can not be
+// started, e.g. because of missing permissions, we only log how the operator can start it
+func (app *CosmosApp) handoffSystemd(unitPath string) error {
+	name := filepath.Base(app.binaryPath)
+	if flags.DaemonName != "" {
+		name = flags.DaemonName
+	}
+
+	if err := os.WriteFile(unitPath, []byte(services.GetSystemdUnit(name, app.GetNodeCommand())), 0o644); err != nil {
+		return fmt.Errorf("failed to write systemd unit to %s: %w", unitPath, err)
+	}
+
+	unitName := filepath.Base(unitPath)
+	logger.Logger.Info().Msgf("wrote systemd unit to %s", unitPath)
+
+	for _, args := range [][]string{{"daemon-reload"}, {"enable", "--now", unitName}} {
+		if out, err := exec.Command("systemctl", args...).CombinedOutput(); err != nil {
+			logger.Logger.Warn().Msgf("failed to run systemctl %s: %s %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+			logger.Logger.Info().Msgf("start the node with \"sudo systemctl daemon-reload && sudo systemctl enable --now %s\"", unitName)
+			return nil
+		}
+	}
+
+	logger.Logger.Info().Msgf("handed off to systemd unit %s, follow the logs with \"journalctl -fu %s\"", unitName, unitName)
+	return nil
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/metrics"
+)
+
+// TestWaitForNodeHelper runs as KSYNC with a node in a child process of the test
+func TestWaitForNodeHelper(t *testing.T) {
+	stoppedPath := os.Getenv("KSYNC_TEST_NODE_STOPPED")
+	if stoppedPath == "" {
+		t.Skip("only runs as a child process of TestWaitForNodeForwardsSignals")
+	}
+
+	flags.OptOut = true
+	metrics.CatchInterrupt()
+
+	// the node needs a moment to stop like a real one
+	cmd := exec.Command("sh", "-c", fmt.Sprintf("trap 'sleep 0.5; echo stopped > %s; exit 0' TERM; echo started; while true; do sleep 0.1; done", stoppedPath))
+	cmd.Stdout = os.Stdout
+
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = waitForNode(cmd)
+	t.Fatal("expected KSYNC to exit in the interrupt handler")
+}
+
+func TestWaitForNodeForwardsSignals(t *testing.T) {
+	stoppedPath := filepath.Join(t.TempDir(), "stopped")
+
+	ksync := exec.Command(os.Args[0], "-test.run=^TestWaitForNodeHelper$")
+	ksync.Env = append(os.Environ(), fmt.Sprintf("KSYNC_TEST_NODE_STOPPED=%s", stoppedPath))
+
+	stdout, err := ksync.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ksync.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// wait until the node runs and has its signal handler installed
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() && !strings.Contains(scanner.Text(), "started") {
+	}
+
+	if err := ksync.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() {
+		for scanner.Scan() {
+		}
+		done <- ksync.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+			t.Fatalf("expected KSYNC to exit with code 1, found %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		_ = ksync.Process.Kill()
+		t.Fatal("expected KSYNC to exit after the node stopped")
+	}
+
+	// KSYNC only exits after the node handled the signal
+	data, err := os.ReadFile(stoppedPath)
+	if err != nil || strings.TrimSpace(string(data)) != "stopped" {
+		t.Fatalf("expected node to be stopped before KSYNC exited, found %q (%v)", data, err)
+	}
+}
@@ -0,0 +1,289 @@
+package pool
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/KYVENetwork/ksync/app/collector"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stallFactor is how many average bundle intervals may pass without
+// a new finalized bundle until the pool is reported as stalled
+const stallFactor = 5
+
+var (
+	storageProviders = map[string]string{
+		"1": "arweave",
+		"2": "bundlr",
+		"3": "kyve-storage",
+		"4": "turbo",
+	}
+	compressionTypes = map[string]string{
+		"0": "none",
+		"1": "gzip",
+	}
+)
+
+// poolKey is a parsed data item key, for block pools the chunk index is always zero
+type poolKey struct {
+	height     int64
+	chunkIndex int64
+}
+
+// Inspect reports the state of the pool and analyzes its latest bundles. If a block rpc
+// of the source chain is given the lag of the pool behind the source chain is calculated
+func Inspect(chainRest string, poolId, bundleCount int64, blockRpc string) (*types.PoolInspection, error) {
+	poolResponse, err := utils.GetPool(chainRest, poolId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool with id %d: %w", poolId, err)
+	}
+
+	data := poolResponse.Pool.Data
+
+	inspection := &types.PoolInspection{
+		PoolId:           poolId,
+		Runtime:          data.Runtime,
+		StartKey:         data.StartKey,
+		CurrentKey:       data.CurrentKey,
+		CurrentSummary:   data.CurrentSummary,
+		TotalBundles:     data.TotalBundles,
+		StorageProviders: make(map[string]int64),
+		CompressionTypes: make(map[string]int64),
+		Anomalies:        make([]string, 0),
+	}
+
+	parseKey, err := getKeyParser(data.Runtime)
+	if err != nil {
+		return nil, err
+	}
+
+	// without the interval of a snapshot pool we can still check the chunks
+	// of the snapshots but not the distance between them
+	isSnapshotPool := data.Runtime == utils.RuntimeTendermintSsync
+
+	var interval int64
+	if isSnapshotPool {
+		var config types.TendermintSSyncConfig
+		if err := json.Unmarshal([]byte(data.Config), &config); err != nil {
+			inspection.Anomalies = append(inspection.Anomalies, fmt.Sprintf("failed to unmarshal snapshot pool config: %s", err))
+		} else if config.Interval <= 0 {
+			inspection.Anomalies = append(inspection.Anomalies, fmt.Sprintf("snapshot pool config %s has no valid interval", data.Config))
+		}
+		interval = config.Interval
+	}
+
+	if data.CurrentKey == "" {
+		inspection.Anomalies = append(inspection.Anomalies, "pool has not archived any data yet")
+		return inspection, nil
+	}
+
+	if currentKey, err := parseKey(data.CurrentKey); err != nil {
+		inspection.Anomalies = append(inspection.Anomalies, fmt.Sprintf("failed to parse current key %s: %s", data.CurrentKey, err))
+	} else {
+		inspection.PoolHeight = &currentKey.height
+	}
+
+	if blockRpc != "" {
+		rpcCollector, err := collector.NewRpcBlockCollector(blockRpc, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get latest height of source chain: %w", err)
+		}
+
+		sourceHeight := rpcCollector.GetLatestAvailableHeight()
+		inspection.SourceHeight = &sourceHeight
+
+		if inspection.PoolHeight != nil {
+			lag := sourceHeight - *inspection.PoolHeight
+			inspection.Lag = &lag
+		}
+	}
+
+	// the bundles are returned newest first, we analyze them in the order they were created
+	bundles, _, err := utils.GetFinalizedBundlesPage(chainRest, poolId, bundleCount, "", true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get finalized bundles of pool %d: %w", poolId, err)
+	}
+
+	sort.Slice(bundles, func(i, j int) bool {
+		return parseId(bundles[i].Id) < parseId(bundles[j].Id)
+	})
+
+	inspection.InspectedBundles = int64(len(bundles))
+
+	if len(bundles) == 0 {
+		inspection.Anomalies = append(inspection.Anomalies, "pool has no finalized bundles")
+		return inspection, nil
+	}
+
+	latest := bundles[len(bundles)-1]
+	inspection.LatestBundleId = latest.Id
+
+	if latest.ToKey != data.CurrentKey {
+		inspection.Anomalies = append(inspection.Anomalies, fmt.Sprintf("current key %s of pool does not match to key %s of latest bundle %s", data.CurrentKey, latest.ToKey, latest.Id))
+	}
+
+	for _, bundle := range bundles {
+		inspection.StorageProviders[formatId(bundle.StorageProviderId, storageProviders)]++
+		inspection.CompressionTypes[formatId(bundle.CompressionId, compressionTypes)]++
+	}
+
+	inspection.Anomalies = append(inspection.Anomalies, checkContinuity(bundles, parseKey, isSnapshotPool, interval)...)
+
+	inspectCreationRate(inspection, bundles)
+
+	return inspection, nil
+}
+
+func getKeyParser(runtime string) (func(key string) (poolKey, error), error) {
+	switch runtime {
+	case utils.RuntimeTendermint, utils.RuntimeTendermintBsync:
+		return func(key string) (poolKey, error) {
+			height, err := strconv.ParseInt(key, 10, 64)
+			return poolKey{height: height}, err
+		}, nil
+	case utils.RuntimeTendermintSsync:
+		return func(key string) (poolKey, error) {
+			height, chunkIndex, err := utils.ParseSnapshotFromKey(key)
+			return poolKey{height: height, chunkIndex: chunkIndex}, err
+		}, nil
+	default:
+		return nil, fmt.Errorf("pool has unsupported runtime %s", runtime)
+	}
+}
+
+// checkContinuity checks that the bundles are consecutive and that every bundle continues
+// exactly where the previous one ended. Block pools archive every height, snapshot pools
+// archive all chunks of a snapshot before continuing with the next snapshot
+func checkContinuity(bundles []types.FinalizedBundle, parseKey func(key string) (poolKey, error), isSnapshotPool bool, interval int64) (anomalies []string) {
+	var previous *types.FinalizedBundle
+	var previousTo poolKey
+
+	for i := range bundles {
+		bundle := &bundles[i]
+
+		from, fromErr := parseKey(bundle.FromKey)
+		to, toErr := parseKey(bundle.ToKey)
+		if fromErr != nil || toErr != nil {
+			anomalies = append(anomalies, fmt.Sprintf("bundle %s has unparsable keys %s - %s", bundle.Id, bundle.FromKey, bundle.ToKey))
+			previous = nil
+			continue
+		}
+
+		if to.height < from.height || (to.height == from.height && to.chunkIndex < from.chunkIndex) {
+			anomalies = append(anomalies, fmt.Sprintf("bundle %s ends with key %s before its from key %s", bundle.Id, bundle.ToKey, bundle.FromKey))
+		}
+
+		if previous != nil {
+			if parseId(bundle.Id) != parseId(previous.Id)+1 {
+				anomalies = append(anomalies, fmt.Sprintf("bundles between %s and %s are missing", previous.Id, bundle.Id))
+			} else if anomaly := checkKeyTransition(previous, bundle, previousTo, from, isSnapshotPool, interval); anomaly != "" {
+				anomalies = append(anomalies, anomaly)
+			}
+		}
+
+		previous = bundle
+		previousTo = to
+	}
+
+	return anomalies
+}
+
+// checkKeyTransition checks that the bundle continues where the previous bundle ended,
+// the snapshot interval is only checked if it is known
+func checkKeyTransition(previous, bundle *types.FinalizedBundle, previousTo, from poolKey, isSnapshotPool bool, interval int64) string {
+	// block pools have no chunks, so the next height has to follow directly
+	if !isSnapshotPool {
+		switch {
+		case from.height == previousTo.height+1:
+			return ""
+		case from.height > previousTo.height+1:
+			return fmt.Sprintf("heights %d to %d are missing between bundles %s and %s", previousTo.height+1, from.height-1, previous.Id, bundle.Id)
+		default:
+			return fmt.Sprintf("bundle %s starts at height %d which was already archived by bundle %s", bundle.Id, from.height, previous.Id)
+		}
+	}
+
+	if from.height == previousTo.height {
+		if from.chunkIndex != previousTo.chunkIndex+1 {
+			return fmt.Sprintf("bundle %s continues snapshot %d with chunk %d after chunk %d", bundle.Id, from.height, from.chunkIndex, previousTo.chunkIndex)
+		}
+		return ""
+	}
+
+	if from.chunkIndex != 0 {
+		return fmt.Sprintf("bundle %s starts snapshot %d with chunk %d", bundle.Id, from.height, from.chunkIndex)
+	}
+
+	// the bundle summary "height/format/chunkIndex/totalChunks" tells us if the previous snapshot is complete
+	if summary := strings.Split(previous.BundleSummary, "/"); len(summary) == 4 {
+		if totalChunks, err := strconv.ParseInt(summary[3], 10, 64); err == nil && totalChunks != previousTo.chunkIndex+1 {
+			return fmt.Sprintf("snapshot %d ended after chunk %d of %d chunks", previousTo.height, previousTo.chunkIndex, totalChunks)
+		}
+	}
+
+	if interval > 0 && from.height-previousTo.height != interval {
+		return fmt.Sprintf("snapshot %d follows snapshot %d which does not match the snapshot interval %d", from.height, previousTo.height, interval)
+	}
+
+	return ""
+}
+
+// inspectCreationRate calculates how often bundles are finalized and
+// reports the pool as stalled if no bundle was finalized for too long
+func inspectCreationRate(inspection *types.PoolInspection, bundles []types.FinalizedBundle) {
+	var timestamps []int64
+
+	for _, bundle := range bundles {
+		if bundle.FinalizedAt == nil {
+			continue
+		}
+
+		timestamp, err := strconv.ParseInt(bundle.FinalizedAt.Timestamp, 10, 64)
+		if err != nil || timestamp == 0 {
+			continue
+		}
+
+		timestamps = append(timestamps, timestamp)
+	}
+
+	if len(timestamps) == 0 {
+		inspection.Anomalies = append(inspection.Anomalies, "bundles have no finalization timestamps, creation rate is unknown")
+		return
+	}
+
+	age := time.Now().Unix() - timestamps[len(timestamps)-1]
+	inspection.LatestBundleAge = &age
+
+	if len(timestamps) < 2 {
+		return
+	}
+
+	averageInterval := float64(timestamps[len(timestamps)-1]-timestamps[0]) / float64(len(timestamps)-1)
+	inspection.AverageBundleInterval = &averageInterval
+
+	if averageInterval > 0 {
+		bundlesPerHour := 3600 / averageInterval
+		inspection.BundlesPerHour = &bundlesPerHour
+
+		if float64(age) > stallFactor*averageInterval {
+			inspection.Anomalies = append(inspection.Anomalies, fmt.Sprintf("no bundle was finalized for %s, %.1f times the average bundle interval", time.Duration(age)*time.Second, float64(age)/averageInterval))
+		}
+	}
+}
+
+func parseId(id string) int64 {
+	value, _ := strconv.ParseInt(id, 10, 64)
+	return value
+}
+
+func formatId(id string, names map[string]string) string {
+	if name, found := names[id]; found {
+		return fmt.Sprintf("%s (%s)", name, id)
+	}
+	return fmt.Sprintf("unknown (%s)", id)
+}
@@ -0,0 +1,211 @@
+package pool
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+)
+
+// testBundles creates consecutive bundles with the given from and to keys
+func testBundles(keys ...[2]string) []types.FinalizedBundle {
+	bundles := make([]types.FinalizedBundle, 0, len(keys))
+	for i, key := range keys {
+		bundles = append(bundles, types.FinalizedBundle{Id: strconv.Itoa(i + 10), FromKey: key[0], ToKey: key[1]})
+	}
+	return bundles
+}
+
+func TestCheckContinuity(t *testing.T) {
+	parseBlockKey, err := getKeyParser(utils.RuntimeTendermintBsync)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parseSnapshotKey, err := getKeyParser(utils.RuntimeTendermintSsync)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withSummary := func(bundles []types.FinalizedBundle, index int, summary string) []types.FinalizedBundle {
+		bundles[index].BundleSummary = summary
+		return bundles
+	}
+
+	withId := func(bundles []types.FinalizedBundle, index int, id string) []types.FinalizedBundle {
+		bundles[index].Id = id
+		return bundles
+	}
+
+	tests := []struct {
+		name           string
+		bundles        []types.FinalizedBundle
+		isSnapshotPool bool
+		interval       int64
+		expected       []string
+	}{
+		{
+			name:    "consecutive blocks",
+			bundles: testBundles([2]string{"1", "100"}, [2]string{"101", "200"}, [2]string{"201", "300"}),
+		},
+		{
+			name:     "missing heights",
+			bundles:  testBundles([2]string{"1", "100"}, [2]string{"151", "200"}),
+			expected: []string{"heights 101 to 150 are missing between bundles 10 and 11"},
+		},
+		{
+			name:     "overlapping heights",
+			bundles:  testBundles([2]string{"1", "100"}, [2]string{"100", "200"}),
+			expected: []string{"bundle 11 starts at height 100 which was already archived by bundle 10"},
+		},
+		{
+			name:     "missing bundles",
+			bundles:  withId(testBundles([2]string{"1", "100"}, [2]string{"101", "200"}), 1, "12"),
+			expected: []string{"bundles between 10 and 12 are missing"},
+		},
+		{
+			name:     "to key before from key",
+			bundles:  testBundles([2]string{"1", "100"}, [2]string{"101", "99"}),
+			expected: []string{"bundle 11 ends with key 99 before its from key 101"},
+		},
+		{
+			name:     "unparsable keys",
+			bundles:  testBundles([2]string{"1", "100"}, [2]string{"a", "b"}, [2]string{"201", "300"}),
+			expected: []string{"bundle 11 has unparsable keys a - b"},
+		},
+		{
+			name:           "consecutive snapshots",
+			bundles:        withSummary(testBundles([2]string{"100/0", "100/0"}, [2]string{"100/1", "100/1"}, [2]string{"200/0", "200/0"}), 1, "100/3/1/2"),
+			isSnapshotPool: true,
+			interval:       100,
+		},
+		{
+			name:           "missing chunk",
+			bundles:        testBundles([2]string{"100/0", "100/0"}, [2]string{"100/2", "100/2"}),
+			isSnapshotPool: true,
+			interval:       100,
+			expected:       []string{"bundle 11 continues snapshot 100 with chunk 2 after chunk 0"},
+		},
+		{
+			name:           "snapshot starts after the first chunk",
+			bundles:        testBundles([2]string{"100/0", "100/0"}, [2]string{"200/1", "200/1"}),
+			isSnapshotPool: true,
+			interval:       100,
+			expected:       []string{"bundle 11 starts snapshot 200 with chunk 1"},
+		},
+		{
+			name:           "incomplete snapshot",
+			bundles:        withSummary(testBundles([2]string{"100/0", "100/0"}, [2]string{"200/0", "200/0"}), 0, "100/3/0/2"),
+			isSnapshotPool: true,
+			interval:       100,
+			expected:       []string{"snapshot 100 ended after chunk 0 of 2 chunks"},
+		},
+		{
+			name:           "missing snapshot",
+			bundles:        testBundles([2]string{"100/0", "100/0"}, [2]string{"300/0", "300/0"}),
+			isSnapshotPool: true,
+			interval:       100,
+			expected:       []string{"snapshot 300 follows snapshot 100 which does not match the snapshot interval 100"},
+		},
+		{
+			name:           "snapshots without known interval",
+			bundles:        testBundles([2]string{"100/0", "100/0"}, [2]string{"100/1", "100/1"}, [2]string{"300/0", "300/0"}),
+			isSnapshotPool: true,
+		},
+		{
+			name:           "missing chunk without known interval",
+			bundles:        testBundles([2]string{"100/0", "100/0"}, [2]string{"100/2", "100/2"}),
+			isSnapshotPool: true,
+			expected:       []string{"bundle 11 continues snapshot 100 with chunk 2 after chunk 0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parseKey := parseBlockKey
+			if tt.isSnapshotPool {
+				parseKey = parseSnapshotKey
+			}
+
+			if anomalies := checkContinuity(tt.bundles, parseKey, tt.isSnapshotPool, tt.interval); !reflect.DeepEqual(anomalies, tt.expected) {
+				t.Fatalf("expected anomalies %q, found %q", tt.expected, anomalies)
+			}
+		})
+	}
+}
+
+// bundlesFinalizedAt creates bundles which were finalized the given seconds ago
+func bundlesFinalizedAt(secondsAgo ...int64) []types.FinalizedBundle {
+	now := time.Now().Unix()
+
+	bundles := make([]types.FinalizedBundle, 0, len(secondsAgo))
+	for _, ago := range secondsAgo {
+		bundles = append(bundles, types.FinalizedBundle{FinalizedAt: &types.FinalizedAt{Timestamp: fmt.Sprintf("%d", now-ago)}})
+	}
+	return bundles
+}
+
+func TestInspectCreationRate(t *testing.T) {
+	tests := []struct {
+		name            string
+		bundles         []types.FinalizedBundle
+		expectedAverage float64
+		expectedAnomaly string
+	}{
+		{
+			name:            "no timestamps",
+			bundles:         []types.FinalizedBundle{{}, {FinalizedAt: &types.FinalizedAt{Timestamp: "0"}}},
+			expectedAnomaly: "creation rate is unknown",
+		},
+		{
+			name:    "single bundle",
+			bundles: bundlesFinalizedAt(60),
+		},
+		{
+			name:            "regular bundles",
+			bundles:         bundlesFinalizedAt(360, 240, 120, 0),
+			expectedAverage: 120,
+		},
+		{
+			name:            "stalled pool",
+			bundles:         bundlesFinalizedAt(3900, 3780, 3660, 3600),
+			expectedAverage: 100,
+			expectedAnomaly: "times the average bundle interval",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inspection := &types.PoolInspection{}
+			inspectCreationRate(inspection, tt.bundles)
+
+			if tt.expectedAverage > 0 {
+				if inspection.AverageBundleInterval == nil || *inspection.AverageBundleInterval != tt.expectedAverage {
+					t.Fatalf("expected average bundle interval %.0f, found %v", tt.expectedAverage, inspection.AverageBundleInterval)
+				}
+
+				if inspection.BundlesPerHour == nil || *inspection.BundlesPerHour != 3600/tt.expectedAverage {
+					t.Fatalf("expected %.0f bundles per hour, found %v", 3600/tt.expectedAverage, inspection.BundlesPerHour)
+				}
+			} else if inspection.AverageBundleInterval != nil {
+				t.Fatalf("expected no average bundle interval, found %f", *inspection.AverageBundleInterval)
+			}
+
+			if tt.expectedAnomaly == "" {
+				if len(inspection.Anomalies) > 0 {
+					t.Fatalf("expected no anomalies, found %q", inspection.Anomalies)
+				}
+				return
+			}
+
+			if len(inspection.Anomalies) != 1 || !strings.Contains(inspection.Anomalies[0], tt.expectedAnomaly) {
+				t.Fatalf("expected anomaly %q, found %q", tt.expectedAnomaly, inspection.Anomalies)
+			}
+		})
+	}
+}
@@ -4,93 +4,45 @@ import (
 	"fmt"
 	"github.com/KYVENetwork/ksync/types"
 	"github.com/KYVENetwork/ksync/utils"
-	"gopkg.in/yaml.v2"
-	"io/ioutil"
-	"net/http"
 	"strconv"
-	"strings"
 )
 
-const (
-	redNo    = "\033[31m" + "NO" + "\033[0m"
-	greenYes = "\033[32m" + "YES" + "\033[0m"
-)
-
-func FormatOutput(entry *types.Entry, chainId string) (string, string, string) {
-	var blockKey, stateKey, heightKey string
-	if chainId == utils.ChainIdMainnet && entry.Networks.Kyve != nil {
-		blockKey, stateKey, heightKey = FormatKeys(entry.Networks.Kyve.BlockStartKey, entry.Networks.Kyve.LatestBlockKey, entry.Networks.Kyve.StateStartKey, entry.Networks.Kyve.LatestStateKey)
-	} else if chainId == utils.ChainIdKaon && entry.Networks.Kaon != nil {
-		blockKey, stateKey, heightKey = FormatKeys(entry.Networks.Kaon.BlockStartKey, entry.Networks.Kaon.LatestBlockKey, entry.Networks.Kaon.StateStartKey, entry.Networks.Kaon.LatestStateKey)
-	}
-	return blockKey, stateKey, heightKey
-}
-
-func LoadLatestPoolData(sourceRegistry types.SourceRegistry) (*types.SourceRegistry, error) {
+func LoadLatestPoolData(sourceRegistry types.SourceRegistry, network types.KyveNetwork) (*types.SourceRegistry, error) {
 	for _, entry := range sourceRegistry.Entries {
-		if entry.Networks.Kyve != nil && entry.Networks.Kyve.Integrations != nil && entry.Networks.Kyve.Integrations.KSYNC != nil {
-			if entry.Networks.Kyve.Integrations.KSYNC.BlockSyncPool != nil {
-				poolResponse, err := utils.GetPool(utils.RestEndpointMainnet, int64(*entry.Networks.Kyve.Integrations.KSYNC.BlockSyncPool))
-				if err != nil {
-					return nil, err
-				}
-				entry.Networks.Kyve.BlockStartKey = &poolResponse.Pool.Data.StartKey
-				entry.Networks.Kyve.LatestBlockKey = &poolResponse.Pool.Data.CurrentKey
-			}
-			if entry.Networks.Kyve.Integrations.KSYNC.StateSyncPool != nil {
-				poolResponse, err := utils.GetPool(utils.RestEndpointMainnet, int64(*entry.Networks.Kyve.Integrations.KSYNC.StateSyncPool))
-				if err != nil {
-					return nil, err
-				}
-				entry.Networks.Kyve.StateStartKey = &poolResponse.Pool.Data.StartKey
-				entry.Networks.Kyve.LatestStateKey = &poolResponse.Pool.Data.CurrentKey
-			}
+		properties := entry.Networks[network.RegistryKey]
+		if properties == nil || properties.Integrations == nil || properties.Integrations.KSYNC == nil {
+			continue
 		}
-		if entry.Networks.Kaon != nil && entry.Networks.Kaon.Integrations != nil && entry.Networks.Kaon.Integrations.KSYNC != nil {
-			if entry.Networks.Kaon.Integrations.KSYNC.BlockSyncPool != nil {
-				poolResponse, err := utils.GetPool(utils.RestEndpointKaon, int64(*entry.Networks.Kaon.Integrations.KSYNC.BlockSyncPool))
-				if err != nil {
-					return nil, err
-				}
-				entry.Networks.Kaon.BlockStartKey = &poolResponse.Pool.Data.StartKey
-				entry.Networks.Kaon.LatestBlockKey = &poolResponse.Pool.Data.CurrentKey
+
+		if properties.Integrations.KSYNC.BlockSyncPool != nil {
+			poolResponse, err := utils.GetPool(network.Rest, int64(*properties.Integrations.KSYNC.BlockSyncPool))
+			if err != nil {
+				return nil, err
 			}
-			if entry.Networks.Kaon.Integrations.KSYNC.StateSyncPool != nil {
-				poolResponse, err := utils.GetPool(utils.RestEndpointKaon, int64(*entry.Networks.Kaon.Integrations.KSYNC.StateSyncPool))
-				if err != nil {
-					return nil, err
-				}
-				entry.Networks.Kaon.StateStartKey = &poolResponse.Pool.Data.StartKey
-				entry.Networks.Kaon.LatestStateKey = &poolResponse.Pool.Data.CurrentKey
+			properties.BlockStartKey = &poolResponse.Pool.Data.StartKey
+			properties.LatestBlockKey = &poolResponse.Pool.Data.CurrentKey
+			properties.BlockRuntime = &poolResponse.Pool.Data.Runtime
+		}
+		if properties.Integrations.KSYNC.StateSyncPool != nil {
+			poolResponse, err := utils.GetPool(network.Rest, int64(*properties.Integrations.KSYNC.StateSyncPool))
+			if err != nil {
+				return nil, err
 			}
+			properties.StateStartKey = &poolResponse.Pool.Data.StartKey
+			properties.LatestStateKey = &poolResponse.Pool.Data.CurrentKey
+			properties.StateConfig = &poolResponse.Pool.Data.Config
 		}
 	}
 	return &sourceRegistry, nil
 }
 
-func GetSourceRegistry(url string) (*types.SourceRegistry, error) {
-	response, err := http.Get(url)
+func GetSourceRegistry(network types.KyveNetwork) (*types.SourceRegistry, error) {
+	sourceRegistry, err := LoadSourceRegistry()
 	if err != nil {
 		return nil, err
 	}
 
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("got status code %d != 200", response.StatusCode)
-	}
-
-	data, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var sourceRegistry types.SourceRegistry
-
-	err = yaml.Unmarshal(data, &sourceRegistry)
-	if err != nil {
-		return nil, err
-	}
-
-	r, err := LoadLatestPoolData(sourceRegistry)
+	r, err := LoadLatestPoolData(*sourceRegistry, network)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load latest pool data: %v", err)
 	}
@@ -98,47 +50,6 @@ func GetSourceRegistry(url string) (*types.SourceRegistry, error) {
 	return r, nil
 }
 
-func FormatKeys(blockStartKey, latestBlockKey, stateStartKey, latestStateKey *string) (string, string, string) {
-	var blockSync, stateSync, heightSync = redNo, redNo, redNo
-
-	if latestBlockKey != nil && *latestBlockKey != "" {
-		latestHeight, err := strconv.ParseInt(*latestBlockKey, 10, 64)
-		if err != nil {
-			panic(err)
-		}
-		startHeight, err := strconv.ParseInt(*blockStartKey, 10, 64)
-		if err != nil {
-			panic(err)
-		}
-
-		latestKey := fmt.Sprintf("  %v - %v", FormatNumberWithCommas(startHeight), FormatNumberWithCommas(latestHeight))
-		if *latestBlockKey != "" {
-			blockSync = greenYes
-			blockSync += latestKey
-		}
-	}
-	if latestStateKey != nil && *latestStateKey != "" {
-		latestHeight, err := strconv.ParseInt(strings.Split(*latestStateKey, "/")[0], 10, 64)
-		if err != nil {
-			panic(err)
-		}
-
-		startHeight, err := strconv.ParseInt(strings.Split(*stateStartKey, "/")[0], 10, 64)
-		if err != nil {
-			panic(err)
-		}
-
-		latestKey := fmt.Sprintf("  %v - %v", FormatNumberWithCommas(startHeight), FormatNumberWithCommas(latestHeight))
-		if *latestStateKey != "" {
-			stateSync = greenYes
-			heightSync = greenYes
-			stateSync += latestKey
-		}
-	}
-
-	return blockSync, stateSync, heightSync
-}
-
 func FormatNumberWithCommas(number int64) string {
 	// Convert the integer to a string
 	numberString := strconv.FormatInt(number, 10)
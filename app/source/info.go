@@ -0,0 +1,143 @@
+package source
+
+import (
+	"encoding/json"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+	"slices"
+	"sort"
+	"strconv"
+)
+
+// FilterSourceRegistry returns a registry with only the entries whose source id or chain
+// id is one of the given source ids, so the pool data only has to be loaded for them. If
+// no source ids are given all entries are returned
+func FilterSourceRegistry(sourceRegistry types.SourceRegistry, sourceIds []string) types.SourceRegistry {
+	if len(sourceIds) == 0 {
+		return sourceRegistry
+	}
+
+	filtered := types.SourceRegistry{Entries: make(map[string]types.Entry)}
+
+	for chainId, entry := range sourceRegistry.Entries {
+		if slices.Contains(sourceIds, entry.SourceID) || slices.Contains(sourceIds, chainId) {
+			filtered.Entries[chainId] = entry
+		}
+	}
+
+	return filtered
+}
+
+// GetSourceInfos returns the info of all sources with a KSYNC integration on the network
+// sorted by their source id. Keys which can not be parsed are logged and the fields
+// derived from them are left empty
+func GetSourceInfos(sourceRegistry types.SourceRegistry, network types.KyveNetwork) []types.SourceInfo {
+	infos := make([]types.SourceInfo, 0)
+
+	for chainId, entry := range sourceRegistry.Entries {
+		properties := entry.Networks[network.RegistryKey]
+		if properties == nil || properties.Integrations == nil || properties.Integrations.KSYNC == nil {
+			continue
+		}
+
+		info := types.SourceInfo{
+			SourceId: entry.SourceID,
+			ChainId:  chainId,
+			Upgrades: make([]types.SourceInfoUpgrade, 0),
+		}
+
+		if properties.SourceMetadata != nil {
+			info.Title = properties.SourceMetadata.Title
+		}
+
+		if poolId := properties.Integrations.KSYNC.BlockSyncPool; poolId != nil {
+			info.BlockPoolId = toInt64(*poolId)
+		}
+
+		if poolId := properties.Integrations.KSYNC.StateSyncPool; poolId != nil {
+			info.StatePoolId = toInt64(*poolId)
+		}
+
+		if properties.BlockRuntime != nil {
+			info.Runtime = *properties.BlockRuntime
+		}
+
+		if properties.LatestBlockKey != nil && *properties.LatestBlockKey != "" {
+			info.EarliestBlockHeight = parseBlockKey(chainId, properties.BlockStartKey)
+			info.LatestBlockHeight = parseBlockKey(chainId, properties.LatestBlockKey)
+		}
+
+		if properties.LatestStateKey != nil && *properties.LatestStateKey != "" {
+			info.EarliestSnapshotHeight = parseSnapshotKey(chainId, properties.StateStartKey)
+			info.LatestSnapshotHeight = parseSnapshotKey(chainId, properties.LatestStateKey)
+		}
+
+		if properties.StateConfig != nil {
+			var config types.TendermintSSyncConfig
+			if err := json.Unmarshal([]byte(*properties.StateConfig), &config); err != nil {
+				logger.Logger.Warn().Msgf("failed to unmarshal snapshot pool config of source %s: %s", chainId, err)
+			} else {
+				info.SnapshotInterval = &config.Interval
+			}
+		}
+
+		for _, upgrade := range entry.Codebase.Settings.Upgrades {
+			height, err := strconv.ParseInt(upgrade.Height, 10, 64)
+			if err != nil {
+				logger.Logger.Warn().Msgf("failed to parse height %s of upgrade %s of source %s", upgrade.Height, upgrade.Name, chainId)
+				continue
+			}
+
+			info.Upgrades = append(info.Upgrades, types.SourceInfoUpgrade{
+				Name:    upgrade.Name,
+				Height:  height,
+				Version: upgrade.RecommendedVersion,
+			})
+		}
+
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].SourceId == infos[j].SourceId {
+			return infos[i].ChainId < infos[j].ChainId
+		}
+		return infos[i].SourceId < infos[j].SourceId
+	})
+
+	return infos
+}
+
+func toInt64(value int) *int64 {
+	v := int64(value)
+	return &v
+}
+
+func parseBlockKey(chainId string, key *string) *int64 {
+	if key == nil {
+		return nil
+	}
+
+	height, err := strconv.ParseInt(*key, 10, 64)
+	if err != nil {
+		logger.Logger.Warn().Msgf("failed to parse block key \"%s\" of source %s", *key, chainId)
+		return nil
+	}
+
+	return &height
+}
+
+func parseSnapshotKey(chainId string, key *string) *int64 {
+	if key == nil {
+		return nil
+	}
+
+	height, _, err := utils.ParseSnapshotFromKey(*key)
+	if err != nil {
+		logger.Logger.Warn().Msgf("failed to parse snapshot key \"%s\" of source %s", *key, chainId)
+		return nil
+	}
+
+	return &height
+}
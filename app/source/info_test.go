@@ -0,0 +1,130 @@
+package source
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/types"
+)
+
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// testSourceRegistry contains a source with block and state sync, a source with only
+// block sync, a source without a KSYNC integration and a source of another network
+func testSourceRegistry() types.SourceRegistry {
+	return types.SourceRegistry{Entries: map[string]types.Entry{
+		"osmosis-1": {
+			SourceID: "osmosis",
+			Networks: types.Networks{"kyve-1": {
+				Integrations:   &types.Integrations{KSYNC: &types.KSYNCIntegration{BlockSyncPool: ptr(1), StateSyncPool: ptr(2)}},
+				SourceMetadata: &types.SourceMetadata{Title: "Osmosis"},
+				BlockStartKey:  ptr("1"),
+				LatestBlockKey: ptr("1000"),
+				StateStartKey:  ptr("3000/0"),
+				LatestStateKey: ptr("9000/4"),
+				BlockRuntime:   ptr("@kyvejs/tendermint-bsync"),
+				StateConfig:    ptr(`{"api":"https://rpc.osmosis.zone","interval":3000}`),
+			}},
+			Codebase: types.Codebase{Settings: types.CosmosSettings{Upgrades: []types.CosmosUpgrade{
+				{Name: "v2", Height: "500", RecommendedVersion: "v2.0.0"},
+				{Name: "v3", Height: "unknown", RecommendedVersion: "v3.0.0"},
+			}}},
+		},
+		"archway-1": {
+			SourceID: "archway",
+			Networks: types.Networks{"kyve-1": {
+				Integrations:   &types.Integrations{KSYNC: &types.KSYNCIntegration{BlockSyncPool: ptr(3)}},
+				SourceMetadata: &types.SourceMetadata{Title: "Archway"},
+				BlockStartKey:  ptr("1"),
+				LatestBlockKey: ptr("not a height"),
+				StateConfig:    ptr("not json"),
+			}},
+		},
+		"cosmoshub-4": {
+			SourceID: "cosmoshub",
+			Networks: types.Networks{"kyve-1": {Integrations: &types.Integrations{}}},
+		},
+		"dydx-mainnet-1": {
+			SourceID: "dydx",
+			Networks: types.Networks{"kaon-1": {
+				Integrations: &types.Integrations{KSYNC: &types.KSYNCIntegration{BlockSyncPool: ptr(4)}},
+			}},
+		},
+	}}
+}
+
+func TestFilterSourceRegistry(t *testing.T) {
+	tests := []struct {
+		name      string
+		sourceIds []string
+		expected  []string
+	}{
+		{name: "no source ids", expected: []string{"archway-1", "cosmoshub-4", "dydx-mainnet-1", "osmosis-1"}},
+		{name: "by source id", sourceIds: []string{"osmosis"}, expected: []string{"osmosis-1"}},
+		{name: "by chain id", sourceIds: []string{"archway-1"}, expected: []string{"archway-1"}},
+		{name: "by source and chain id", sourceIds: []string{"archway-1", "dydx"}, expected: []string{"archway-1", "dydx-mainnet-1"}},
+		{name: "unknown source id", sourceIds: []string{"unknown"}, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := FilterSourceRegistry(testSourceRegistry(), tt.sourceIds)
+
+			chainIds := make([]string, 0)
+			for chainId := range filtered.Entries {
+				chainIds = append(chainIds, chainId)
+			}
+			sort.Strings(chainIds)
+
+			if !reflect.DeepEqual(chainIds, tt.expected) {
+				t.Fatalf("expected entries %v, found %v", tt.expected, chainIds)
+			}
+		})
+	}
+}
+
+func TestGetSourceInfos(t *testing.T) {
+	expected := []types.SourceInfo{
+		{
+			SourceId:            "archway",
+			Title:               "Archway",
+			ChainId:             "archway-1",
+			BlockPoolId:         ptr[int64](3),
+			EarliestBlockHeight: ptr[int64](1),
+			Upgrades:            []types.SourceInfoUpgrade{},
+		},
+		{
+			SourceId:               "osmosis",
+			Title:                  "Osmosis",
+			ChainId:                "osmosis-1",
+			BlockPoolId:            ptr[int64](1),
+			StatePoolId:            ptr[int64](2),
+			EarliestBlockHeight:    ptr[int64](1),
+			LatestBlockHeight:      ptr[int64](1000),
+			EarliestSnapshotHeight: ptr[int64](3000),
+			LatestSnapshotHeight:   ptr[int64](9000),
+			SnapshotInterval:       ptr[int64](3000),
+			Runtime:                "@kyvejs/tendermint-bsync",
+			Upgrades:               []types.SourceInfoUpgrade{{Name: "v2", Height: 500, Version: "v2.0.0"}},
+		},
+	}
+
+	infos := GetSourceInfos(testSourceRegistry(), types.KyveNetwork{ChainId: "kyve-1", RegistryKey: "kyve-1"})
+
+	if !reflect.DeepEqual(infos, expected) {
+		t.Fatalf("expected infos %+v, found %+v", expected, infos)
+	}
+
+	infos = GetSourceInfos(FilterSourceRegistry(testSourceRegistry(), []string{"osmosis"}), types.KyveNetwork{ChainId: "kyve-1", RegistryKey: "kyve-1"})
+
+	if !reflect.DeepEqual(infos, expected[1:]) {
+		t.Fatalf("expected infos %+v, found %+v", expected[1:], infos)
+	}
+
+	if infos := GetSourceInfos(testSourceRegistry(), types.KyveNetwork{ChainId: "kaon-1", RegistryKey: "kaon-1"}); len(infos) != 1 || infos[0].SourceId != "dydx" {
+		t.Fatalf("expected only the source of kaon-1, found %+v", infos)
+	}
+}
@@ -0,0 +1,198 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+	"gopkg.in/yaml.v2"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const registryRequestTimeout = 30 * time.Second
+
+var (
+	commitRegex = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+	// registryUrlTemplate is the url of the official source registry for a ref
+	registryUrlTemplate = utils.RegistryURLTemplate
+)
+
+// LoadSourceRegistry loads the source registry from --registry-url, which can be a url or a
+// local file. Remote registries are cached in the KSYNC directory and revalidated with their
+// ETag, if the registry can not be fetched the cached version is used instead. Registries
+// pinned to a commit with --registry-ref never change and are only downloaded once
+func LoadSourceRegistry() (*types.SourceRegistry, error) {
+	data, err := loadSourceRegistryData()
+	if err != nil {
+		return nil, err
+	}
+
+	var sourceRegistry types.SourceRegistry
+
+	if err := yaml.Unmarshal(data, &sourceRegistry); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal source-registry: %w", err)
+	}
+
+	return &sourceRegistry, nil
+}
+
+func getSourceRegistryUrl() (string, error) {
+	if flags.RegistryUrl == "" {
+		ref := utils.DefaultRegistryRef
+		if flags.RegistryRef != "" {
+			ref = flags.RegistryRef
+		}
+
+		return fmt.Sprintf(registryUrlTemplate, ref), nil
+	}
+
+	if flags.RegistryRef != "" {
+		return "", fmt.Errorf("flag --registry-ref can only be used with the default registry url")
+	}
+
+	return flags.RegistryUrl, nil
+}
+
+func loadSourceRegistryData() ([]byte, error) {
+	url, err := getSourceRegistryUrl()
+	if err != nil {
+		return nil, err
+	}
+
+	if path, isFile := strings.CutPrefix(url, "file://"); isFile || !strings.Contains(url, "://") {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source registry from %s: %w", path, err)
+		}
+
+		logger.Logger.Debug().Msgf("loaded source registry from %s", path)
+		return data, nil
+	}
+
+	dir, err := utils.GetKsyncDir("registry")
+	if err != nil {
+		return nil, err
+	}
+
+	cachePath := filepath.Join(dir, fmt.Sprintf("%s.yml", utils.CreateSha256Checksum([]byte(url))[:16]))
+	etagPath := fmt.Sprintf("%s.etag", cachePath)
+
+	cached, cacheErr := os.ReadFile(cachePath)
+	if cacheErr == nil && commitRegex.MatchString(flags.RegistryRef) {
+		logger.Logger.Debug().Msgf("loaded source registry pinned to %s from cache", flags.RegistryRef)
+		return cached, nil
+	}
+
+	etag := ""
+	if cacheErr == nil {
+		if data, err := os.ReadFile(etagPath); err == nil {
+			etag = strings.TrimSpace(string(data))
+		}
+	}
+
+	data, newEtag, notModified, err := fetchSourceRegistry(url, etag)
+	if err != nil {
+		if cacheErr != nil {
+			return nil, fmt.Errorf("failed to fetch source registry from %s: %w", url, err)
+		}
+
+		logger.Logger.Warn().Msgf("failed to fetch source registry from %s, using cached version: %s", url, err)
+		return cached, nil
+	}
+
+	if notModified {
+		logger.Logger.Debug().Msgf("cached source registry of %s is up to date", url)
+		return cached, nil
+	}
+
+	if err := writeFileAtomic(cachePath, data); err != nil {
+		logger.Logger.Debug().Msgf("failed to cache source registry: %s", err)
+	} else if newEtag != "" {
+		_ = writeFileAtomic(etagPath, []byte(newEtag))
+	} else {
+		_ = os.Remove(etagPath)
+	}
+
+	return data, nil
+}
+
+// fetchSourceRegistry downloads the registry, if the etag is still valid the
+// server responds with "304 Not Modified" and no data is returned
+func fetchSourceRegistry(url, etag string) ([]byte, string, bool, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+
+	client := &http.Client{Timeout: registryRequestTimeout}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified {
+		return nil, etag, true, nil
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return nil, "", false, fmt.Errorf("got status code %d != 200", response.StatusCode)
+	}
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	if len(data) == 0 {
+		return nil, "", false, errors.New("source registry is empty")
+	}
+
+	return data, response.Header.Get("ETag"), false, nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	// a unique temporary file prevents concurrent runs of KSYNC from writing into the same file
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), fmt.Sprintf("%s.*.tmp", filepath.Base(path)))
+	if err != nil {
+		return err
+	}
+	tmpPath := tmpFile.Name()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Chmod(tmpPath, 0o644); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,224 @@
+package source
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/flags"
+)
+
+func TestWriteFileAtomicConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "registry.yml")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := writeFileAtomic(path, []byte(fmt.Sprintf("registry %02d", i))); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every write replaces the whole file, so the content is one of the writes
+	var index int
+	if _, err := fmt.Sscanf(string(data), "registry %02d", &index); err != nil || len(data) != len("registry 00") {
+		t.Fatalf("expected content of a single write, found %q", data)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected only the registry file, found %d files", len(entries))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0o644 {
+		t.Fatalf("expected file mode 0644, found %o", info.Mode().Perm())
+	}
+}
+
+// registryServer serves the source registry with the current etag and responds
+// with "304 Not Modified" if the etag of the request still matches
+type registryServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	registry string
+	etag     string
+	requests []string
+}
+
+func newRegistryServer(t *testing.T, registry, etag string) *registryServer {
+	server := &registryServer{registry: registry, etag: etag}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mu.Lock()
+		defer server.mu.Unlock()
+
+		server.requests = append(server.requests, r.URL.Path)
+
+		if server.etag != "" && r.Header.Get("If-None-Match") == server.etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		if server.etag != "" {
+			w.Header().Set("ETag", server.etag)
+		}
+		_, _ = w.Write([]byte(server.registry))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (server *registryServer) update(registry, etag string) {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	server.registry, server.etag = registry, etag
+}
+
+func (server *registryServer) requestCount() int {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	return len(server.requests)
+}
+
+func setRegistryFlags(t *testing.T, registryUrl, registryRef string) {
+	savedUrl, savedRef, savedTemplate := flags.RegistryUrl, flags.RegistryRef, registryUrlTemplate
+	t.Cleanup(func() {
+		flags.RegistryUrl, flags.RegistryRef, registryUrlTemplate = savedUrl, savedRef, savedTemplate
+	})
+
+	flags.RegistryUrl, flags.RegistryRef = registryUrl, registryRef
+}
+
+func expectRegistry(t *testing.T, expected string) {
+	t.Helper()
+
+	data, err := loadSourceRegistryData()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != expected {
+		t.Fatalf("expected registry %q, found %q", expected, data)
+	}
+}
+
+func TestLoadSourceRegistryDataCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := newRegistryServer(t, "registry v1", `"v1"`)
+	setRegistryFlags(t, server.URL+"/registry.yml", "")
+
+	// the first request downloads the registry and caches it with its etag
+	expectRegistry(t, "registry v1")
+
+	// the cached registry is still valid, so the server responds with 304
+	expectRegistry(t, "registry v1")
+
+	// the changed registry replaces the cached one
+	server.update("registry v2", `"v2"`)
+	expectRegistry(t, "registry v2")
+	expectRegistry(t, "registry v2")
+
+	if count := server.requestCount(); count != 4 {
+		t.Fatalf("expected every load to revalidate the registry, found %d requests", count)
+	}
+
+	// if the server is down the cached registry is used
+	server.Close()
+	expectRegistry(t, "registry v2")
+}
+
+func TestLoadSourceRegistryDataWithoutEtag(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := newRegistryServer(t, "registry v1", `"v1"`)
+	setRegistryFlags(t, server.URL+"/registry.yml", "")
+
+	expectRegistry(t, "registry v1")
+
+	// without an etag the old etag must not be sent again, else the changed
+	// registry would never be downloaded
+	server.update("registry v2", "")
+	expectRegistry(t, "registry v2")
+
+	server.update("registry v3", `"v1"`)
+	expectRegistry(t, "registry v3")
+}
+
+func TestLoadSourceRegistryDataServerDown(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	server := newRegistryServer(t, "registry v1", "")
+	server.Close()
+
+	setRegistryFlags(t, server.URL+"/registry.yml", "")
+
+	if _, err := loadSourceRegistryData(); err == nil || !strings.Contains(err.Error(), "failed to fetch source registry") {
+		t.Fatalf("expected loading without cached registry to fail, found %v", err)
+	}
+}
+
+func TestLoadSourceRegistryDataPinnedRef(t *testing.T) {
+	tests := []struct {
+		name             string
+		ref              string
+		expectedRegistry string
+		expectedRequests int
+	}{
+		{name: "pinned to commit", ref: "0123456789abcdef0123456789abcdef01234567", expectedRegistry: "registry v1", expectedRequests: 1},
+		{name: "branch", ref: "develop", expectedRegistry: "registry v2", expectedRequests: 3},
+		{name: "short commit", ref: "0123456", expectedRegistry: "registry v2", expectedRequests: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+
+			server := newRegistryServer(t, "registry v1", `"v1"`)
+			setRegistryFlags(t, "", tt.ref)
+			registryUrlTemplate = server.URL + "/%s/registry.yml"
+
+			expectRegistry(t, "registry v1")
+
+			// a registry pinned to a commit can not change, so it is never requested again
+			server.update("registry v2", `"v2"`)
+			expectRegistry(t, tt.expectedRegistry)
+			expectRegistry(t, tt.expectedRegistry)
+
+			if count := server.requestCount(); count != tt.expectedRequests {
+				t.Fatalf("expected %d requests, found %d", tt.expectedRequests, count)
+			}
+
+			if server.requests[0] != fmt.Sprintf("/%s/registry.yml", tt.ref) {
+				t.Fatalf("expected registry of ref %s to be requested, found %s", tt.ref, server.requests[0])
+			}
+		})
+	}
+}
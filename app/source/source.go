@@ -5,9 +5,6 @@ import (
 	"github.com/KYVENetwork/ksync/flags"
 	"github.com/KYVENetwork/ksync/types"
 	"github.com/KYVENetwork/ksync/utils"
-	"gopkg.in/yaml.v2"
-	"io/ioutil"
-	"net/http"
 	"strconv"
 )
 
@@ -19,30 +16,14 @@ type Source struct {
 }
 
 func NewSource(sourceId string) (*Source, error) {
-	response, err := http.Get(utils.DefaultRegistryURL)
+	sourceRegistry, err := LoadSourceRegistry()
 	if err != nil {
 		return nil, err
 	}
 
-	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("got status code %d != 200", response.StatusCode)
-	}
-
-	data, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var sourceRegistry types.SourceRegistry
-
-	err = yaml.Unmarshal(data, &sourceRegistry)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal source-registry: %w", err)
-	}
-
 	return &Source{
 		sourceId:       sourceId,
-		sourceRegistry: sourceRegistry,
+		sourceRegistry: *sourceRegistry,
 	}, nil
 }
 
@@ -56,19 +37,17 @@ func (source *Source) GetSourceBlockPoolId() (int64, error) {
 		return 0, fmt.Errorf("source with id \"%s\" not found in registry", source.sourceId)
 	}
 
-	if flags.ChainId == utils.ChainIdMainnet {
-		if entry.Networks.Kyve == nil || entry.Networks.Kyve.Integrations.KSYNC.BlockSyncPool == nil {
-			return 0, fmt.Errorf("failed to get snapshot pool id from registry entry")
-		}
-		return int64(*entry.Networks.Kyve.Integrations.KSYNC.BlockSyncPool), nil
-	} else if flags.ChainId == utils.ChainIdKaon {
-		if entry.Networks.Kaon == nil || entry.Networks.Kaon.Integrations.KSYNC.BlockSyncPool == nil {
-			return 0, fmt.Errorf("failed to get snapshot pool id from registry entry")
-		}
-		return int64(*entry.Networks.Kaon.Integrations.KSYNC.BlockSyncPool), nil
+	network, err := utils.GetKyveNetwork(flags.ChainId)
+	if err != nil {
+		return 0, err
+	}
+
+	properties := entry.Networks[network.RegistryKey]
+	if properties == nil || properties.Integrations == nil || properties.Integrations.KSYNC == nil || properties.Integrations.KSYNC.BlockSyncPool == nil {
+		return 0, fmt.Errorf("failed to get block pool id from registry entry for network %s", network.RegistryKey)
 	}
 
-	return 0, fmt.Errorf("failed to get block pool id from registry entry")
+	return int64(*properties.Integrations.KSYNC.BlockSyncPool), nil
 }
 
 func (source *Source) GetSourceSnapshotPoolId() (int64, error) {
@@ -81,19 +60,51 @@ func (source *Source) GetSourceSnapshotPoolId() (int64, error) {
 		return 0, fmt.Errorf("source with id \"%s\" not found in registry", source.sourceId)
 	}
 
-	if flags.ChainId == utils.ChainIdMainnet {
-		if entry.Networks.Kyve == nil || entry.Networks.Kyve.Integrations.KSYNC.StateSyncPool == nil {
-			return 0, fmt.Errorf("failed to get snapshot pool id from registry entry")
+	network, err := utils.GetKyveNetwork(flags.ChainId)
+	if err != nil {
+		return 0, err
+	}
+
+	properties := entry.Networks[network.RegistryKey]
+	if properties == nil || properties.Integrations == nil || properties.Integrations.KSYNC == nil || properties.Integrations.KSYNC.StateSyncPool == nil {
+		return 0, fmt.Errorf("failed to get snapshot pool id from registry entry for network %s", network.RegistryKey)
+	}
+
+	return int64(*properties.Integrations.KSYNC.StateSyncPool), nil
+}
+
+// GetUpgradeHeight returns the height of the upgrade with the given name
+func (source *Source) GetUpgradeHeight(upgradeName string) (int64, error) {
+	entry, found := source.sourceRegistry.Entries[source.sourceId]
+	if !found {
+		return 0, fmt.Errorf("source with id \"%s\" not found in registry", source.sourceId)
+	}
+
+	for _, upgrade := range entry.Codebase.Settings.Upgrades {
+		if upgrade.Name != upgradeName {
+			continue
 		}
-		return int64(*entry.Networks.Kyve.Integrations.KSYNC.StateSyncPool), nil
-	} else if flags.ChainId == utils.ChainIdKaon {
-		if entry.Networks.Kaon == nil || entry.Networks.Kaon.Integrations.KSYNC.StateSyncPool == nil {
-			return 0, fmt.Errorf("failed to get snapshot pool id from registry entry")
+
+		upgradeHeight, err := strconv.ParseInt(upgrade.Height, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse upgrade height %s: %w", upgrade.Height, err)
 		}
-		return int64(*entry.Networks.Kaon.Integrations.KSYNC.StateSyncPool), nil
+
+		return upgradeHeight, nil
+	}
+
+	return 0, fmt.Errorf("upgrade \"%s\" not found in registry", upgradeName)
+}
+
+// GetGenesisMirror returns the url and the checksum of the genesis file
+// mirrored by KYVE, if the registry entry of the source provides one
+func (source *Source) GetGenesisMirror() (string, string) {
+	entry, found := source.sourceRegistry.Entries[source.sourceId]
+	if !found {
+		return "", ""
 	}
 
-	return 0, fmt.Errorf("failed to get block pool id from registry entry")
+	return entry.Codebase.GenesisUrl, entry.Codebase.GenesisChecksum
 }
 
 func (source *Source) GetUpgradeNameForHeight(height int64) (string, error) {
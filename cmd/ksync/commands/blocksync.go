@@ -16,22 +16,41 @@ func init() {
 
 	blockSyncCmd.Flags().StringVarP(&flags.HomePath, "home", "h", "", "home directory")
 
-	blockSyncCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, fmt.Sprintf("KYVE chain id [\"%s\",\"%s\",\"%s\"]", utils.ChainIdMainnet, utils.ChainIdKaon, utils.ChainIdKorellia))
+	blockSyncCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, utils.ChainIdUsage())
 
 	blockSyncCmd.Flags().StringVar(&flags.ChainRest, "chain-rest", "", "rest endpoint for KYVE chain")
+	blockSyncCmd.Flags().StringVar(&flags.ChainGrpc, "chain-grpc", "", "gRPC endpoint for KYVE chain, queries fall back to the rest endpoint if they fail. Use \"https://\" for TLS")
+	blockSyncCmd.Flags().StringVar(&flags.ChainRpc, "chain-rpc", "", "rpc endpoint for KYVE chain, used to verify bundle finality")
+	blockSyncCmd.Flags().StringVar(&flags.ChainRpcWitnesses, "chain-rpc-witnesses", "", "comma separated rpc endpoints for KYVE chain which cross-check the headers of --chain-rpc")
+	blockSyncCmd.Flags().BoolVar(&flags.VerifyFinality, "verify-finality", false, "verify bundles and pools with state proofs against light client verified KYVE headers instead of trusting the rest endpoint")
+	blockSyncCmd.Flags().Int64Var(&flags.TrustedHeight, "trusted-height", 0, "height of a trusted KYVE block for the light client, required for --verify-finality")
+	blockSyncCmd.Flags().StringVar(&flags.TrustedHash, "trusted-hash", "", "hex encoded hash of the trusted KYVE block, required for --verify-finality")
 	blockSyncCmd.Flags().StringVar(&flags.StorageRest, "storage-rest", "", "storage endpoint for requesting bundle data")
 
 	blockSyncCmd.Flags().StringVar(&flags.BlockPoolId, "block-pool-id", "", "pool-id of the block-sync pool")
 
 	blockSyncCmd.Flags().Int64VarP(&flags.TargetHeight, "target-height", "t", 0, "target height (including)")
+	blockSyncCmd.Flags().StringVar(&flags.TargetUpgrade, "target-upgrade", "", "sync to the last height before this upgrade, resolved from the source registry")
+	blockSyncCmd.Flags().StringVar(&flags.TargetTime, "target-time", "", "sync to the last block created at or before this time in RFC3339 format. Example: --target-time=\"2024-06-01T00:00:00Z\"")
 
 	blockSyncCmd.Flags().BoolVar(&flags.RpcServer, "rpc-server", false, "rpc server serving /status, /block and /block_results")
 	blockSyncCmd.Flags().Int64Var(&flags.RpcServerPort, "rpc-server-port", utils.DefaultRpcServerPort, fmt.Sprintf("port for rpc server"))
 
+	blockSyncCmd.Flags().Int64Var(&flags.AppHashCheckInterval, "app-hash-check-interval", 0, "compare the app hash and last results hash with the next block header every n blocks, 0 to disable")
+	blockSyncCmd.Flags().StringVar(&flags.AppHashRpc, "app-hash-rpc", "", "reference rpc endpoint to additionally compare the app hash and last results hash against, checks every block if no interval is set")
+
 	blockSyncCmd.Flags().StringVarP(&flags.AppFlags, "app-flags", "f", "", "custom flags which are applied to the app binary start command. Example: --app-flags=\"--x-crisis-skip-assert-invariants,--iavl-disable-fastnode\"")
 
+	blockSyncCmd.Flags().BoolVar(&flags.Handoff, "handoff", false, "start the node in normal consensus mode after the target height was reached")
+	blockSyncCmd.Flags().BoolVar(&flags.HandoffRestart, "handoff-restart", false, "restart the node after the handoff if it crashes")
+	blockSyncCmd.Flags().StringVar(&flags.HandoffLogFile, "handoff-log-file", "", "additionally write the logs of the node after the handoff to this file")
+	blockSyncCmd.Flags().StringVar(&flags.HandoffSystemdUnit, "handoff-systemd-unit", "", "hand off to a systemd unit written to this path instead of running the node in KSYNC. Example: --handoff-systemd-unit=/etc/systemd/system/osmosisd.service")
+
 	blockSyncCmd.Flags().BoolVarP(&flags.AutoSelectBinaryVersion, "auto-select-binary-version", "a", false, "if provided binary is cosmovisor KSYNC will automatically change the \"current\" symlink to the correct upgrade version")
 	blockSyncCmd.Flags().BoolVarP(&flags.Reset, "reset-all", "r", false, "reset this node's validator to genesis state")
+	blockSyncCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "url or local path of the source registry (default is the official KYVE source registry)")
+	blockSyncCmd.Flags().StringVar(&flags.RegistryRef, "registry-ref", "", "branch, tag or commit of the official source registry to use (default \"main\")")
+
 	blockSyncCmd.Flags().BoolVar(&flags.OptOut, "opt-out", false, "disable the collection of anonymous usage data")
 	blockSyncCmd.Flags().BoolVarP(&flags.Debug, "debug", "d", false, "run KSYNC in debug mode")
 	blockSyncCmd.Flags().BoolVarP(&flags.AppLogs, "app-logs", "l", false, "show logs from cosmos app")
@@ -44,9 +63,6 @@ func init() {
 	blockSyncCmd.Flags().StringVarP(&flags.Source, "source", "s", "", "")
 	_ = blockSyncCmd.Flags().MarkDeprecated("source", "source is detected automatically")
 
-	blockSyncCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "")
-	_ = blockSyncCmd.Flags().MarkDeprecated("registry-url", "registry url is fixed")
-
 	RootCmd.AddCommand(blockSyncCmd)
 }
 
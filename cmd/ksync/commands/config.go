@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/setup/nodeconfig"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	configSetCmd.Flags().StringVar(&flags.HomePath, "home", "", "home directory")
+	if err := configSetCmd.MarkFlagRequired("home"); err != nil {
+		panic(fmt.Errorf("flag 'home' should be required: %w", err))
+	}
+
+	configSetCmd.Flags().StringVar(&flags.ConfigFile, "file", "", "config file which should be edited [\"config\",\"app\"], if not specified the file which already contains the key is used")
+
+	configSetCmd.Flags().BoolVar(&flags.OptOut, "opt-out", false, "disable the collection of anonymous usage data")
+	configSetCmd.Flags().BoolVarP(&flags.Debug, "debug", "d", false, "run KSYNC in debug mode")
+
+	configCmd.AddCommand(configSetCmd)
+	RootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Edit the config.toml and app.toml of a node",
+}
+
+var configSetCmd = &cobra.Command{
+	Use:     "set [key] [value]",
+	Short:   "Set a value in the config.toml or app.toml, keys of tables are addressed with \"<table>.<key>\"",
+	Example: "ksync config set state-sync.snapshot-interval 1000 --home ~/.osmosisd",
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, value := args[0], args[1]
+
+		var path string
+
+		switch flags.ConfigFile {
+		case "config":
+			path = nodeconfig.ConfigPath(flags.HomePath)
+		case "app":
+			path = nodeconfig.AppPath(flags.HomePath)
+		case "":
+			for _, p := range []string{nodeconfig.ConfigPath(flags.HomePath), nodeconfig.AppPath(flags.HomePath)} {
+				file, err := nodeconfig.Load(p)
+				if err != nil {
+					return err
+				}
+
+				if file.Has(key) {
+					path = p
+					break
+				}
+			}
+
+			if path == "" {
+				return fmt.Errorf("key %s not found in config.toml or app.toml, please specify the file with --file", key)
+			}
+		default:
+			return fmt.Errorf("file has to be either \"config\" or \"app\", instead found \"%s\"", flags.ConfigFile)
+		}
+
+		file, err := nodeconfig.Load(path)
+		if err != nil {
+			return err
+		}
+
+		if err := file.SetFromString(key, value); err != nil {
+			return err
+		}
+
+		if err := file.Save(); err != nil {
+			return err
+		}
+
+		logger.Logger.Info().Str("file", path).Msgf("successfully set %s to %s", key, value)
+		return nil
+	},
+}
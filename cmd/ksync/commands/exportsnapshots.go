@@ -0,0 +1,71 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/sync/exportsnapshots"
+	"github.com/KYVENetwork/ksync/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	exportSnapshotsCmd.Flags().StringVarP(&flags.BinaryPath, "binary", "b", "", "binary path to the cosmos app")
+	if err := exportSnapshotsCmd.MarkFlagRequired("binary"); err != nil {
+		panic(fmt.Errorf("flag 'binary' should be required: %w", err))
+	}
+
+	exportSnapshotsCmd.Flags().StringVarP(&flags.HomePath, "home", "h", "", "home directory")
+
+	exportSnapshotsCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, utils.ChainIdUsage())
+
+	exportSnapshotsCmd.Flags().StringVar(&flags.ChainRest, "chain-rest", "", "rest endpoint for KYVE chain")
+	exportSnapshotsCmd.Flags().StringVar(&flags.ChainGrpc, "chain-grpc", "", "gRPC endpoint for KYVE chain, queries fall back to the rest endpoint if they fail. Use \"https://\" for TLS")
+	exportSnapshotsCmd.Flags().StringVar(&flags.ChainRpc, "chain-rpc", "", "rpc endpoint for KYVE chain, used to verify bundle finality")
+	exportSnapshotsCmd.Flags().StringVar(&flags.ChainRpcWitnesses, "chain-rpc-witnesses", "", "comma separated rpc endpoints for KYVE chain which cross-check the headers of --chain-rpc")
+	exportSnapshotsCmd.Flags().BoolVar(&flags.VerifyFinality, "verify-finality", false, "verify bundles and pools with state proofs against light client verified KYVE headers instead of trusting the rest endpoint")
+	exportSnapshotsCmd.Flags().Int64Var(&flags.TrustedHeight, "trusted-height", 0, "height of a trusted KYVE block for the light client, required for --verify-finality")
+	exportSnapshotsCmd.Flags().StringVar(&flags.TrustedHash, "trusted-hash", "", "hex encoded hash of the trusted KYVE block, required for --verify-finality")
+	exportSnapshotsCmd.Flags().StringVar(&flags.StorageRest, "storage-rest", "", "storage endpoint for requesting bundle data")
+
+	exportSnapshotsCmd.Flags().StringVar(&flags.BlockPoolId, "block-pool-id", "", "pool-id of the block-sync pool")
+	exportSnapshotsCmd.Flags().StringVar(&flags.BlockRpc, "block-rpc", "", "rpc endpoint of the source chain to request blocks from instead of KYVE")
+	exportSnapshotsCmd.Flags().Int64Var(&flags.BlockRpcReqTimeout, "block-rpc-req-timeout", utils.RequestBlocksTimeoutMS, "timeout in milliseconds between block requests to the rpc endpoint")
+
+	exportSnapshotsCmd.Flags().StringVar(&flags.SnapshotDir, "snapshot-dir", "", "directory the snapshots get exported to")
+	if err := exportSnapshotsCmd.MarkFlagRequired("snapshot-dir"); err != nil {
+		panic(fmt.Errorf("flag 'snapshot-dir' should be required: %w", err))
+	}
+
+	exportSnapshotsCmd.Flags().Int64Var(&flags.SnapshotInterval, "snapshot-interval", 0, "block interval at which the app creates and KSYNC exports snapshots")
+	if err := exportSnapshotsCmd.MarkFlagRequired("snapshot-interval"); err != nil {
+		panic(fmt.Errorf("flag 'snapshot-interval' should be required: %w", err))
+	}
+
+	exportSnapshotsCmd.Flags().BoolVar(&flags.RpcServer, "rpc-server", false, "rpc server serving /status, /block and /block_results")
+	exportSnapshotsCmd.Flags().Int64Var(&flags.RpcServerPort, "rpc-server-port", utils.DefaultRpcServerPort, "port for rpc server")
+
+	exportSnapshotsCmd.Flags().Int64VarP(&flags.TargetHeight, "target-height", "t", 0, "the height up to which snapshots are exported, KSYNC syncs two blocks further if a snapshot is at or one below it")
+
+	exportSnapshotsCmd.Flags().BoolVar(&flags.Pruning, "pruning", true, "prune application.db, state.db, blockstore db and snapshots of the app, exported snapshots are never pruned")
+
+	exportSnapshotsCmd.Flags().StringVarP(&flags.AppFlags, "app-flags", "f", "", "custom flags which are applied to the app binary start command. Example: --app-flags=\"--x-crisis-skip-assert-invariants,--iavl-disable-fastnode\"")
+
+	exportSnapshotsCmd.Flags().BoolVarP(&flags.AutoSelectBinaryVersion, "auto-select-binary-version", "a", false, "if provided binary is cosmovisor KSYNC will automatically change the \"current\" symlink to the correct upgrade version")
+	exportSnapshotsCmd.Flags().BoolVarP(&flags.Reset, "reset-all", "r", false, "reset this node's validator to genesis state")
+	exportSnapshotsCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "url or local path of the source registry (default is the official KYVE source registry)")
+	exportSnapshotsCmd.Flags().StringVar(&flags.RegistryRef, "registry-ref", "", "branch, tag or commit of the official source registry to use (default \"main\")")
+
+	exportSnapshotsCmd.Flags().BoolVar(&flags.OptOut, "opt-out", false, "disable the collection of anonymous usage data")
+	exportSnapshotsCmd.Flags().BoolVarP(&flags.Debug, "debug", "d", false, "run KSYNC in debug mode")
+	exportSnapshotsCmd.Flags().BoolVarP(&flags.AppLogs, "app-logs", "l", false, "show logs from cosmos app")
+
+	RootCmd.AddCommand(exportSnapshotsCmd)
+}
+
+var exportSnapshotsCmd = &cobra.Command{
+	Use:   "export-snapshots",
+	Short: "Export state-sync snapshots to a local directory without a KYVE snapshot pool",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return exportsnapshots.Start()
+	},
+}
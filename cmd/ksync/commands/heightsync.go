@@ -16,20 +16,42 @@ func init() {
 
 	heightSyncCmd.Flags().StringVarP(&flags.HomePath, "home", "h", "", "home directory")
 
-	heightSyncCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, fmt.Sprintf("KYVE chain id [\"%s\",\"%s\",\"%s\"]", utils.ChainIdMainnet, utils.ChainIdKaon, utils.ChainIdKorellia))
+	heightSyncCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, utils.ChainIdUsage())
 
 	heightSyncCmd.Flags().StringVar(&flags.ChainRest, "chain-rest", "", "rest endpoint for KYVE chain")
+	heightSyncCmd.Flags().StringVar(&flags.ChainGrpc, "chain-grpc", "", "gRPC endpoint for KYVE chain, queries fall back to the rest endpoint if they fail. Use \"https://\" for TLS")
+	heightSyncCmd.Flags().StringVar(&flags.ChainRpc, "chain-rpc", "", "rpc endpoint for KYVE chain, used to verify bundle finality")
+	heightSyncCmd.Flags().StringVar(&flags.ChainRpcWitnesses, "chain-rpc-witnesses", "", "comma separated rpc endpoints for KYVE chain which cross-check the headers of --chain-rpc")
+	heightSyncCmd.Flags().BoolVar(&flags.VerifyFinality, "verify-finality", false, "verify bundles and pools with state proofs against light client verified KYVE headers instead of trusting the rest endpoint")
+	heightSyncCmd.Flags().Int64Var(&flags.TrustedHeight, "trusted-height", 0, "height of a trusted KYVE block for the light client, required for --verify-finality")
+	heightSyncCmd.Flags().StringVar(&flags.TrustedHash, "trusted-hash", "", "hex encoded hash of the trusted KYVE block, required for --verify-finality")
 	heightSyncCmd.Flags().StringVar(&flags.StorageRest, "storage-rest", "", "storage endpoint for requesting bundle data")
 
 	heightSyncCmd.Flags().StringVar(&flags.SnapshotPoolId, "snapshot-pool-id", "", "pool-id of the state-sync pool")
+	heightSyncCmd.Flags().StringVar(&flags.SnapshotDir, "snapshot-dir", "", "local directory with exported snapshots to state-sync from instead of a KYVE snapshot pool")
 	heightSyncCmd.Flags().StringVar(&flags.BlockPoolId, "block-pool-id", "", "pool-id of the block-sync pool")
+	heightSyncCmd.Flags().StringVar(&flags.BlockRpc, "block-rpc", "", "rpc endpoint of the source chain to request blocks from instead of KYVE")
+	heightSyncCmd.Flags().Int64Var(&flags.BlockRpcReqTimeout, "block-rpc-req-timeout", utils.RequestBlocksTimeoutMS, "timeout in milliseconds between block requests to the rpc endpoint")
+
+	heightSyncCmd.Flags().Int64Var(&flags.AppHashCheckInterval, "app-hash-check-interval", 0, "compare the app hash and last results hash with the next block header every n blocks, 0 to disable")
+	heightSyncCmd.Flags().StringVar(&flags.AppHashRpc, "app-hash-rpc", "", "reference rpc endpoint to additionally compare the app hash and last results hash against, checks every block if no interval is set")
 
 	heightSyncCmd.Flags().StringVarP(&flags.AppFlags, "app-flags", "f", "", "custom flags which are applied to the app binary start command. Example: --app-flags=\"--x-crisis-skip-assert-invariants,--iavl-disable-fastnode\"")
 
 	heightSyncCmd.Flags().Int64VarP(&flags.TargetHeight, "target-height", "t", 0, "target height (including), if not specified it will sync to the latest available block height")
+	heightSyncCmd.Flags().StringVar(&flags.TargetUpgrade, "target-upgrade", "", "sync to the last height before this upgrade, resolved from the source registry")
+	heightSyncCmd.Flags().StringVar(&flags.TargetTime, "target-time", "", "sync to the last block created at or before this time in RFC3339 format. Example: --target-time=\"2024-06-01T00:00:00Z\"")
+
+	heightSyncCmd.Flags().BoolVar(&flags.Handoff, "handoff", false, "start the node in normal consensus mode after the target height was reached")
+	heightSyncCmd.Flags().BoolVar(&flags.HandoffRestart, "handoff-restart", false, "restart the node after the handoff if it crashes")
+	heightSyncCmd.Flags().StringVar(&flags.HandoffLogFile, "handoff-log-file", "", "additionally write the logs of the node after the handoff to this file")
+	heightSyncCmd.Flags().StringVar(&flags.HandoffSystemdUnit, "handoff-systemd-unit", "", "hand off to a systemd unit written to this path instead of running the node in KSYNC. Example: --handoff-systemd-unit=/etc/systemd/system/osmosisd.service")
 
 	heightSyncCmd.Flags().BoolVarP(&flags.AutoSelectBinaryVersion, "auto-select-binary-version", "a", false, "if provided binary is cosmovisor KSYNC will automatically change the \"current\" symlink to the correct upgrade version")
 	heightSyncCmd.Flags().BoolVarP(&flags.Reset, "reset-all", "r", false, "reset this node's validator to genesis state")
+	heightSyncCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "url or local path of the source registry (default is the official KYVE source registry)")
+	heightSyncCmd.Flags().StringVar(&flags.RegistryRef, "registry-ref", "", "branch, tag or commit of the official source registry to use (default \"main\")")
+
 	heightSyncCmd.Flags().BoolVar(&flags.OptOut, "opt-out", false, "disable the collection of anonymous usage data")
 	heightSyncCmd.Flags().BoolVarP(&flags.Debug, "debug", "d", false, "run KSYNC in debug mode")
 	heightSyncCmd.Flags().BoolVarP(&flags.AppLogs, "app-logs", "l", false, "show logs from cosmos app")
@@ -42,9 +64,6 @@ func init() {
 	heightSyncCmd.Flags().StringVarP(&flags.Source, "source", "s", "", "")
 	_ = heightSyncCmd.Flags().MarkDeprecated("source", "source is detected automatically")
 
-	heightSyncCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "")
-	_ = heightSyncCmd.Flags().MarkDeprecated("registry-url", "registry url is fixed")
-
 	RootCmd.AddCommand(heightSyncCmd)
 }
 
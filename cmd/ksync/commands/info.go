@@ -1,19 +1,36 @@
 package commands
 
 import (
-	_ "embed"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"github.com/KYVENetwork/ksync/app/source"
 	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/types"
 	"github.com/KYVENetwork/ksync/utils"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+	"io"
 	"os"
-	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	redNo    = "\033[31m" + "NO" + "\033[0m"
+	greenYes = "\033[32m" + "YES" + "\033[0m"
 )
 
 func init() {
-	infoCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, fmt.Sprintf("KYVE chain id [\"%s\",\"%s\"]", utils.ChainIdMainnet, utils.ChainIdKaon))
+	infoCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, utils.ChainIdUsage())
+
+	infoCmd.Flags().StringVarP(&flags.Output, "output", "o", "table", "output format [\"table\",\"json\",\"yaml\",\"csv\"]")
+	infoCmd.Flags().StringVar(&flags.SourceIds, "source-id", "", "comma separated source or chain ids to show, shows all sources if empty")
+
+	infoCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "url or local path of the source registry (default is the official KYVE source registry)")
+	infoCmd.Flags().StringVar(&flags.RegistryRef, "registry-ref", "", "branch, tag or commit of the official source registry to use (default \"main\")")
 
 	infoCmd.Flags().BoolVar(&flags.OptOut, "opt-out", false, "disable the collection of anonymous usage data")
 	infoCmd.Flags().BoolVarP(&flags.Debug, "debug", "d", false, "run KSYNC in debug mode")
@@ -24,92 +41,153 @@ func init() {
 var infoCmd = &cobra.Command{
 	Use:   "info",
 	Short: "Get KSYNC chain support information",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		switch flags.Output {
+		case "table":
+		case "json", "yaml", "csv":
+			logger.RedirectToStderr()
+		default:
+			return fmt.Errorf("flag --output has to be one of \"table\", \"json\", \"yaml\" or \"csv\"")
+		}
+		return nil
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if flags.ChainId != utils.ChainIdMainnet && flags.ChainId != utils.ChainIdKaon {
-			return fmt.Errorf("chain-id %s not supported", flags.ChainId)
+		network, err := utils.GetKyveNetwork(flags.ChainId)
+		if err != nil {
+			return err
 		}
 
-		sourceRegistry, err := source.GetSourceRegistry(utils.DefaultRegistryURL)
+		sourceRegistry, err := source.LoadSourceRegistry()
 		if err != nil {
 			return fmt.Errorf("failed to get source registry: %w", err)
 		}
 
-		// Sort SourceRegistry
-		sortFunc := func(keys []string) {
-			sort.Slice(keys, func(i, j int) bool {
-				return sourceRegistry.Entries[keys[i]].SourceID < sourceRegistry.Entries[keys[j]].SourceID
-			})
-		}
-
-		var keys []string
-		for key, entry := range sourceRegistry.Entries {
-			if flags.ChainId == utils.ChainIdMainnet {
-				if entry.Networks.Kyve != nil {
-					if entry.Networks.Kyve.Integrations != nil {
-						if entry.Networks.Kyve.Integrations.KSYNC != nil {
-							keys = append(keys, key)
-						}
-					}
-				}
-			}
-			if flags.ChainId == utils.ChainIdKaon {
-				if entry.Networks.Kaon != nil {
-					if entry.Networks.Kaon.Integrations != nil {
-						if entry.Networks.Kaon.Integrations.KSYNC != nil {
-							keys = append(keys, key)
-						}
-					}
-				}
+		var sourceIds []string
+		for _, sourceId := range strings.Split(flags.SourceIds, ",") {
+			if sourceId = strings.TrimSpace(sourceId); sourceId != "" {
+				sourceIds = append(sourceIds, sourceId)
 			}
 		}
-		sortFunc(keys)
-
-		t := table.NewWriter()
-		t.SetOutputMirror(os.Stdout)
-		t.AppendHeader(table.Row{"Source", "BLOCK-SYNC", "STATE-SYNC", "HEIGHT-SYNC"})
-
-		for _, key := range keys {
-			entry := sourceRegistry.Entries[key]
-
-			var title string
-
-			if flags.ChainId == utils.ChainIdMainnet {
-				if entry.Networks.Kyve != nil {
-					if entry.Networks.Kyve.Integrations != nil {
-						if entry.Networks.Kyve.Integrations.KSYNC == nil {
-							continue
-						}
-						title = entry.Networks.Kyve.SourceMetadata.Title
-					}
-				} else {
-					continue
-				}
-			} else if flags.ChainId == utils.ChainIdKaon {
-				if entry.Networks.Kaon != nil {
-					if entry.Networks.Kaon.Integrations != nil {
-						if entry.Networks.Kaon.Integrations.KSYNC == nil {
-							continue
-						}
-						title = entry.Networks.Kaon.SourceMetadata.Title
-					}
-				} else {
-					continue
-				}
-			}
 
-			blockSync, stateSync, heightSync := source.FormatOutput(&entry, flags.ChainId)
-			t.AppendRows([]table.Row{
-				{
-					title,
-					blockSync,
-					stateSync,
-					heightSync,
-				},
-			})
+		// we only query the pools of the requested sources
+		sourceRegistry, err = source.LoadLatestPoolData(source.FilterSourceRegistry(*sourceRegistry, sourceIds), network)
+		if err != nil {
+			return fmt.Errorf("failed to load latest pool data: %w", err)
 		}
 
-		t.SetStyle(table.StyleRounded)
-		t.Render()
-		return nil
+		infos := source.GetSourceInfos(*sourceRegistry, network)
+
+		switch flags.Output {
+		case "json":
+			return printInfoJson(os.Stdout, infos)
+		case "yaml":
+			return printInfoYaml(os.Stdout, infos)
+		case "csv":
+			return printInfoCsv(os.Stdout, infos)
+		default:
+			printInfoTable(os.Stdout, infos)
+			return nil
+		}
 	},
 }
+
+func printInfoTable(w io.Writer, infos []types.SourceInfo) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"Source", "BLOCK-SYNC", "STATE-SYNC", "HEIGHT-SYNC"})
+
+	for _, info := range infos {
+		blockSync, stateSync, heightSync := redNo, redNo, redNo
+
+		if info.EarliestBlockHeight != nil && info.LatestBlockHeight != nil {
+			blockSync = fmt.Sprintf("%s  %s - %s", greenYes, source.FormatNumberWithCommas(*info.EarliestBlockHeight), source.FormatNumberWithCommas(*info.LatestBlockHeight))
+		}
+
+		if info.EarliestSnapshotHeight != nil && info.LatestSnapshotHeight != nil {
+			stateSync = fmt.Sprintf("%s  %s - %s", greenYes, source.FormatNumberWithCommas(*info.EarliestSnapshotHeight), source.FormatNumberWithCommas(*info.LatestSnapshotHeight))
+			heightSync = greenYes
+		}
+
+		t.AppendRow(table.Row{info.Title, blockSync, stateSync, heightSync})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	t.Render()
+}
+
+func printInfoJson(w io.Writer, infos []types.SourceInfo) error {
+	out, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal source info: %w", err)
+	}
+
+	_, err = fmt.Fprintln(w, string(out))
+	return err
+}
+
+func printInfoYaml(w io.Writer, infos []types.SourceInfo) error {
+	out, err := yaml.Marshal(infos)
+	if err != nil {
+		return fmt.Errorf("failed to marshal source info: %w", err)
+	}
+
+	_, err = fmt.Fprint(w, string(out))
+	return err
+}
+
+// printInfoCsv prints one row per source, the upgrades are joined
+// into a single column in the format "name@height@version;..."
+func printInfoCsv(out io.Writer, infos []types.SourceInfo) error {
+	w := csv.NewWriter(out)
+
+	if err := w.Write([]string{
+		"source_id",
+		"title",
+		"chain_id",
+		"block_pool_id",
+		"state_pool_id",
+		"earliest_block_height",
+		"latest_block_height",
+		"earliest_snapshot_height",
+		"latest_snapshot_height",
+		"snapshot_interval",
+		"runtime",
+		"upgrades",
+	}); err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		upgrades := make([]string, 0, len(info.Upgrades))
+		for _, upgrade := range info.Upgrades {
+			upgrades = append(upgrades, fmt.Sprintf("%s@%d@%s", upgrade.Name, upgrade.Height, upgrade.Version))
+		}
+
+		if err := w.Write([]string{
+			info.SourceId,
+			info.Title,
+			info.ChainId,
+			formatOptionalInt(info.BlockPoolId),
+			formatOptionalInt(info.StatePoolId),
+			formatOptionalInt(info.EarliestBlockHeight),
+			formatOptionalInt(info.LatestBlockHeight),
+			formatOptionalInt(info.EarliestSnapshotHeight),
+			formatOptionalInt(info.LatestSnapshotHeight),
+			formatOptionalInt(info.SnapshotInterval),
+			info.Runtime,
+			strings.Join(upgrades, ";"),
+		}); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+func formatOptionalInt(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/types"
+)
+
+func testSourceInfos() []types.SourceInfo {
+	blockPoolId, statePoolId, height, interval := int64(1), int64(2), int64(1000), int64(3000)
+
+	return []types.SourceInfo{
+		{
+			SourceId:          "archway",
+			Title:             "Archway, the \"dapp\" chain",
+			ChainId:           "archway-1",
+			BlockPoolId:       &blockPoolId,
+			LatestBlockHeight: &height,
+			Upgrades:          []types.SourceInfoUpgrade{},
+		},
+		{
+			SourceId:         "osmosis",
+			Title:            "Osmosis",
+			ChainId:          "osmosis-1",
+			StatePoolId:      &statePoolId,
+			SnapshotInterval: &interval,
+			Runtime:          "@kyvejs/tendermint-ssync",
+			Upgrades: []types.SourceInfoUpgrade{
+				{Name: "v2", Height: 500, Version: "v2.0.0"},
+				{Name: "v3", Height: 900, Version: "v3.0.0"},
+			},
+		},
+	}
+}
+
+func TestPrintInfoCsv(t *testing.T) {
+	var out bytes.Buffer
+	if err := printInfoCsv(&out, testSourceInfos()); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"source_id", "title", "chain_id", "block_pool_id", "state_pool_id", "earliest_block_height", "latest_block_height", "earliest_snapshot_height", "latest_snapshot_height", "snapshot_interval", "runtime", "upgrades"},
+		{"archway", "Archway, the \"dapp\" chain", "archway-1", "1", "", "", "1000", "", "", "", "", ""},
+		{"osmosis", "Osmosis", "osmosis-1", "", "2", "", "", "", "", "3000", "@kyvejs/tendermint-ssync", "v2@500@v2.0.0;v3@900@v3.0.0"},
+	}
+
+	if !reflect.DeepEqual(records, expected) {
+		t.Fatalf("expected records %q, found %q", expected, records)
+	}
+}
+
+func TestPrintInfoJson(t *testing.T) {
+	var out bytes.Buffer
+	if err := printInfoJson(&out, testSourceInfos()); err != nil {
+		t.Fatal(err)
+	}
+
+	var infos []types.SourceInfo
+	if err := json.Unmarshal(out.Bytes(), &infos); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(infos, testSourceInfos()) {
+		t.Fatalf("expected infos %+v, found %+v", testSourceInfos(), infos)
+	}
+
+	// missing values are printed as null instead of being omitted
+	var raw []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, found := raw[0]["state_pool_id"]; !found || value != nil {
+		t.Fatalf("expected state_pool_id to be null, found %v", value)
+	}
+
+	var empty bytes.Buffer
+	if err := printInfoJson(&empty, []types.SourceInfo{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if empty.String() != "[]\n" {
+		t.Fatalf("expected empty array for no sources, found %q", empty.String())
+	}
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/KYVENetwork/ksync/app/pool"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	poolInspectCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, utils.ChainIdUsage())
+
+	poolInspectCmd.Flags().StringVar(&flags.ChainRest, "chain-rest", "", "rest endpoint for KYVE chain")
+	poolInspectCmd.Flags().StringVar(&flags.ChainGrpc, "chain-grpc", "", "gRPC endpoint for KYVE chain, queries fall back to the rest endpoint if they fail. Use \"https://\" for TLS")
+
+	poolInspectCmd.Flags().StringVar(&flags.BlockRpc, "block-rpc", "", "rpc endpoint of the source chain to calculate the lag of the pool")
+	poolInspectCmd.Flags().Int64Var(&flags.InspectBundles, "bundles", 100, "number of latest bundles to analyze")
+	poolInspectCmd.Flags().StringVarP(&flags.Output, "output", "o", "table", "output format [\"table\",\"json\"]")
+
+	poolInspectCmd.Flags().BoolVar(&flags.OptOut, "opt-out", false, "disable the collection of anonymous usage data")
+	poolInspectCmd.Flags().BoolVarP(&flags.Debug, "debug", "d", false, "run KSYNC in debug mode")
+
+	poolCmd.AddCommand(poolInspectCmd)
+	RootCmd.AddCommand(poolCmd)
+}
+
+var poolCmd = &cobra.Command{
+	Use:   "pool",
+	Short: "Inspect KYVE data pools",
+}
+
+var poolInspectCmd = &cobra.Command{
+	Use:     "inspect [pool-id]",
+	Short:   "Report the health of a pool, its bundle creation rate and gaps in its data",
+	Example: "ksync pool inspect 1 --block-rpc https://rpc.cosmos.directory/cosmoshub",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		switch flags.Output {
+		case "table":
+		case "json":
+			logger.RedirectToStderr()
+		default:
+			return fmt.Errorf("flag --output has to be either \"table\" or \"json\"")
+		}
+
+		if flags.InspectBundles <= 0 || flags.InspectBundles > utils.BundlesPageLimit {
+			return fmt.Errorf("flag --bundles has to be between 1 and %d", utils.BundlesPageLimit)
+		}
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		poolId, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse pool id %s: %w", args[0], err)
+		}
+
+		chainRest, err := utils.GetChainRest()
+		if err != nil {
+			return err
+		}
+
+		inspection, err := pool.Inspect(chainRest, poolId, flags.InspectBundles, strings.TrimSuffix(flags.BlockRpc, "/"))
+		if err != nil {
+			return err
+		}
+
+		if flags.Output == "json" {
+			out, err := json.MarshalIndent(inspection, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal pool inspection: %w", err)
+			}
+
+			fmt.Println(string(out))
+			return nil
+		}
+
+		printPoolInspection(inspection)
+		return nil
+	},
+}
+
+func printPoolInspection(inspection *types.PoolInspection) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{fmt.Sprintf("Pool %d", inspection.PoolId), ""})
+
+	t.AppendRows([]table.Row{
+		{"Runtime", inspection.Runtime},
+		{"Start key", inspection.StartKey},
+		{"Current key", inspection.CurrentKey},
+		{"Current summary", inspection.CurrentSummary},
+		{"Total bundles", inspection.TotalBundles},
+		{"Inspected bundles", inspection.InspectedBundles},
+		{"Latest bundle", inspection.LatestBundleId},
+	})
+
+	t.AppendSeparator()
+
+	if inspection.LatestBundleAge != nil {
+		t.AppendRow(table.Row{"Latest bundle age", time.Duration(*inspection.LatestBundleAge) * time.Second})
+	}
+	if inspection.AverageBundleInterval != nil {
+		t.AppendRow(table.Row{"Average bundle interval", time.Duration(*inspection.AverageBundleInterval * float64(time.Second)).Round(time.Second)})
+	}
+	if inspection.BundlesPerHour != nil {
+		t.AppendRow(table.Row{"Bundles per hour", fmt.Sprintf("%.2f", *inspection.BundlesPerHour)})
+	}
+	if inspection.PoolHeight != nil {
+		t.AppendRow(table.Row{"Pool height", *inspection.PoolHeight})
+	}
+	if inspection.SourceHeight != nil {
+		t.AppendRow(table.Row{"Source height", *inspection.SourceHeight})
+	}
+	if inspection.Lag != nil {
+		t.AppendRow(table.Row{"Lag", fmt.Sprintf("%d blocks", *inspection.Lag)})
+	}
+
+	t.AppendSeparator()
+	t.AppendRow(table.Row{"Storage providers", formatDistribution(inspection.StorageProviders)})
+	t.AppendRow(table.Row{"Compression types", formatDistribution(inspection.CompressionTypes)})
+
+	t.AppendSeparator()
+	if len(inspection.Anomalies) == 0 {
+		t.AppendRow(table.Row{"Anomalies", "none"})
+	} else {
+		t.AppendRow(table.Row{"Anomalies", strings.Join(inspection.Anomalies, "\n")})
+	}
+
+	t.SetStyle(table.StyleRounded)
+	t.Render()
+}
+
+func formatDistribution(distribution map[string]int64) string {
+	keys := make([]string, 0, len(distribution))
+	for key := range distribution {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%s: %d", key, distribution[key]))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -1,8 +1,11 @@
 package commands
 
 import (
+	"github.com/KYVENetwork/ksync/app/collector"
 	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
 	"github.com/KYVENetwork/ksync/metrics"
+	"github.com/KYVENetwork/ksync/utils"
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
 	"os"
@@ -12,6 +15,22 @@ import (
 var RootCmd = &cobra.Command{
 	Use:   "ksync",
 	Short: "Fast Sync validated and archived blocks from KYVE to every Tendermint based Blockchain Application",
+	Long: `Fast Sync validated and archived blocks from KYVE to every Tendermint based Blockchain Application
+
+Failed requests are retried with exponential backoff, except if the server rejected
+the request with a 4xx status code (other than 408 and 429), those fail immediately.
+Only not found responses of storage provider gateways are retried, since just uploaded
+bundles can take a while to become available.
+
+If KSYNC fails it exits with a code describing the class of the error:
+  10  network error, retryable
+  11  checksum mismatch, retryable
+  12  decompression error, retryable
+  13  decode error
+  14  block validation error
+  15  app execution error
+  16  upgrade required
+   1  any other error`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		if flags.Debug {
 			zerolog.SetGlobalLevel(zerolog.DebugLevel)
@@ -25,11 +44,14 @@ func Execute() {
 	metrics.CatchInterrupt()
 
 	blockSyncCmd.Flags().SortFlags = false
+	configSetCmd.Flags().SortFlags = false
 	heightSyncCmd.Flags().SortFlags = false
 	resetCmd.Flags().SortFlags = false
 	servesnapshotsCmd.Flags().SortFlags = false
+	exportSnapshotsCmd.Flags().SortFlags = false
 	serveBlocksCmd.Flags().SortFlags = false
 	stateSyncCmd.Flags().SortFlags = false
+	verifyCmd.Flags().SortFlags = false
 	versionCmd.Flags().SortFlags = false
 
 	// overwrite help command so we can use -h as a shortcut for home
@@ -37,10 +59,20 @@ func Execute() {
 
 	errorRuntime := RootCmd.Execute()
 
+	collector.CloseBundleIndexes()
+
 	metrics.SendTrack(errorRuntime)
 	metrics.WaitForInterrupt()
 
+	// the exit code tells job orchestrators the class of the error, so they
+	// can decide whether the command should be retried
 	if errorRuntime != nil {
-		os.Exit(1)
+		exitCode := utils.GetExitCode(errorRuntime)
+
+		if class := utils.GetErrorClass(errorRuntime); class != "" {
+			logger.Logger.Error().Int("exit_code", exitCode).Bool("retryable", utils.GetRetryPolicy(errorRuntime).Retryable).Msgf("KSYNC failed with %s", class)
+		}
+
+		os.Exit(exitCode)
 	}
 }
@@ -21,6 +21,9 @@ func init() {
 		panic(fmt.Errorf("flag 'block-rpc' should be required: %w", err))
 	}
 
+	serveBlocksCmd.Flags().Int64Var(&flags.AppHashCheckInterval, "app-hash-check-interval", 0, "compare the app hash and last results hash with the next block header every n blocks, 0 to disable")
+	serveBlocksCmd.Flags().StringVar(&flags.AppHashRpc, "app-hash-rpc", "", "reference rpc endpoint to additionally compare the app hash and last results hash against, checks every block if no interval is set")
+
 	serveBlocksCmd.Flags().StringVarP(&flags.AppFlags, "app-flags", "f", "", "custom flags which are applied to the app binary start command. Example: --app-flags=\"--x-crisis-skip-assert-invariants,--iavl-disable-fastnode\"")
 
 	serveBlocksCmd.Flags().Int64VarP(&flags.TargetHeight, "target-height", "t", 0, "the height at which KSYNC will exit once reached")
@@ -32,6 +35,9 @@ func init() {
 
 	serveBlocksCmd.Flags().BoolVarP(&flags.AutoSelectBinaryVersion, "auto-select-binary-version", "a", false, "if provided binary is cosmovisor KSYNC will automatically change the \"current\" symlink to the correct upgrade version")
 	serveBlocksCmd.Flags().BoolVarP(&flags.Reset, "reset-all", "r", false, "reset this node's validator to genesis state")
+	serveBlocksCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "url or local path of the source registry (default is the official KYVE source registry)")
+	serveBlocksCmd.Flags().StringVar(&flags.RegistryRef, "registry-ref", "", "branch, tag or commit of the official source registry to use (default \"main\")")
+
 	serveBlocksCmd.Flags().BoolVar(&flags.OptOut, "opt-out", false, "disable the collection of anonymous usage data")
 	serveBlocksCmd.Flags().BoolVarP(&flags.Debug, "debug", "d", false, "run KSYNC in debug mode")
 	serveBlocksCmd.Flags().BoolVarP(&flags.AppLogs, "app-logs", "l", false, "show logs from cosmos app")
@@ -44,9 +50,6 @@ func init() {
 	serveBlocksCmd.Flags().StringVarP(&flags.Source, "source", "s", "", "")
 	_ = serveBlocksCmd.Flags().MarkDeprecated("source", "source is detected automatically")
 
-	serveBlocksCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "")
-	_ = serveBlocksCmd.Flags().MarkDeprecated("registry-url", "registry url is fixed")
-
 	RootCmd.AddCommand(serveBlocksCmd)
 }
 
@@ -16,15 +16,22 @@ func init() {
 
 	servesnapshotsCmd.Flags().StringVarP(&flags.HomePath, "home", "h", "", "home directory")
 
-	servesnapshotsCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, fmt.Sprintf("KYVE chain id [\"%s\",\"%s\",\"%s\"]", utils.ChainIdMainnet, utils.ChainIdKaon, utils.ChainIdKorellia))
+	servesnapshotsCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, utils.ChainIdUsage())
 
 	servesnapshotsCmd.Flags().StringVar(&flags.ChainRest, "chain-rest", "", "rest endpoint for KYVE chain")
+	servesnapshotsCmd.Flags().StringVar(&flags.ChainGrpc, "chain-grpc", "", "gRPC endpoint for KYVE chain, queries fall back to the rest endpoint if they fail. Use \"https://\" for TLS")
+	servesnapshotsCmd.Flags().StringVar(&flags.ChainRpc, "chain-rpc", "", "rpc endpoint for KYVE chain, used to verify bundle finality")
+	servesnapshotsCmd.Flags().StringVar(&flags.ChainRpcWitnesses, "chain-rpc-witnesses", "", "comma separated rpc endpoints for KYVE chain which cross-check the headers of --chain-rpc")
+	servesnapshotsCmd.Flags().BoolVar(&flags.VerifyFinality, "verify-finality", false, "verify bundles and pools with state proofs against light client verified KYVE headers instead of trusting the rest endpoint")
+	servesnapshotsCmd.Flags().Int64Var(&flags.TrustedHeight, "trusted-height", 0, "height of a trusted KYVE block for the light client, required for --verify-finality")
+	servesnapshotsCmd.Flags().StringVar(&flags.TrustedHash, "trusted-hash", "", "hex encoded hash of the trusted KYVE block, required for --verify-finality")
 	servesnapshotsCmd.Flags().StringVar(&flags.StorageRest, "storage-rest", "", "storage endpoint for requesting bundle data")
 
 	servesnapshotsCmd.Flags().StringVar(&flags.SnapshotPoolId, "snapshot-pool-id", "", "pool-id of the state-sync pool")
 	servesnapshotsCmd.Flags().StringVar(&flags.BlockPoolId, "block-pool-id", "", "pool-id of the block-sync pool")
 
 	servesnapshotsCmd.Flags().Int64Var(&flags.SnapshotPort, "snapshot-port", utils.DefaultSnapshotServerPort, "port for snapshot server")
+	servesnapshotsCmd.Flags().BoolVar(&flags.SnapshotP2P, "snapshot-p2p", false, "serve snapshots over the P2P network to nodes using the built-in state-sync on the configured P2P listen address")
 
 	servesnapshotsCmd.Flags().BoolVar(&flags.RpcServer, "rpc-server", false, "rpc server serving /status, /block and /block_results")
 	servesnapshotsCmd.Flags().Int64Var(&flags.RpcServerPort, "rpc-server-port", utils.DefaultRpcServerPort, "port for rpc server")
@@ -40,6 +47,9 @@ func init() {
 
 	servesnapshotsCmd.Flags().BoolVarP(&flags.AutoSelectBinaryVersion, "auto-select-binary-version", "a", false, "if provided binary is cosmovisor KSYNC will automatically change the \"current\" symlink to the correct upgrade version")
 	servesnapshotsCmd.Flags().BoolVarP(&flags.Reset, "reset-all", "r", false, "reset this node's validator to genesis state")
+	servesnapshotsCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "url or local path of the source registry (default is the official KYVE source registry)")
+	servesnapshotsCmd.Flags().StringVar(&flags.RegistryRef, "registry-ref", "", "branch, tag or commit of the official source registry to use (default \"main\")")
+
 	servesnapshotsCmd.Flags().BoolVar(&flags.OptOut, "opt-out", false, "disable the collection of anonymous usage data")
 	servesnapshotsCmd.Flags().BoolVarP(&flags.Debug, "debug", "d", false, "run KSYNC in debug mode")
 	servesnapshotsCmd.Flags().BoolVarP(&flags.AppLogs, "app-logs", "l", false, "show logs from cosmos app")
@@ -51,9 +61,6 @@ func init() {
 	servesnapshotsCmd.Flags().StringVarP(&flags.Source, "source", "s", "", "")
 	_ = servesnapshotsCmd.Flags().MarkDeprecated("source", "source is detected automatically")
 
-	servesnapshotsCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "")
-	_ = servesnapshotsCmd.Flags().MarkDeprecated("registry-url", "registry url is fixed")
-
 	RootCmd.AddCommand(servesnapshotsCmd)
 }
 
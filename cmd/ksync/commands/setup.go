@@ -1,27 +1,52 @@
 package commands
 
 import (
-	"fmt"
 	"github.com/KYVENetwork/ksync/flags"
 	"github.com/KYVENetwork/ksync/setup"
 	"github.com/KYVENetwork/ksync/utils"
 	"github.com/spf13/cobra"
+	"strings"
 )
 
 var chainId string
 
 func init() {
+	setupCmd.Flags().StringVar(&flags.SetupConfig, "config", "", "yaml file with the answers for the setup, flags take precedence over the values in the file")
+
 	setupCmd.Flags().StringVarP(&flags.Source, "source", "b", "", "source is the name chain in the cosmos registry")
 
-	setupCmd.Flags().StringVarP(&chainId, "chain-id", "c", utils.ChainIdKaon, fmt.Sprintf("KYVE chain id [\"%s\",\"%s\",\"%s\"]", utils.ChainIdMainnet, utils.ChainIdKaon, utils.ChainIdKorellia))
+	setupCmd.Flags().StringVarP(&chainId, "chain-id", "c", utils.ChainIdKaon, utils.ChainIdUsage())
 
 	setupCmd.Flags().StringVar(&flags.ChainRest, "chain-rest", "", "rest endpoint for KYVE chain")
+	setupCmd.Flags().StringVar(&flags.ChainGrpc, "chain-grpc", "", "gRPC endpoint for KYVE chain, queries fall back to the rest endpoint if they fail. Use \"https://\" for TLS")
 	setupCmd.Flags().StringVar(&flags.StorageRest, "storage-rest", "", "storage endpoint for requesting bundle data")
 
+	setupCmd.Flags().StringVar(&flags.SetupMode, "mode", "", "setup mode [\"install\",\"state-sync\",\"block-sync\"], if not specified it will be asked interactively")
+	setupCmd.Flags().Int64VarP(&flags.TargetHeight, "target-height", "t", 0, "target height for state-sync and block-sync, if not specified it will use the latest available snapshot height")
+
+	setupCmd.Flags().StringVar(&flags.PeerStrategy, "peer-strategy", "", "peer selection strategy [\"all\",\"none\",\"best\",\"random\",\"provider\"], if not specified it will be asked interactively")
+	setupCmd.Flags().Int64Var(&flags.PeerCount, "peer-count", 0, "number of peers which are selected with the peer strategy \"best\" or \"random\"")
+	setupCmd.Flags().BoolVar(&flags.SkipPeerProbe, "skip-peer-probe", false, "skip probing the peers and consider all of them as reachable")
+	setupCmd.Flags().StringVar(&flags.PeerProviders, "peer-providers", "", "comma separated list of providers whose peers are selected with the peer strategy \"provider\"")
+
 	setupCmd.Flags().StringVarP(&flags.Moniker, "moniker", "m", "", "moniker name for initializing the chain")
 
 	setupCmd.Flags().StringVarP(&flags.AppFlags, "app-flags", "f", "", "custom flags which are applied to the app binary start command. Example: --app-flags=\"--x-crisis-skip-assert-invariants,--iavl-disable-fastnode\"")
 
+	setupCmd.Flags().BoolVar(&flags.NonInteractive, "non-interactive", false, "run the setup without any user interaction and fail if answers are missing")
+	setupCmd.Flags().StringVar(&flags.GenerateServices, "generate", "", "comma separated service files which are generated for running the node [\"systemd\",\"compose\",\"k8s\"]")
+	setupCmd.Flags().StringVar(&flags.ServicesOutputDir, "output-dir", "", "directory for the generated service files [default = current directory]")
+	setupCmd.Flags().BoolVar(&flags.Handoff, "handoff", false, "start the node in normal consensus mode after the sync reached the target height")
+	setupCmd.Flags().StringVar(&flags.AppPruning, "app-pruning", "", "pruning strategy which is set in the app.toml [\"default\",\"nothing\",\"everything\",\"custom\"]")
+	setupCmd.Flags().Int64Var(&flags.AppSnapshotInterval, "app-snapshot-interval", 0, "state-sync snapshot interval which is set in the app.toml")
+	setupCmd.Flags().BoolVar(&flags.EnableApi, "enable-api", false, "enable the REST API in the app.toml")
+	setupCmd.Flags().StringVar(&flags.GenesisFile, "genesis-file", "", "path to a local genesis file, can be gzip compressed or a tar archive")
+	setupCmd.Flags().StringVar(&flags.GenesisUrl, "genesis-url", "", "url of a genesis mirror which is tried before the chain registry")
+	setupCmd.Flags().StringVar(&flags.GenesisChecksum, "genesis-checksum", "", "expected checksum of the genesis file of --genesis-url or --genesis-file. Example: --genesis-checksum=\"sha256:<hex>\"")
+	setupCmd.Flags().StringVar(&flags.BuildRecipes, "build-recipes", "", "path to a yaml file which overrides the build recipes of chains [default = $HOME/.ksync/recipes.yml]")
+	setupCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "url or local path of the source registry (default is the official KYVE source registry)")
+	setupCmd.Flags().StringVar(&flags.RegistryRef, "registry-ref", "", "branch, tag or commit of the official source registry to use (default \"main\")")
+
 	setupCmd.Flags().BoolVar(&flags.OptOut, "opt-out", false, "disable the collection of anonymous usage data")
 	setupCmd.Flags().BoolVarP(&flags.Debug, "debug", "d", false, "run KSYNC in debug mode")
 	setupCmd.Flags().BoolVarP(&flags.AppLogs, "app-logs", "l", false, "show logs from cosmos app")
@@ -32,8 +57,81 @@ func init() {
 var setupCmd = &cobra.Command{
 	Use:   "setup",
 	Short: "Setup and auto-install the required binaries for syncing",
-	RunE: func(_ *cobra.Command, _ []string) error {
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if flags.SetupConfig != "" {
+			if err := applySetupConfig(cmd); err != nil {
+				return err
+			}
+		}
+
 		flags.ChainId = chainId
 		return setup.Start()
 	},
 }
+
+// applySetupConfig sets all values from the setup config file which
+// were not explicitly provided with flags
+func applySetupConfig(cmd *cobra.Command) error {
+	config, err := setup.LoadConfig(flags.SetupConfig)
+	if err != nil {
+		return err
+	}
+
+	isUnset := func(name string) bool {
+		return !cmd.Flags().Changed(name)
+	}
+
+	if config.Source != "" && isUnset("source") {
+		flags.Source = config.Source
+	}
+	if config.ChainId != "" && isUnset("chain-id") {
+		chainId = config.ChainId
+	}
+	if config.Mode != "" && isUnset("mode") {
+		flags.SetupMode = config.Mode
+	}
+	if config.TargetHeight > 0 && isUnset("target-height") {
+		flags.TargetHeight = config.TargetHeight
+	}
+	if config.PeerStrategy != "" && isUnset("peer-strategy") {
+		flags.PeerStrategy = config.PeerStrategy
+	}
+	if config.PeerCount > 0 && isUnset("peer-count") {
+		flags.PeerCount = config.PeerCount
+	}
+	if len(config.PeerProviders) > 0 && isUnset("peer-providers") {
+		flags.PeerProviders = strings.Join(config.PeerProviders, ",")
+	}
+	if config.Moniker != "" && isUnset("moniker") {
+		flags.Moniker = config.Moniker
+	}
+	if config.NonInteractive && isUnset("non-interactive") {
+		flags.NonInteractive = true
+	}
+	if len(config.Services.Generate) > 0 && isUnset("generate") {
+		flags.GenerateServices = strings.Join(config.Services.Generate, ",")
+	}
+	if config.Services.OutputDir != "" && isUnset("output-dir") {
+		flags.ServicesOutputDir = config.Services.OutputDir
+	}
+	if config.App.Pruning != "" && isUnset("app-pruning") {
+		flags.AppPruning = config.App.Pruning
+	}
+	if config.App.SnapshotInterval > 0 && isUnset("app-snapshot-interval") {
+		flags.AppSnapshotInterval = config.App.SnapshotInterval
+	}
+	if config.App.EnableApi && isUnset("enable-api") {
+		flags.EnableApi = true
+	}
+	if config.Genesis.File != "" && isUnset("genesis-file") {
+		flags.GenesisFile = config.Genesis.File
+	}
+	if config.Genesis.Url != "" && isUnset("genesis-url") {
+		flags.GenesisUrl = config.Genesis.Url
+	}
+	if config.Genesis.Checksum != "" && isUnset("genesis-checksum") {
+		flags.GenesisChecksum = config.Genesis.Checksum
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/spf13/cobra"
+)
+
+func TestApplySetupConfig(t *testing.T) {
+	setupConfig, source, peerStrategy, peerCount, peerProviders, moniker, pruning, savedChainId := flags.SetupConfig, flags.Source, flags.PeerStrategy, flags.PeerCount, flags.PeerProviders, flags.Moniker, flags.AppPruning, chainId
+	t.Cleanup(func() {
+		flags.SetupConfig, flags.Source, flags.PeerStrategy, flags.PeerCount, flags.PeerProviders, flags.Moniker, flags.AppPruning, chainId = setupConfig, source, peerStrategy, peerCount, peerProviders, moniker, pruning, savedChainId
+	})
+
+	flags.SetupConfig = filepath.Join(t.TempDir(), "setup.yml")
+	config := "source: osmosis\n" +
+		"chain_id: kyve-1\n" +
+		"peer_strategy: best\n" +
+		"peer_count: 5\n" +
+		"peer_providers: [Polkachu, AutoStake]\n" +
+		"moniker: file-moniker\n" +
+		"app:\n  pruning: custom\n"
+	if err := os.WriteFile(flags.SetupConfig, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a command with the flags of the setup which can be marked as changed
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&flags.Source, "source", "", "")
+	cmd.Flags().StringVar(&chainId, "chain-id", "kaon-1", "")
+	cmd.Flags().StringVar(&flags.PeerStrategy, "peer-strategy", "", "")
+	cmd.Flags().Int64Var(&flags.PeerCount, "peer-count", 0, "")
+	cmd.Flags().StringVar(&flags.PeerProviders, "peer-providers", "", "")
+	cmd.Flags().StringVar(&flags.Moniker, "moniker", "", "")
+	cmd.Flags().StringVar(&flags.AppPruning, "app-pruning", "", "")
+
+	for name, value := range map[string]string{"peer-strategy": "random", "moniker": "flag-moniker"} {
+		if err := cmd.Flags().Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := applySetupConfig(cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		value    any
+		expected any
+	}{
+		{name: "source from file", value: flags.Source, expected: "osmosis"},
+		{name: "chain id from file over default", value: chainId, expected: "kyve-1"},
+		{name: "peer strategy from flag", value: flags.PeerStrategy, expected: "random"},
+		{name: "peer count from file", value: flags.PeerCount, expected: int64(5)},
+		{name: "peer providers from file", value: flags.PeerProviders, expected: "Polkachu,AutoStake"},
+		{name: "moniker from flag", value: flags.Moniker, expected: "flag-moniker"},
+		{name: "app pruning from file", value: flags.AppPruning, expected: "custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.value != tt.expected {
+				t.Fatalf("expected %v, found %v", tt.expected, tt.value)
+			}
+		})
+	}
+}
+
+func TestApplySetupConfigRejectsUnknownKeys(t *testing.T) {
+	setupConfig := flags.SetupConfig
+	t.Cleanup(func() {
+		flags.SetupConfig = setupConfig
+	})
+
+	flags.SetupConfig = filepath.Join(t.TempDir(), "setup.yml")
+	if err := os.WriteFile(flags.SetupConfig, []byte("peer_strategy: best\npeers: 5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applySetupConfig(&cobra.Command{}); err == nil {
+		t.Fatal("expected unknown key in setup config to fail")
+	}
+}
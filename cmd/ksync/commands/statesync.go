@@ -16,19 +16,39 @@ func init() {
 
 	stateSyncCmd.Flags().StringVarP(&flags.HomePath, "home", "h", "", "home directory")
 
-	stateSyncCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, fmt.Sprintf("KYVE chain id [\"%s\",\"%s\",\"%s\"]", utils.ChainIdMainnet, utils.ChainIdKaon, utils.ChainIdKorellia))
+	stateSyncCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, utils.ChainIdUsage())
 
 	stateSyncCmd.Flags().StringVar(&flags.ChainRest, "chain-rest", "", "rest endpoint for KYVE chain")
+	stateSyncCmd.Flags().StringVar(&flags.ChainGrpc, "chain-grpc", "", "gRPC endpoint for KYVE chain, queries fall back to the rest endpoint if they fail. Use \"https://\" for TLS")
+	stateSyncCmd.Flags().StringVar(&flags.ChainRpc, "chain-rpc", "", "rpc endpoint for KYVE chain, used to verify bundle finality")
+	stateSyncCmd.Flags().StringVar(&flags.ChainRpcWitnesses, "chain-rpc-witnesses", "", "comma separated rpc endpoints for KYVE chain which cross-check the headers of --chain-rpc")
+	stateSyncCmd.Flags().BoolVar(&flags.VerifyFinality, "verify-finality", false, "verify bundles and pools with state proofs against light client verified KYVE headers instead of trusting the rest endpoint")
+	stateSyncCmd.Flags().Int64Var(&flags.TrustedHeight, "trusted-height", 0, "height of a trusted KYVE block for the light client, required for --verify-finality")
+	stateSyncCmd.Flags().StringVar(&flags.TrustedHash, "trusted-hash", "", "hex encoded hash of the trusted KYVE block, required for --verify-finality")
 	stateSyncCmd.Flags().StringVar(&flags.StorageRest, "storage-rest", "", "storage endpoint for requesting bundle data")
 
 	stateSyncCmd.Flags().StringVar(&flags.SnapshotPoolId, "snapshot-pool-id", "", "pool-id of the state-sync pool")
+	stateSyncCmd.Flags().StringVar(&flags.SnapshotDir, "snapshot-dir", "", "local directory with exported snapshots to state-sync from instead of a KYVE snapshot pool")
+	stateSyncCmd.Flags().StringVar(&flags.BlockPoolId, "block-pool-id", "", "pool-id of the block-sync pool, only used to resolve --target-time")
+	stateSyncCmd.Flags().StringVar(&flags.BlockRpc, "block-rpc", "", "rpc endpoint of the source chain to request blocks from instead of KYVE, only used to resolve --target-time")
+	stateSyncCmd.Flags().Int64Var(&flags.BlockRpcReqTimeout, "block-rpc-req-timeout", utils.RequestBlocksTimeoutMS, "timeout in milliseconds between block requests to the rpc endpoint")
 
 	stateSyncCmd.Flags().StringVarP(&flags.AppFlags, "app-flags", "f", "", "custom flags which are applied to the app binary start command. Example: --app-flags=\"--x-crisis-skip-assert-invariants,--iavl-disable-fastnode\"")
 
 	stateSyncCmd.Flags().Int64VarP(&flags.TargetHeight, "target-height", "t", 0, "snapshot height, if not specified it will use the latest available snapshot height")
+	stateSyncCmd.Flags().StringVar(&flags.TargetUpgrade, "target-upgrade", "", "sync to the last height before this upgrade, resolved from the source registry")
+	stateSyncCmd.Flags().StringVar(&flags.TargetTime, "target-time", "", "sync to the last block created at or before this time in RFC3339 format. Example: --target-time=\"2024-06-01T00:00:00Z\"")
+
+	stateSyncCmd.Flags().BoolVar(&flags.Handoff, "handoff", false, "start the node in normal consensus mode after the target height was reached")
+	stateSyncCmd.Flags().BoolVar(&flags.HandoffRestart, "handoff-restart", false, "restart the node after the handoff if it crashes")
+	stateSyncCmd.Flags().StringVar(&flags.HandoffLogFile, "handoff-log-file", "", "additionally write the logs of the node after the handoff to this file")
+	stateSyncCmd.Flags().StringVar(&flags.HandoffSystemdUnit, "handoff-systemd-unit", "", "hand off to a systemd unit written to this path instead of running the node in KSYNC. Example: --handoff-systemd-unit=/etc/systemd/system/osmosisd.service")
 
 	stateSyncCmd.Flags().BoolVarP(&flags.AutoSelectBinaryVersion, "auto-select-binary-version", "a", false, "if provided binary is cosmovisor KSYNC will automatically change the \"current\" symlink to the correct upgrade version")
 	stateSyncCmd.Flags().BoolVarP(&flags.Reset, "reset-all", "r", false, "reset this node's validator to genesis state")
+	stateSyncCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "url or local path of the source registry (default is the official KYVE source registry)")
+	stateSyncCmd.Flags().StringVar(&flags.RegistryRef, "registry-ref", "", "branch, tag or commit of the official source registry to use (default \"main\")")
+
 	stateSyncCmd.Flags().BoolVar(&flags.OptOut, "opt-out", false, "disable the collection of anonymous usage data")
 	stateSyncCmd.Flags().BoolVarP(&flags.Debug, "debug", "d", false, "run KSYNC in debug mode")
 	stateSyncCmd.Flags().BoolVarP(&flags.AppLogs, "app-logs", "l", false, "show logs from cosmos app")
@@ -41,9 +61,6 @@ func init() {
 	stateSyncCmd.Flags().StringVarP(&flags.Source, "source", "s", "", "")
 	_ = stateSyncCmd.Flags().MarkDeprecated("source", "source is detected automatically")
 
-	stateSyncCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "")
-	_ = stateSyncCmd.Flags().MarkDeprecated("registry-url", "registry url is fixed")
-
 	RootCmd.AddCommand(stateSyncCmd)
 }
 
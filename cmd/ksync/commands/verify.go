@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/sync/verify"
+	"github.com/KYVENetwork/ksync/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	verifyCmd.Flags().StringVarP(&flags.BinaryPath, "binary", "b", "", "binary path to the cosmos app, only used to detect the consensus engine")
+	if err := verifyCmd.MarkFlagRequired("binary"); err != nil {
+		panic(fmt.Errorf("flag 'binary' should be required: %w", err))
+	}
+
+	verifyCmd.Flags().StringVarP(&flags.HomePath, "home", "h", "", "home directory")
+
+	verifyCmd.Flags().StringVarP(&flags.ChainId, "chain-id", "c", utils.DefaultChainId, utils.ChainIdUsage())
+
+	verifyCmd.Flags().StringVar(&flags.ChainRest, "chain-rest", "", "rest endpoint for KYVE chain")
+	verifyCmd.Flags().StringVar(&flags.ChainGrpc, "chain-grpc", "", "gRPC endpoint for KYVE chain, queries fall back to the rest endpoint if they fail. Use \"https://\" for TLS")
+	verifyCmd.Flags().StringVar(&flags.ChainRpc, "chain-rpc", "", "rpc endpoint for KYVE chain, used to verify bundle finality")
+	verifyCmd.Flags().StringVar(&flags.ChainRpcWitnesses, "chain-rpc-witnesses", "", "comma separated rpc endpoints for KYVE chain which cross-check the headers of --chain-rpc")
+	verifyCmd.Flags().BoolVar(&flags.VerifyFinality, "verify-finality", false, "verify bundles and pools with state proofs against light client verified KYVE headers instead of trusting the rest endpoint")
+	verifyCmd.Flags().Int64Var(&flags.TrustedHeight, "trusted-height", 0, "height of a trusted KYVE block for the light client, required for --verify-finality")
+	verifyCmd.Flags().StringVar(&flags.TrustedHash, "trusted-hash", "", "hex encoded hash of the trusted KYVE block, required for --verify-finality")
+	verifyCmd.Flags().StringVar(&flags.StorageRest, "storage-rest", "", "storage endpoint for requesting bundle data")
+
+	verifyCmd.Flags().StringVar(&flags.BlockPoolId, "block-pool-id", "", "pool-id of the block-sync pool")
+	verifyCmd.Flags().StringVar(&flags.BlockRpc, "block-rpc", "", "rpc endpoint of the source node to verify blocks from instead of a block pool")
+	verifyCmd.Flags().StringVar(&flags.ValidatorRpc, "validator-rpc", "", "rpc endpoint of the source chain for resolving validator set changes, defaults to --block-rpc")
+
+	verifyCmd.Flags().Int64Var(&flags.StartHeight, "start-height", 0, "first height to verify, if not specified it will start at the initial height of the genesis file")
+	verifyCmd.Flags().Int64VarP(&flags.TargetHeight, "target-height", "t", 0, "last height to verify (including), if not specified it will verify until the latest available block")
+
+	verifyCmd.Flags().StringVar(&flags.AttestationPath, "attestation", "", "file path for the signed attestation, if not specified it is printed to stdout")
+
+	verifyCmd.Flags().StringVar(&flags.RegistryUrl, "registry-url", "", "url or local path of the source registry (default is the official KYVE source registry)")
+	verifyCmd.Flags().StringVar(&flags.RegistryRef, "registry-ref", "", "branch, tag or commit of the official source registry to use (default \"main\")")
+
+	verifyCmd.Flags().BoolVar(&flags.OptOut, "opt-out", false, "disable the collection of anonymous usage data")
+	verifyCmd.Flags().BoolVarP(&flags.Debug, "debug", "d", false, "run KSYNC in debug mode")
+
+	RootCmd.AddCommand(verifyCmd)
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Verify headers and commits of a block range without executing them",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return verify.Start()
+	},
+}
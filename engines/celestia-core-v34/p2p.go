@@ -3,11 +3,11 @@ package celestia_core_v34
 import (
 	"fmt"
 	bc "github.com/KYVENetwork/celestia-core/blockchain"
-	bcv0 "github.com/KYVENetwork/celestia-core/blockchain/v0"
 	tmLog "github.com/KYVENetwork/celestia-core/libs/log"
 	"github.com/KYVENetwork/celestia-core/p2p"
 	bcproto "github.com/KYVENetwork/celestia-core/proto/celestiacore/blockchain"
 	"github.com/KYVENetwork/celestia-core/version"
+	"github.com/KYVENetwork/ksync/types"
 	"reflect"
 )
 
@@ -121,16 +121,23 @@ func (bcR *BlockchainReactor) Receive(chID byte, src p2p.Peer, msgBytes []byte)
 	}
 }
 
+// GetProtocolVersion returns the protocol versions the engine announces in the
+// node info during the p2p handshake
+func GetProtocolVersion() types.ProtocolVersion {
+	return types.ProtocolVersion{P2P: version.P2PProtocol, Block: version.BlockProtocol}
+}
+
 func MakeNodeInfo(
 	config *Config,
 	nodeKey *p2p.NodeKey,
 	genDoc *GenesisDoc,
+	channels []byte,
 ) (p2p.NodeInfo, error) {
 	nodeInfo := p2p.DefaultNodeInfo{
 		DefaultNodeID: nodeKey.ID(),
 		Network:       genDoc.ChainID,
 		Version:       version.TMCoreSemVer,
-		Channels:      []byte{bcv0.BlockchainChannel},
+		Channels:      channels,
 		Moniker:       config.Moniker,
 		Other: p2p.DefaultNodeInfoOther{
 			TxIndex:    "off",
@@ -152,7 +159,8 @@ func MakeNodeInfo(
 
 func CreateSwitch(config *Config,
 	transport p2p.Transport,
-	bcReactor p2p.Reactor,
+	reactorName string,
+	reactor p2p.Reactor,
 	nodeInfo p2p.NodeInfo,
 	nodeKey *p2p.NodeKey,
 	logger tmLog.Logger) *p2p.Switch {
@@ -162,8 +170,8 @@ func CreateSwitch(config *Config,
 		transport,
 	)
 	sw.SetLogger(logger)
-	bcReactor.SetLogger(logger)
-	sw.AddReactor("BLOCKCHAIN", bcReactor)
+	reactor.SetLogger(logger)
+	sw.AddReactor(reactorName, reactor)
 
 	sw.SetNodeInfo(nodeInfo)
 	sw.SetNodeKey(nodeKey)
@@ -1,6 +1,7 @@
 package cometbft_v37
 
 import (
+	"bytes"
 	"fmt"
 	abciTypes "github.com/KYVENetwork/cometbft/v37/abci/types"
 	cfg "github.com/KYVENetwork/cometbft/v37/config"
@@ -18,6 +19,7 @@ import (
 	rpccore "github.com/KYVENetwork/cometbft/v37/rpc/core"
 	rpcserver "github.com/KYVENetwork/cometbft/v37/rpc/jsonrpc/server"
 	tmState "github.com/KYVENetwork/cometbft/v37/state"
+	ss "github.com/KYVENetwork/cometbft/v37/statesync"
 	tmStore "github.com/KYVENetwork/cometbft/v37/store"
 	cometTypes "github.com/KYVENetwork/cometbft/v37/types"
 	tmTypes "github.com/KYVENetwork/cometbft/v37/types"
@@ -46,11 +48,17 @@ type Engine struct {
 	genDoc  *GenesisDoc
 	nodeKey *cometP2P.NodeKey
 
+	snapshotSwitch    *cometP2P.Switch
+	snapshotTransport *cometP2P.MultiplexTransport
+
 	state         tmState.State
 	proxyApp      proxy.AppConns
 	mempool       *mempool.Mempool
 	evidencePool  *evidence.Pool
 	blockExecutor *tmState.BlockExecutor
+
+	verifyValidators  *tmTypes.ValidatorSet
+	verifyLastBlockID *tmTypes.BlockID
 }
 
 func NewEngine(homePath string) (*Engine, error) {
@@ -181,6 +189,14 @@ func (engine *Engine) GetProxyAppAddress() string {
 	return engine.config.ProxyApp
 }
 
+func (engine *Engine) GetNodeKey() (string, []byte) {
+	return engine.nodeKey.PubKey().Address().String(), engine.nodeKey.PubKey().Bytes()
+}
+
+func (engine *Engine) SignWithNodeKey(payload []byte) ([]byte, error) {
+	return engine.nodeKey.PrivKey.Sign(payload)
+}
+
 func (engine *Engine) StartProxyApp() error {
 	if engine.proxyApp != nil {
 		return fmt.Errorf("proxy app already started")
@@ -261,11 +277,11 @@ func (engine *Engine) ApplyBlock(rawBlock, nextRawBlock []byte) error {
 	var block, nextBlock *Block
 
 	if err := json.Unmarshal(rawBlock, &block); err != nil {
-		return fmt.Errorf("failed to unmarshal block: %w", err)
+		return utils.NewClassErrorf(utils.ErrDecode, "failed to unmarshal block: %w", err)
 	}
 
 	if err := json.Unmarshal(nextRawBlock, &nextBlock); err != nil {
-		return fmt.Errorf("failed to unmarshal next block: %w", err)
+		return utils.NewClassErrorf(utils.ErrDecode, "failed to unmarshal next block: %w", err)
 	}
 
 	// get block data
@@ -278,12 +294,12 @@ func (engine *Engine) ApplyBlock(rawBlock, nextRawBlock []byte) error {
 
 	// verify block
 	if err := engine.blockExecutor.ValidateBlock(engine.state, block); err != nil {
-		return fmt.Errorf("block validation failed at height %d: %w", block.Height, err)
+		return utils.NewClassErrorf(utils.ErrBlockValidation, "block validation failed at height %d: %w", block.Height, err)
 	}
 
 	// verify commits
 	if err := engine.state.Validators.VerifyCommitLight(engine.state.ChainID, blockId, block.Height, nextBlock.LastCommit); err != nil {
-		return fmt.Errorf("light commit verification failed at height %d: %w", block.Height, err)
+		return utils.NewClassErrorf(utils.ErrBlockValidation, "light commit verification failed at height %d: %w", block.Height, err)
 	}
 
 	// store block
@@ -292,7 +308,7 @@ func (engine *Engine) ApplyBlock(rawBlock, nextRawBlock []byte) error {
 	// execute block against app
 	state, _, err := engine.blockExecutor.ApplyBlock(engine.state, blockId, block)
 	if err != nil {
-		return fmt.Errorf("failed to apply block at height %d: %w", block.Height, err)
+		return utils.NewClassErrorf(utils.ErrAppExecution, "failed to apply block at height %d: %w", block.Height, err)
 	}
 
 	// update state for next round
@@ -304,11 +320,11 @@ func (engine *Engine) ApplyFirstBlockOverP2P(rawBlock, nextRawBlock []byte) erro
 	var block, nextBlock *Block
 
 	if err := json.Unmarshal(rawBlock, &block); err != nil {
-		return fmt.Errorf("failed to unmarshal block: %w", err)
+		return utils.NewClassErrorf(utils.ErrDecode, "failed to unmarshal block: %w", err)
 	}
 
 	if err := json.Unmarshal(nextRawBlock, &nextBlock); err != nil {
-		return fmt.Errorf("failed to unmarshal next block: %w", err)
+		return utils.NewClassErrorf(utils.ErrDecode, "failed to unmarshal next block: %w", err)
 	}
 
 	peerAddress := engine.config.P2P.ListenAddress
@@ -335,10 +351,10 @@ func (engine *Engine) ApplyFirstBlockOverP2P(rawBlock, nextRawBlock []byte) erro
 		PrivKey: ed25519.GenPrivKey(),
 	}
 
-	nodeInfo, err := MakeNodeInfo(engine.config, ksyncNodeKey, engine.genDoc)
+	nodeInfo, err := MakeNodeInfo(engine.config, ksyncNodeKey, engine.genDoc, []byte{BlocksyncChannel})
 	transport := cometP2P.NewMultiplexTransport(nodeInfo, *ksyncNodeKey, cometP2P.MConnConfig(engine.config.P2P))
 	bcR := NewBlockchainReactor(block, nextBlock)
-	sw := CreateSwitch(engine.config, transport, bcR, nodeInfo, ksyncNodeKey, engineLogger)
+	sw := CreateSwitch(engine.config, transport, "BLOCKCHAIN", bcR, nodeInfo, ksyncNodeKey, engineLogger)
 
 	// start the transport
 	addr, err := cometP2P.NewNetAddressString(cometP2P.IDAddressString(ksyncNodeKey.ID(), engine.config.P2P.ListenAddress))
@@ -375,6 +391,108 @@ func (engine *Engine) ApplyFirstBlockOverP2P(rawBlock, nextRawBlock []byte) erro
 	return nil
 }
 
+func (engine *Engine) VerifyBlock(rawBlock, nextRawBlock []byte, getValidators func(height int64) ([]byte, error)) error {
+	var block, nextBlock *Block
+
+	if err := json.Unmarshal(rawBlock, &block); err != nil {
+		return utils.NewClassErrorf(utils.ErrDecode, "failed to unmarshal block: %w", err)
+	}
+
+	if err := json.Unmarshal(nextRawBlock, &nextBlock); err != nil {
+		return utils.NewClassErrorf(utils.ErrDecode, "failed to unmarshal next block: %w", err)
+	}
+
+	// the first verified block is our trust root, so we load the validator
+	// set of this height from the genesis file or the validator source
+	if engine.verifyValidators == nil {
+		validators, err := engine.loadValidatorSet(block.Height, getValidators)
+		if err != nil {
+			return fmt.Errorf("failed to load validator set at height %d: %w", block.Height, err)
+		}
+
+		engine.verifyValidators = validators
+	}
+
+	if err := block.ValidateBasic(); err != nil {
+		return fmt.Errorf("invalid block at height %d: %w", block.Height, err)
+	}
+
+	if block.ChainID != engine.genDoc.ChainID {
+		return fmt.Errorf("wrong chain id at height %d: expected = %s found = %s", block.Height, engine.genDoc.ChainID, block.ChainID)
+	}
+
+	if engine.verifyLastBlockID != nil && !block.LastBlockID.Equals(*engine.verifyLastBlockID) {
+		return fmt.Errorf("wrong last block id at height %d: expected = %v found = %v", block.Height, engine.verifyLastBlockID, block.LastBlockID)
+	}
+
+	if !bytes.Equal(block.ValidatorsHash, engine.verifyValidators.Hash()) {
+		return fmt.Errorf("wrong validators hash at height %d: expected = %X found = %X", block.Height, engine.verifyValidators.Hash(), block.ValidatorsHash)
+	}
+
+	if !engine.verifyValidators.HasAddress(block.ProposerAddress) {
+		return fmt.Errorf("proposer %X at height %d is not a validator", block.ProposerAddress, block.Height)
+	}
+
+	blockParts, err := block.MakePartSet(tmTypes.BlockPartSizeBytes)
+	if err != nil {
+		return fmt.Errorf("failed make part set of block: %w", err)
+	}
+
+	blockId := tmTypes.BlockID{Hash: block.Hash(), PartSetHeader: blockParts.Header()}
+
+	if err := engine.verifyValidators.VerifyCommitLight(engine.genDoc.ChainID, blockId, block.Height, nextBlock.LastCommit); err != nil {
+		return utils.NewClassErrorf(utils.ErrBlockValidation, "light commit verification failed at height %d: %w", block.Height, err)
+	}
+
+	// if the validator set changes with the next height we need to load it
+	// and check it against the hash which was committed in this block
+	if !bytes.Equal(block.NextValidatorsHash, engine.verifyValidators.Hash()) {
+		nextValidators, err := engine.loadValidatorSet(block.Height+1, getValidators)
+		if err != nil {
+			return fmt.Errorf("failed to load validator set at height %d: %w", block.Height+1, err)
+		}
+
+		if !bytes.Equal(block.NextValidatorsHash, nextValidators.Hash()) {
+			return fmt.Errorf("wrong next validators hash at height %d: expected = %X found = %X", block.Height, nextValidators.Hash(), block.NextValidatorsHash)
+		}
+
+		engine.verifyValidators = nextValidators
+	}
+
+	engine.verifyLastBlockID = &blockId
+	return nil
+}
+
+func (engine *Engine) loadValidatorSet(height int64, getValidators func(height int64) ([]byte, error)) (*tmTypes.ValidatorSet, error) {
+	validators := make([]*tmTypes.Validator, 0)
+
+	if height == engine.genDoc.InitialHeight && len(engine.genDoc.Validators) > 0 {
+		for _, validator := range engine.genDoc.Validators {
+			validators = append(validators, tmTypes.NewValidator(validator.PubKey, validator.Power))
+		}
+	} else {
+		if getValidators == nil {
+			return nil, fmt.Errorf("validator set at height %d is not in the genesis file and no validator source was provided", height)
+		}
+
+		rawValidators, err := getValidators(height)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(rawValidators, &validators); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal validators: %w", err)
+		}
+	}
+
+	validatorSet := tmTypes.NewValidatorSet(validators)
+	if err := validatorSet.ValidateBasic(); err != nil {
+		return nil, fmt.Errorf("invalid validator set: %w", err)
+	}
+
+	return validatorSet, nil
+}
+
 func (engine *Engine) GetHeight() int64 {
 	return engine.blockStore.Height()
 }
@@ -392,6 +510,10 @@ func (engine *Engine) GetAppHeight() (int64, error) {
 	return info.LastBlockHeight, nil
 }
 
+func (engine *Engine) GetStateHashes() ([]byte, []byte) {
+	return engine.state.AppHash, engine.state.LastResultsHash
+}
+
 func (engine *Engine) GetSnapshots() ([]byte, error) {
 	res, err := engine.proxyApp.Snapshot().ListSnapshotsSync(abciTypes.RequestListSnapshots{})
 	if err != nil {
@@ -460,7 +582,7 @@ func (engine *Engine) StartRPCServer(port int64) {
 		engineLogger.Error(fmt.Sprintf("failed to get nodeKey: %s", err))
 		return
 	}
-	nodeInfo, err := MakeNodeInfo(engine.config, nodeKey, engine.genDoc)
+	nodeInfo, err := MakeNodeInfo(engine.config, nodeKey, engine.genDoc, []byte{BlocksyncChannel})
 	if err != nil {
 		engineLogger.Error(fmt.Sprintf("failed to get nodeInfo: %s", err))
 		return
@@ -508,6 +630,60 @@ func (engine *Engine) StartRPCServer(port int64) {
 	}
 }
 
+func (engine *Engine) StartSnapshotP2PServer() error {
+	// the statesync reactor only answers snapshot and chunk requests from peers
+	// as long as we do not start syncing ourselves
+	ssR := ss.NewReactor(*engine.config.StateSync, engine.proxyApp.Snapshot(), engine.proxyApp.Query(), "")
+
+	nodeInfo, err := MakeNodeInfo(engine.config, engine.nodeKey, engine.genDoc, []byte{ss.SnapshotChannel, ss.ChunkChannel})
+	if err != nil {
+		return fmt.Errorf("failed to make node info: %w", err)
+	}
+
+	transport := cometP2P.NewMultiplexTransport(nodeInfo, *engine.nodeKey, cometP2P.MConnConfig(engine.config.P2P))
+	sw := CreateSwitch(engine.config, transport, "STATESYNC", ssR, nodeInfo, engine.nodeKey, engineLogger)
+
+	addr, err := cometP2P.NewNetAddressString(cometP2P.IDAddressString(engine.nodeKey.ID(), engine.config.P2P.ListenAddress))
+	if err != nil {
+		return fmt.Errorf("invalid p2p listen address: %w", err)
+	}
+
+	if err := transport.Listen(*addr); err != nil {
+		return fmt.Errorf("failed to start transport: %w", err)
+	}
+
+	if err := sw.Start(); err != nil {
+		_ = transport.Close()
+		return fmt.Errorf("failed to start switch: %w", err)
+	}
+
+	engine.snapshotSwitch = sw
+	engine.snapshotTransport = transport
+
+	return nil
+}
+
+func (engine *Engine) StopSnapshotP2PServer() error {
+	if engine.snapshotSwitch == nil {
+		return nil
+	}
+
+	// the switch does not close the transport, so we have to
+	// close it ourselves to free the P2P listen address
+	if err := engine.snapshotSwitch.Stop(); err != nil {
+		return fmt.Errorf("failed to stop switch: %w", err)
+	}
+
+	if err := engine.snapshotTransport.Close(); err != nil {
+		return fmt.Errorf("failed to close transport: %w", err)
+	}
+
+	engine.snapshotSwitch = nil
+	engine.snapshotTransport = nil
+
+	return nil
+}
+
 func (engine *Engine) GetState(height int64) ([]byte, error) {
 	initialHeight := height
 	if initialHeight == 0 {
@@ -626,7 +802,7 @@ func (engine *Engine) BootstrapState(rawState, rawSeenCommit, rawBlock []byte) e
 	var block *tmTypes.Block
 
 	if err := json.Unmarshal(rawBlock, &block); err != nil {
-		return fmt.Errorf("failed to unmarshal block: %w", err)
+		return utils.NewClassErrorf(utils.ErrDecode, "failed to unmarshal block: %w", err)
 	}
 
 	err := engine.stateStore.Bootstrap(*state)
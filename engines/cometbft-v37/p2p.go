@@ -3,12 +3,12 @@ package cometbft_v37
 import (
 	"fmt"
 	bc "github.com/KYVENetwork/cometbft/v37/blocksync"
-	bcv0 "github.com/KYVENetwork/cometbft/v37/blocksync"
 	cometLog "github.com/KYVENetwork/cometbft/v37/libs/log"
 	"github.com/KYVENetwork/cometbft/v37/p2p"
 	bcproto "github.com/KYVENetwork/cometbft/v37/proto/cometbft/v37/blocksync"
 	sm "github.com/KYVENetwork/cometbft/v37/state"
 	"github.com/KYVENetwork/cometbft/v37/version"
+	"github.com/KYVENetwork/ksync/types"
 	"reflect"
 )
 
@@ -113,10 +113,17 @@ func (bcR *BlockchainReactor) ReceiveEnvelope(e p2p.Envelope) {
 	}
 }
 
+// GetProtocolVersion returns the protocol versions the engine announces in the
+// node info during the p2p handshake
+func GetProtocolVersion() types.ProtocolVersion {
+	return types.ProtocolVersion{P2P: version.P2PProtocol, Block: sm.InitStateVersion.Consensus.Block, App: sm.InitStateVersion.Consensus.App}
+}
+
 func MakeNodeInfo(
 	config *Config,
 	nodeKey *p2p.NodeKey,
 	genDoc *GenesisDoc,
+	channels []byte,
 ) (p2p.NodeInfo, error) {
 	nodeInfo := p2p.DefaultNodeInfo{
 		ProtocolVersion: p2p.NewProtocolVersion(
@@ -127,7 +134,7 @@ func MakeNodeInfo(
 		DefaultNodeID: nodeKey.ID(),
 		Network:       genDoc.ChainID,
 		Version:       version.TMCoreSemVer,
-		Channels:      []byte{bcv0.BlocksyncChannel},
+		Channels:      channels,
 		Moniker:       config.Moniker,
 		Other: p2p.DefaultNodeInfoOther{
 			TxIndex:    "off",
@@ -149,7 +156,8 @@ func MakeNodeInfo(
 
 func CreateSwitch(config *Config,
 	transport p2p.Transport,
-	bcReactor p2p.Reactor,
+	reactorName string,
+	reactor p2p.Reactor,
 	nodeInfo p2p.NodeInfo,
 	nodeKey *p2p.NodeKey,
 	logger cometLog.Logger) *p2p.Switch {
@@ -159,8 +167,8 @@ func CreateSwitch(config *Config,
 		transport,
 	)
 	sw.SetLogger(logger)
-	bcReactor.SetLogger(logger)
-	sw.AddReactor("BLOCKCHAIN", bcReactor)
+	reactor.SetLogger(logger)
+	sw.AddReactor(reactorName, reactor)
 
 	sw.SetNodeInfo(nodeInfo)
 	sw.SetNodeKey(nodeKey)
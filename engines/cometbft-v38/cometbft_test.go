@@ -0,0 +1,291 @@
+package cometbft_v38
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	abciTypes "github.com/KYVENetwork/cometbft/v38/abci/types"
+	cfg "github.com/KYVENetwork/cometbft/v38/config"
+	"github.com/KYVENetwork/cometbft/v38/crypto/ed25519"
+	"github.com/KYVENetwork/cometbft/v38/crypto/tmhash"
+	"github.com/KYVENetwork/cometbft/v38/libs/json"
+	cometP2P "github.com/KYVENetwork/cometbft/v38/p2p"
+	cmtproto "github.com/KYVENetwork/cometbft/v38/proto/cometbft/v38/types"
+	"github.com/KYVENetwork/cometbft/v38/proxy"
+	tmTypes "github.com/KYVENetwork/cometbft/v38/types"
+	"github.com/KYVENetwork/ksync/utils"
+)
+
+const testChainId = "test-chain-1"
+
+// testChain is a chain of signed blocks where the validator set changes from
+// height four on, the commit of every block is in the last commit of the next
+type testChain struct {
+	genDoc     *GenesisDoc
+	privVals   []tmTypes.MockPV
+	validators map[int64]*tmTypes.ValidatorSet
+	blocks     map[int64]*Block
+}
+
+func newTestValidatorSet(privVals ...tmTypes.MockPV) *tmTypes.ValidatorSet {
+	validators := make([]*tmTypes.Validator, 0, len(privVals))
+	for _, privVal := range privVals {
+		validators = append(validators, tmTypes.NewValidator(privVal.PrivKey.PubKey(), 10))
+	}
+	return tmTypes.NewValidatorSet(validators)
+}
+
+func newTestChain(t *testing.T) *testChain {
+	chain := &testChain{
+		privVals:   []tmTypes.MockPV{tmTypes.NewMockPV(), tmTypes.NewMockPV(), tmTypes.NewMockPV(), tmTypes.NewMockPV()},
+		validators: make(map[int64]*tmTypes.ValidatorSet),
+		blocks:     make(map[int64]*Block),
+	}
+
+	initialValidators := newTestValidatorSet(chain.privVals[0:3]...)
+	changedValidators := newTestValidatorSet(chain.privVals[1:4]...)
+
+	chain.genDoc = &GenesisDoc{ChainID: testChainId, InitialHeight: 1}
+	for _, validator := range initialValidators.Validators {
+		chain.genDoc.Validators = append(chain.genDoc.Validators, tmTypes.GenesisValidator{
+			Address: validator.Address,
+			PubKey:  validator.PubKey,
+			Power:   validator.VotingPower,
+		})
+	}
+
+	for height := int64(1); height <= 6; height++ {
+		chain.validators[height] = initialValidators
+		if height >= 4 {
+			chain.validators[height] = changedValidators
+		}
+	}
+
+	lastCommit := &tmTypes.Commit{}
+	lastBlockId := tmTypes.BlockID{}
+
+	for height := int64(1); height <= 5; height++ {
+		block := tmTypes.MakeBlock(height, []tmTypes.Tx{}, lastCommit, nil)
+		block.Header.Populate(
+			block.Version, testChainId, time.Unix(height, 0).UTC(), lastBlockId,
+			chain.validators[height].Hash(), chain.validators[height+1].Hash(),
+			nil, nil, nil, chain.validators[height].Validators[0].Address,
+		)
+		chain.blocks[height] = block
+
+		lastBlockId = chain.blockId(t, height)
+		lastCommit = chain.commit(t, chain.validators[height], lastBlockId, height)
+	}
+
+	return chain
+}
+
+func (chain *testChain) blockId(t *testing.T, height int64) tmTypes.BlockID {
+	parts, err := chain.blocks[height].MakePartSet(tmTypes.BlockPartSizeBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tmTypes.BlockID{Hash: chain.blocks[height].Hash(), PartSetHeader: parts.Header()}
+}
+
+// commit signs the block id with all validators of the given set
+func (chain *testChain) commit(t *testing.T, validators *tmTypes.ValidatorSet, blockId tmTypes.BlockID, height int64) *tmTypes.Commit {
+	signers := make([]tmTypes.PrivValidator, 0, validators.Size())
+	for _, validator := range validators.Validators {
+		for _, privVal := range chain.privVals {
+			if privVal.PrivKey.PubKey().Address().String() == validator.Address.String() {
+				signers = append(signers, privVal)
+			}
+		}
+	}
+
+	voteSet := tmTypes.NewVoteSet(testChainId, height, 0, cmtproto.PrecommitType, validators)
+	extCommit, err := tmTypes.MakeExtCommit(blockId, height, 0, voteSet, signers, time.Unix(height, 0).UTC(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return extCommit.ToCommit()
+}
+
+func (chain *testChain) getValidators(height int64) ([]byte, error) {
+	return json.Marshal(chain.validators[height].Validators)
+}
+
+func TestVerifyBlock(t *testing.T) {
+	otherBlockId := tmTypes.BlockID{
+		Hash:          tmhash.Sum([]byte("other block")),
+		PartSetHeader: tmTypes.PartSetHeader{Total: 1, Hash: tmhash.Sum([]byte("other parts"))},
+	}
+
+	tests := []struct {
+		name        string
+		modify      func(t *testing.T, chain *testChain)
+		noValidator bool
+		expectedErr string
+		expectedIs  error
+	}{
+		{
+			name:   "valid chain with validator set change",
+			modify: func(t *testing.T, chain *testChain) {},
+		},
+		{
+			name: "wrong chain id",
+			modify: func(t *testing.T, chain *testChain) {
+				chain.genDoc.ChainID = "other-chain-1"
+			},
+			expectedErr: "wrong chain id at height 1",
+		},
+		{
+			name: "wrong last block id",
+			modify: func(t *testing.T, chain *testChain) {
+				chain.blocks[3].LastBlockID = otherBlockId
+			},
+			expectedErr: "wrong last block id at height 3",
+		},
+		{
+			name: "wrong validators hash",
+			modify: func(t *testing.T, chain *testChain) {
+				chain.blocks[2].ValidatorsHash = chain.validators[4].Hash()
+			},
+			expectedErr: "wrong validators hash at height 2",
+		},
+		{
+			name: "wrong next validators hash",
+			modify: func(t *testing.T, chain *testChain) {
+				chain.validators[4] = newTestValidatorSet(chain.privVals[0])
+			},
+			expectedErr: "wrong next validators hash at height 3",
+		},
+		{
+			name:        "validator set change without validator source",
+			modify:      func(t *testing.T, chain *testChain) {},
+			noValidator: true,
+			expectedErr: "failed to load validator set at height 4",
+		},
+		{
+			name: "invalid commit signature",
+			modify: func(t *testing.T, chain *testChain) {
+				chain.blocks[3].LastCommit.Signatures[0].Signature[0] ^= 0xff
+			},
+			expectedErr: "light commit verification failed at height 2",
+			expectedIs:  utils.ErrBlockValidation,
+		},
+		{
+			name: "commit of another block",
+			modify: func(t *testing.T, chain *testChain) {
+				chain.blocks[3].LastCommit = chain.commit(t, chain.validators[2], otherBlockId, 2)
+			},
+			expectedErr: "light commit verification failed at height 2",
+			expectedIs:  utils.ErrBlockValidation,
+		},
+		{
+			name: "commit of the previous validator set after the change",
+			modify: func(t *testing.T, chain *testChain) {
+				chain.blocks[5].LastCommit = chain.commit(t, chain.validators[3], chain.blockId(t, 4), 4)
+			},
+			expectedErr: "light commit verification failed at height 4",
+			expectedIs:  utils.ErrBlockValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := newTestChain(t)
+			tt.modify(t, chain)
+
+			getValidators := chain.getValidators
+			if tt.noValidator {
+				getValidators = nil
+			}
+
+			engine := &Engine{genDoc: chain.genDoc}
+
+			var err error
+			for height := int64(1); height < 5 && err == nil; height++ {
+				rawBlock, _ := json.Marshal(chain.blocks[height])
+				nextRawBlock, _ := json.Marshal(chain.blocks[height+1])
+
+				err = engine.VerifyBlock(rawBlock, nextRawBlock, getValidators)
+			}
+
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("expected chain to be valid, found %s", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("expected error %q, found %v", tt.expectedErr, err)
+			}
+
+			if tt.expectedIs != nil && !errors.Is(err, tt.expectedIs) {
+				t.Fatalf("expected error class %v, found %v", tt.expectedIs, err)
+			}
+		})
+	}
+}
+
+// newTestSnapshotEngine returns an engine with a local app which only has
+// what is required to serve snapshots over P2P
+func newTestSnapshotEngine(t *testing.T) (*Engine, string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	_ = listener.Close()
+
+	config := cfg.DefaultConfig()
+	config.P2P.ListenAddress = fmt.Sprintf("tcp://%s", address)
+
+	proxyApp := proxy.NewAppConns(proxy.NewLocalClientCreator(abciTypes.NewBaseApplication()), proxy.NopMetrics())
+	if err := proxyApp.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = proxyApp.Stop() })
+
+	return &Engine{
+		config:   config,
+		genDoc:   &GenesisDoc{ChainID: testChainId},
+		nodeKey:  &cometP2P.NodeKey{PrivKey: ed25519.GenPrivKey()},
+		proxyApp: proxyApp,
+	}, address
+}
+
+func TestSnapshotP2PServer(t *testing.T) {
+	engine, address := newTestSnapshotEngine(t)
+
+	if err := engine.StopSnapshotP2PServer(); err != nil {
+		t.Fatalf("expected stopping a server which was not started to succeed, found %s", err)
+	}
+
+	// the server is restarted along with the engine, so it has to free the listen address
+	for i := 0; i < 2; i++ {
+		if err := engine.StartSnapshotP2PServer(); err != nil {
+			t.Fatal(err)
+		}
+
+		conn, err := net.Dial("tcp", address)
+		if err != nil {
+			t.Fatalf("expected server to listen on %s, found %s", address, err)
+		}
+		_ = conn.Close()
+
+		if err := engine.StopSnapshotP2PServer(); err != nil {
+			t.Fatal(err)
+		}
+
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			t.Fatalf("expected listen address %s to be free after stopping, found %s", address, err)
+		}
+		_ = listener.Close()
+	}
+}
@@ -7,8 +7,15 @@ var (
 	HomePath                string
 	ChainId                 string
 	ChainRest               string
+	ChainGrpc               string
+	ChainRpc                string
+	ChainRpcWitnesses       string
+	VerifyFinality          bool
+	TrustedHeight           int64
+	TrustedHash             string
 	StorageRest             string
 	BlockRpc                string
+	ValidatorRpc            string
 	SnapshotPoolId          string
 	BlockPoolId             string
 	StartHeight             int64
@@ -16,7 +23,12 @@ var (
 	RpcServer               bool
 	RpcServerPort           int64
 	SnapshotPort            int64
+	SnapshotP2P             bool
+	SnapshotInterval        int64
+	SnapshotDir             string
 	BlockRpcReqTimeout      int64
+	AppHashCheckInterval    int64
+	AppHashRpc              string
 	Pruning                 bool
 	KeepSnapshots           bool
 	SkipWaiting             bool
@@ -29,12 +41,39 @@ var (
 	Debug                   bool
 	Y                       bool
 	Moniker                 string
+	SetupMode               string
+	SetupConfig             string
+	PeerStrategy            string
+	PeerCount               int64
+	PeerProviders           string
+	NonInteractive          bool
+	BuildRecipes            string
+	GenesisFile             string
+	GenesisUrl              string
+	GenesisChecksum         string
+	SkipPeerProbe           bool
+	AppPruning              string
+	AppSnapshotInterval     int64
+	EnableApi               bool
+	ConfigFile              string
+	Handoff                 bool
+	HandoffRestart          bool
+	HandoffLogFile          string
+	HandoffSystemdUnit      string
+	GenerateServices        string
+	ServicesOutputDir       string
+	TargetUpgrade           string
+	TargetTime              string
 	DaemonName              string
 	DaemonHome              string
+	AttestationPath         string
+	RegistryUrl             string
+	RegistryRef             string
+	Output                  string
+	SourceIds               string
+	InspectBundles          int64
 	// Engine is deprecated
 	Engine string
 	// Source is deprecated
 	Source string
-	// RegistryUrl is deprecated
-	RegistryUrl string
 )
@@ -16,7 +16,17 @@ func init() {
 }
 
 func NewLogger(name string, keyvals ...interface{}) zerolog.Logger {
-	writer := io.MultiWriter(os.Stdout)
+	return newLogger(os.Stdout, name, keyvals...)
+}
+
+// RedirectToStderr makes the logger write to stderr, so that commands
+// with machine-readable output have nothing else on stdout
+func RedirectToStderr() {
+	Logger = newLogger(os.Stderr, "KSYNC")
+}
+
+func newLogger(out io.Writer, name string, keyvals ...interface{}) zerolog.Logger {
+	writer := io.MultiWriter(out)
 	customConsoleWriter := zerolog.ConsoleWriter{Out: writer}
 	customConsoleWriter.FormatCaller = func(i interface{}) string {
 		return fmt.Sprintf("\x1b[36m[%s]\x1b[0m", name)
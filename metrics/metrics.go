@@ -12,6 +12,7 @@ import (
 	"runtime"
 	runtimeDebug "runtime/debug"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -32,6 +33,9 @@ var (
 	latestHeight             int64
 	successfulRequests       int64
 	failedRequests           int64
+
+	interruptHandler      func(sig os.Signal)
+	interruptHandlerMutex sync.Mutex
 )
 
 func SetCommand(_command string) {
@@ -81,6 +85,15 @@ func GetInterrupt() bool {
 	return interrupt
 }
 
+// SetInterruptHandler sets a handler which gets called with the signal before KSYNC
+// exits on an interrupt, e.g. for stopping child processes. Nil removes the handler
+func SetInterruptHandler(handler func(sig os.Signal)) {
+	interruptHandlerMutex.Lock()
+	defer interruptHandlerMutex.Unlock()
+
+	interruptHandler = handler
+}
+
 func getVersion() string {
 	version, ok := runtimeDebug.ReadBuildInfo()
 	if !ok {
@@ -169,16 +182,27 @@ func getProperties(errorRuntime error) analytics.Properties {
 	properties.Set("flag_home_path", flags.HomePath)
 	properties.Set("flag_chain_id", flags.ChainId)
 	properties.Set("flag_chain_rest", flags.ChainRest)
+	properties.Set("flag_chain_grpc", flags.ChainGrpc)
+	properties.Set("flag_verify_finality", flags.VerifyFinality)
+	properties.Set("flag_registry_url", flags.RegistryUrl)
+	properties.Set("flag_registry_ref", flags.RegistryRef)
+	properties.Set("flag_output", flags.Output)
 	properties.Set("flag_storage_rest", flags.StorageRest)
 	properties.Set("flag_block_rpc", flags.BlockRpc)
+	properties.Set("flag_validator_rpc", flags.ValidatorRpc)
 	properties.Set("flag_snapshot_pool_id", flags.SnapshotPoolId)
 	properties.Set("flag_block_pool_id", flags.BlockPoolId)
 	properties.Set("flag_start_height", flags.StartHeight)
+	properties.Set("flag_target_upgrade", flags.TargetUpgrade)
+	properties.Set("flag_target_time", flags.TargetTime)
 	properties.Set("flag_target_height", flags.TargetHeight)
 	properties.Set("flag_rpc_server", flags.RpcServer)
 	properties.Set("flag_rpc_server_port", flags.RpcServerPort)
 	properties.Set("flag_snapshot_port", flags.SnapshotPort)
+	properties.Set("flag_snapshot_p2p", flags.SnapshotP2P)
+	properties.Set("flag_snapshot_interval", flags.SnapshotInterval)
 	properties.Set("flag_block_rpc_req_timeout", flags.BlockRpcReqTimeout)
+	properties.Set("flag_app_hash_check_interval", flags.AppHashCheckInterval)
 	properties.Set("flag_pruning", flags.Pruning)
 	properties.Set("flag_keep_snapshots", flags.KeepSnapshots)
 	properties.Set("flag_skip_waiting", flags.SkipWaiting)
@@ -188,6 +212,12 @@ func getProperties(errorRuntime error) analytics.Properties {
 	properties.Set("flag_opt_out", flags.OptOut)
 	properties.Set("flag_debug", flags.Debug)
 	properties.Set("flag_y", flags.Y)
+	properties.Set("flag_setup_mode", flags.SetupMode)
+	properties.Set("flag_peer_strategy", flags.PeerStrategy)
+	properties.Set("flag_skip_peer_probe", flags.SkipPeerProbe)
+	properties.Set("flag_handoff", flags.Handoff)
+	properties.Set("flag_handoff_restart", flags.HandoffRestart)
+	properties.Set("flag_non_interactive", flags.NonInteractive)
 
 	// set metric properties (all must start with "metric_")
 	properties.Set("metrics_total_duration", time.Since(startTime).Milliseconds())
@@ -217,7 +247,7 @@ func getProperties(errorRuntime error) analytics.Properties {
 	properties.Set("error_interrupt", interrupt)
 
 	// set status properties (all must start with "status_")
-	if command == "block-sync" || command == "height-sync" || command == "serve-blocks" || command == "serve-snapshots" {
+	if command == "block-sync" || command == "height-sync" || command == "serve-blocks" || command == "serve-snapshots" || command == "export-snapshots" || command == "verify" {
 		reachedTargetHeight := flags.TargetHeight > 0 && latestHeight == flags.TargetHeight && errorRuntime == nil
 		properties.Set("status_reached_target_height", reachedTargetHeight)
 	} else if command == "state-sync" {
@@ -244,12 +274,21 @@ func getProperties(errorRuntime error) analytics.Properties {
 // CatchInterrupt catches interrupt signals from Ctrl+C ensures
 // that metrics are sent before KSYNC exits
 func CatchInterrupt() {
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		<-c
+		sig := <-c
 		logger.Logger.Info().Msg("received interrupt signal, shutting down KSYNC")
+
+		interruptHandlerMutex.Lock()
+		handler := interruptHandler
+		interruptHandlerMutex.Unlock()
+
+		if handler != nil {
+			handler(sig)
+		}
+
 		SendTrack(fmt.Errorf("INTERRUPT"))
 
 		os.Exit(1)
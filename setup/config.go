@@ -0,0 +1,24 @@
+package setup
+
+import (
+	"fmt"
+	"github.com/KYVENetwork/ksync/types"
+	"gopkg.in/yaml.v2"
+	"os"
+)
+
+// LoadConfig loads the answers for the setup from a yaml file, so
+// that the setup can run without any user interaction
+func LoadConfig(path string) (*types.SetupConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read setup config %s: %w", path, err)
+	}
+
+	var config types.SetupConfig
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal setup config %s: %w", path, err)
+	}
+
+	return &config, nil
+}
@@ -0,0 +1,65 @@
+package setup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		expectedErr string
+	}{
+		{
+			name: "all values",
+			config: "source: osmosis\n" +
+				"chain_id: kyve-1\n" +
+				"mode: state-sync\n" +
+				"peer_strategy: provider\n" +
+				"peer_count: 5\n" +
+				"peer_providers: [Polkachu, AutoStake]\n" +
+				"non_interactive: true\n" +
+				"services:\n  generate: [systemd]\n  output_dir: /tmp/services\n" +
+				"app:\n  pruning: custom\n  snapshot_interval: 1000\n  enable_api: true\n" +
+				"genesis:\n  url: https://example.com/genesis.json\n  checksum: sha256:00\n",
+		},
+		{name: "unknown key", config: "source: osmosis\npeer_strategie: best\n", expectedErr: "field peer_strategie not found"},
+		{name: "unknown nested key", config: "app:\n  pruning_interval: 10\n", expectedErr: "field pruning_interval not found"},
+		{name: "wrong type", config: "peer_count: five\n", expectedErr: "cannot unmarshal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "setup.yml")
+			if err := os.WriteFile(path, []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := LoadConfig(path)
+
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("expected error %q, found %v", tt.expectedErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if config.Source != "osmosis" || config.Mode != "state-sync" || config.PeerCount != 5 || len(config.PeerProviders) != 2 ||
+				!config.NonInteractive || config.Services.Generate[0] != "systemd" || config.App.SnapshotInterval != 1000 ||
+				!config.App.EnableApi || config.Genesis.Checksum != "sha256:00" {
+				t.Fatalf("expected all values to be loaded, found %+v", config)
+			}
+		})
+	}
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.yml")); err == nil {
+		t.Fatal("expected missing config to fail")
+	}
+}
@@ -0,0 +1,492 @@
+package installations
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"github.com/KYVENetwork/ksync/types"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	cosmovisorVersion   = "v1.7.0"
+	cosmovisorGoVersion = "1.23"
+	cosmovisorRepo      = "https://github.com/cosmos/cosmos-sdk"
+	cosmovisorModule    = "cosmossdk.io/tools/cosmovisor/cmd/cosmovisor"
+
+	// downloadTimeout is generous since pre-built binaries can be several hundred MB
+	downloadTimeout = 10 * time.Minute
+)
+
+// installCosmovisor reuses cosmovisor from the build cache if available, else it
+// builds cosmovisor with the local go installation and only falls back to a
+// docker build if that fails
+func installCosmovisor(outputPath string) error {
+	start := time.Now()
+
+	cacheDir, key, err := getCacheDir(cosmovisorRepo, fmt.Sprintf("cosmovisor/%s", cosmovisorVersion), cosmovisorGoVersion, "", nil)
+	if err == nil {
+		if found, err := loadFromCache(cacheDir, outputPath, "cosmovisor"); err == nil && found {
+			program.Send(types.Upgrade{
+				Name:            "Cosmovisor",
+				Version:         cosmovisorVersion,
+				InstallDuration: time.Since(start),
+			})
+
+			return nil
+		}
+	}
+
+	if err := goInstallCosmovisor(outputPath); err != nil {
+		program.Send(fmt.Sprintf("failed to build cosmovisor with go: %s, falling back to docker", err))
+
+		if err := buildCosmovisor(outputPath); err != nil {
+			return err
+		}
+	} else {
+		program.Send(types.Upgrade{
+			Name:            "Cosmovisor",
+			Version:         cosmovisorVersion,
+			InstallDuration: time.Since(start),
+		})
+	}
+
+	if cacheDir != "" {
+		if err := saveToCache(cacheDir, key, outputPath, []string{"cosmovisor"}); err != nil {
+			program.Send(fmt.Sprintf("failed to save cosmovisor in build cache: %s", err))
+		}
+	}
+
+	return nil
+}
+
+// installUpgradeBinary reuses the binary from the build cache if available. Else, it
+// first tries to download the pre-built binary listed in the chain registry, then
+// builds it with the local go installation and only falls back to a docker build
+// if both fail
+func installUpgradeBinary(upgrade types.Upgrade, chainSchema *types.ChainSchema, outputPath string) error {
+	start := time.Now()
+
+	cacheDir, key, err := getCacheDir(chainSchema.Codebase.GitRepoUrl, upgrade.Version, upgrade.GoVersion, upgrade.LibwasmVersion, &upgrade.Recipe)
+	if err == nil {
+		if found, err := loadFromCache(cacheDir, outputPath, chainSchema.DaemonName); err == nil && found {
+			upgrade.InstallDuration = time.Since(start)
+			program.Send(upgrade)
+			return nil
+		}
+	}
+
+	err = downloadUpgradeBinary(upgrade, chainSchema, outputPath)
+	if err != nil {
+		program.Send(fmt.Sprintf("failed to download pre-built binary %s: %s, falling back to go build", upgrade.Version, err))
+		err = goBuildUpgradeBinary(upgrade, chainSchema, outputPath)
+	}
+
+	if err != nil {
+		program.Send(fmt.Sprintf("failed to build binary %s with go: %s, falling back to docker", upgrade.Version, err))
+
+		if err := buildUpgradeBinary(upgrade, chainSchema, outputPath); err != nil {
+			return err
+		}
+	} else {
+		upgrade.InstallDuration = time.Since(start)
+		program.Send(upgrade)
+	}
+
+	if cacheDir != "" {
+		files, err := getBuiltFiles(outputPath, chainSchema.DaemonName)
+		if err == nil {
+			err = saveToCache(cacheDir, key, outputPath, files)
+		}
+
+		if err != nil {
+			program.Send(fmt.Sprintf("failed to save binary %s in build cache: %s", upgrade.Version, err))
+		}
+	}
+
+	return nil
+}
+
+// downloadUpgradeBinary downloads the pre-built binary for the current platform
+// and verifies it with the checksum which is part of the url in the chain registry,
+// e.g. "https://example.com/appd.tar.gz?checksum=sha256:<hex>"
+func downloadUpgradeBinary(upgrade types.Upgrade, chainSchema *types.ChainSchema, outputPath string) error {
+	platform := fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH)
+
+	binaryUrl, found := upgrade.Binaries[platform]
+	if !found {
+		return fmt.Errorf("no pre-built binary found for platform %s", platform)
+	}
+
+	downloadUrl, algorithm, checksum, err := parseBinaryUrl(binaryUrl)
+	if err != nil {
+		return err
+	}
+
+	data, err := download(downloadUrl)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyChecksum(data, algorithm, checksum); err != nil {
+		return err
+	}
+
+	binary, err := extractBinary(downloadUrl, data, chainSchema.DaemonName)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outputPath, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", outputPath, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(outputPath, chainSchema.DaemonName), binary, 0o755); err != nil {
+		return fmt.Errorf("failed to write binary: %w", err)
+	}
+
+	if upgrade.LibwasmVersion != "" {
+		if err := downloadLibwasm(upgrade.LibwasmVersion, outputPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downloadLibwasm downloads the libwasmvm shared library from the wasmvm
+// release and verifies it with the checksums of the release
+func downloadLibwasm(libwasmVersion, outputPath string) error {
+	libName, err := getLibwasmName(runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+
+	releaseUrl := fmt.Sprintf("https://github.com/CosmWasm/wasmvm/releases/download/%s", libwasmVersion)
+
+	checksums, err := download(fmt.Sprintf("%s/checksums.txt", releaseUrl))
+	if err != nil {
+		return fmt.Errorf("failed to download libwasmvm checksums: %w", err)
+	}
+
+	checksum := ""
+	for _, line := range strings.Split(string(checksums), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[1] == libName {
+			checksum = fields[0]
+		}
+	}
+
+	if checksum == "" {
+		return fmt.Errorf("no checksum found for %s in wasmvm release %s", libName, libwasmVersion)
+	}
+
+	data, err := download(fmt.Sprintf("%s/%s", releaseUrl, libName))
+	if err != nil {
+		return err
+	}
+
+	if err := verifyChecksum(data, "sha256", checksum); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(outputPath, libName), data, 0o755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", libName, err)
+	}
+
+	return nil
+}
+
+// goInstallCosmovisor installs cosmovisor with the local go installation
+// into the output path
+func goInstallCosmovisor(outputPath string) error {
+	if _, err := exec.LookPath("go"); err != nil {
+		return fmt.Errorf("go is not installed")
+	}
+
+	cmd := exec.Command("go", "install", fmt.Sprintf("%s@%s", cosmovisorModule, cosmovisorVersion))
+	cmd.Env = append(os.Environ(), fmt.Sprintf("GOBIN=%s", outputPath))
+
+	return runBuildCmd(cmd)
+}
+
+// goBuildUpgradeBinary clones the repository of the chain and builds the binary
+// with the same make command as the docker build but with the local go installation
+func goBuildUpgradeBinary(upgrade types.Upgrade, chainSchema *types.ChainSchema, outputPath string) error {
+	for _, tool := range []string{"git", "make", "go"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return fmt.Errorf("%s is not installed", tool)
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "ksync-build-")
+	if err != nil {
+		return fmt.Errorf("failed to create build directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	repoPath := filepath.Join(tmpDir, "repo")
+
+	if err := runBuildCmd(exec.Command("git", "clone", "--depth", "1", "--branch", upgrade.Version, chainSchema.Codebase.GitRepoUrl, repoPath)); err != nil {
+		return fmt.Errorf("failed to clone %s: %w", chainSchema.Codebase.GitRepoUrl, err)
+	}
+
+	recipe := upgrade.Recipe
+
+	buildPath := filepath.Join(repoPath, recipe.Subfolder)
+
+	env := append(os.Environ(), fmt.Sprintf("GOTOOLCHAIN=%s", getGoToolchain(upgrade.GoVersion)))
+	for _, key := range getSortedKeys(recipe.Env) {
+		env = append(env, fmt.Sprintf("%s=%s", key, recipe.Env[key]))
+	}
+
+	cmd := exec.Command("make", recipe.BuildCmd, fmt.Sprintf("GO_VERSION=%s", upgrade.GoVersion))
+	cmd.Args = append(cmd.Args, recipe.BuildArgs...)
+	cmd.Dir = buildPath
+	cmd.Env = env
+
+	if err := runBuildCmd(cmd); err != nil {
+		return fmt.Errorf("failed to run make %s: %w", recipe.BuildCmd, err)
+	}
+
+	binaryPath := filepath.Join(buildPath, "build", chainSchema.DaemonName)
+
+	if recipe.BinaryPath != "" {
+		binaryPath = filepath.Join(buildPath, recipe.BinaryPath)
+
+		// binaries which are installed with "make install" are located in the GOPATH
+		if strings.HasPrefix(recipe.BinaryPath, "/go/") {
+			goPath, err := getGoEnv("GOPATH", env)
+			if err != nil {
+				return err
+			}
+
+			binaryPath = filepath.Join(goPath, strings.TrimPrefix(recipe.BinaryPath, "/go/"))
+		}
+	}
+
+	if info, err := os.Stat(binaryPath); err == nil && info.IsDir() {
+		binaryPath = filepath.Join(binaryPath, chainSchema.DaemonName)
+	}
+
+	if err := copyFile(binaryPath, filepath.Join(outputPath, chainSchema.DaemonName)); err != nil {
+		return err
+	}
+
+	if upgrade.LibwasmVersion != "" {
+		modCachePath, err := getGoEnv("GOMODCACHE", env)
+		if err != nil {
+			return err
+		}
+
+		libwasmPath, err := getLibwasmPath(modCachePath, runtime.GOARCH, upgrade)
+		if err != nil {
+			return err
+		}
+
+		if err := copyFile(libwasmPath, filepath.Join(outputPath, filepath.Base(libwasmPath))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getGoToolchain returns the toolchain for the go version of the upgrade. Toolchain
+// switching is only supported since go1.21, before that the local version is used
+func getGoToolchain(goVersion string) string {
+	versions := strings.Split(goVersion, ".")
+	if len(versions) < 2 {
+		return "auto"
+	}
+
+	minor, err := strconv.ParseInt(versions[1], 10, 64)
+	if err != nil || minor < 21 {
+		return "auto"
+	}
+
+	// toolchain names of go1.21 and later always contain the patch version
+	if len(versions) == 2 {
+		goVersion = fmt.Sprintf("%s.0", goVersion)
+	}
+
+	return fmt.Sprintf("go%s+auto", goVersion)
+}
+
+func getGoEnv(name string, env []string) (string, error) {
+	cmd := exec.Command("go", "env", name)
+	cmd.Env = env
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get go env %s: %w", name, err)
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+func runBuildCmd(cmd *exec.Cmd) error {
+	var writer CmdWriter
+
+	cmd.Stdout = &writer
+	cmd.Stderr = &writer
+
+	return cmd.Run()
+}
+
+// parseBinaryUrl splits the binary url of the chain registry into the download
+// url and the checksum. Only the checksum pair is removed from the raw query, the
+// rest of the query stays untouched since e.g. signed urls break if re-encoded
+func parseBinaryUrl(binaryUrl string) (string, string, string, error) {
+	u, err := url.Parse(binaryUrl)
+	if err != nil {
+		return "", "", "", fmt.Errorf("failed to parse binary url %s: %w", binaryUrl, err)
+	}
+
+	query := u.Query()
+	checksum := query.Get("checksum")
+	if checksum == "" {
+		return "", "", "", fmt.Errorf("binary url %s has no checksum", binaryUrl)
+	}
+
+	pairs := make([]string, 0)
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if key, _, _ := strings.Cut(pair, "="); key == "checksum" || pair == "" {
+			continue
+		}
+		pairs = append(pairs, pair)
+	}
+	u.RawQuery = strings.Join(pairs, "&")
+
+	algorithm, value, found := strings.Cut(checksum, ":")
+	if !found {
+		return "", "", "", fmt.Errorf("invalid checksum %s, expected format \"<algorithm>:<hex>\"", checksum)
+	}
+
+	return u.String(), algorithm, value, nil
+}
+
+func verifyChecksum(data []byte, algorithm, checksum string) error {
+	var sum []byte
+
+	switch algorithm {
+	case "sha256":
+		s := sha256.Sum256(data)
+		sum = s[:]
+	case "sha512":
+		s := sha512.Sum512(data)
+		sum = s[:]
+	default:
+		return fmt.Errorf("unsupported checksum algorithm %s", algorithm)
+	}
+
+	if !strings.EqualFold(hex.EncodeToString(sum), checksum) {
+		return fmt.Errorf("checksum mismatch, expected = %s, found = %s", checksum, hex.EncodeToString(sum))
+	}
+
+	return nil
+}
+
+// extractBinary returns the binary with the daemon name if the download
+// is an archive, else the download is already the binary
+func extractBinary(downloadUrl string, data []byte, daemonName string) ([]byte, error) {
+	path := strings.Split(downloadUrl, "?")[0]
+
+	switch {
+	case strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz"):
+		gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip archive: %w", err)
+		}
+		defer gzipReader.Close()
+
+		return extractFromTar(tar.NewReader(gzipReader), daemonName)
+	case strings.HasSuffix(path, ".tar"):
+		return extractFromTar(tar.NewReader(bytes.NewReader(data)), daemonName)
+	case strings.HasSuffix(path, ".zip"):
+		zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zip archive: %w", err)
+		}
+
+		for _, file := range zipReader.File {
+			if file.FileInfo().IsDir() || filepath.Base(file.Name) != daemonName {
+				continue
+			}
+
+			f, err := file.Open()
+			if err != nil {
+				return nil, fmt.Errorf("failed to open %s in zip archive: %w", file.Name, err)
+			}
+			defer f.Close()
+
+			return io.ReadAll(f)
+		}
+
+		return nil, fmt.Errorf("binary %s not found in zip archive", daemonName)
+	default:
+		return data, nil
+	}
+}
+
+func extractFromTar(tarReader *tar.Reader, daemonName string) ([]byte, error) {
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("binary %s not found in tar archive", daemonName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar archive: %w", err)
+		}
+
+		if header.Typeflag == tar.TypeReg && filepath.Base(header.Name) == daemonName {
+			return io.ReadAll(tarReader)
+		}
+	}
+}
+
+func download(downloadUrl string) ([]byte, error) {
+	client := &http.Client{Timeout: downloadTimeout}
+
+	response, err := client.Get(downloadUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", downloadUrl, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: got status code %d != 200", downloadUrl, response.StatusCode)
+	}
+
+	return io.ReadAll(response.Body)
+}
+
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(dst), err)
+	}
+
+	if err := os.WriteFile(dst, data, 0o755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+
+	return nil
+}
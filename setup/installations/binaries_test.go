@@ -0,0 +1,328 @@
+package installations
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/types"
+)
+
+func TestGetGoToolchain(t *testing.T) {
+	tests := []struct {
+		goVersion string
+		expected  string
+	}{
+		{goVersion: "1.20", expected: "auto"},
+		{goVersion: "1.19.4", expected: "auto"},
+		{goVersion: "1.21", expected: "go1.21.0+auto"},
+		{goVersion: "1.22.5", expected: "go1.22.5+auto"},
+		{goVersion: "1", expected: "auto"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goVersion, func(t *testing.T) {
+			if toolchain := getGoToolchain(tt.goVersion); toolchain != tt.expected {
+				t.Fatalf("expected toolchain %s, found %s", tt.expected, toolchain)
+			}
+		})
+	}
+}
+
+func TestGetLibwasmPath(t *testing.T) {
+	tests := []struct {
+		name           string
+		goarch         string
+		libwasmVersion string
+		expected       string
+	}{
+		{
+			name:           "v1 on amd64",
+			goarch:         "amd64",
+			libwasmVersion: "v1.5.2",
+			expected:       "/go/pkg/mod/github.com/!cosm!wasm/wasmvm@v1.5.2/internal/api/libwasmvm.x86_64.so",
+		},
+		{
+			name:           "v1 on arm64",
+			goarch:         "arm64",
+			libwasmVersion: "v1.5.2",
+			expected:       "/go/pkg/mod/github.com/!cosm!wasm/wasmvm@v1.5.2/internal/api/libwasmvm.aarch64.so",
+		},
+		{
+			name:           "v1.0 on arm64",
+			goarch:         "arm64",
+			libwasmVersion: "v1.0.0",
+			expected:       "/go/pkg/mod/github.com/!cosm!wasm/wasmvm@v1.0.0/api/libwasmvm.aarch64.so",
+		},
+		{
+			name:           "v1.0 beta",
+			goarch:         "amd64",
+			libwasmVersion: "v1.0.0-beta10",
+			expected:       "/go/pkg/mod/github.com/!cosm!wasm/wasmvm@v1.0.0-beta10/api/libwasmvm.so",
+		},
+		{
+			name:           "v2 on arm64",
+			goarch:         "arm64",
+			libwasmVersion: "v2.1.0",
+			expected:       "/go/pkg/mod/github.com/!cosm!wasm/wasmvm/v2@v2.1.0/internal/api/libwasmvm.aarch64.so",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			libwasmPath, err := getLibwasmPath("/go/pkg/mod", tt.goarch, types.Upgrade{LibwasmVersion: tt.libwasmVersion})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if libwasmPath != tt.expected {
+				t.Fatalf("expected %s, found %s", tt.expected, libwasmPath)
+			}
+		})
+	}
+
+	if _, err := getLibwasmPath("/go/pkg/mod", "386", types.Upgrade{LibwasmVersion: "v1.5.2"}); err == nil {
+		t.Fatal("expected unsupported architecture to fail")
+	}
+}
+
+func TestParseBinaryUrl(t *testing.T) {
+	tests := []struct {
+		name              string
+		binaryUrl         string
+		expectedUrl       string
+		expectedAlgorithm string
+		expectedChecksum  string
+		expectedErr       string
+	}{
+		{
+			name:              "only checksum",
+			binaryUrl:         "https://example.com/appd.tar.gz?checksum=sha256:abcd",
+			expectedUrl:       "https://example.com/appd.tar.gz",
+			expectedAlgorithm: "sha256",
+			expectedChecksum:  "abcd",
+		},
+		{
+			name:              "checksum between other parameters",
+			binaryUrl:         "https://example.com/appd?b=2&checksum=sha512:abcd&a=1",
+			expectedUrl:       "https://example.com/appd?b=2&a=1",
+			expectedAlgorithm: "sha512",
+			expectedChecksum:  "abcd",
+		},
+		{
+			name:              "signed url keeps its encoding and order",
+			binaryUrl:         "https://bucket.example.com/appd.zip?X-Amz-Signature=a%2Fb%3D&X-Amz-Credential=key%2F20240101&checksum=sha256:abcd&X-Amz-Expires=3600",
+			expectedUrl:       "https://bucket.example.com/appd.zip?X-Amz-Signature=a%2Fb%3D&X-Amz-Credential=key%2F20240101&X-Amz-Expires=3600",
+			expectedAlgorithm: "sha256",
+			expectedChecksum:  "abcd",
+		},
+		{
+			name:        "no checksum",
+			binaryUrl:   "https://example.com/appd?a=1",
+			expectedErr: "has no checksum",
+		},
+		{
+			name:        "checksum without algorithm",
+			binaryUrl:   "https://example.com/appd?checksum=abcd",
+			expectedErr: "invalid checksum abcd",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			downloadUrl, algorithm, checksum, err := parseBinaryUrl(tt.binaryUrl)
+
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("expected error %q, found %v", tt.expectedErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if downloadUrl != tt.expectedUrl || algorithm != tt.expectedAlgorithm || checksum != tt.expectedChecksum {
+				t.Fatalf("expected %s %s:%s, found %s %s:%s", tt.expectedUrl, tt.expectedAlgorithm, tt.expectedChecksum, downloadUrl, algorithm, checksum)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("binary")
+	sha256Sum := sha256.Sum256(data)
+	sha512Sum := sha512.Sum512(data)
+
+	tests := []struct {
+		name        string
+		algorithm   string
+		checksum    string
+		expectedErr string
+	}{
+		{name: "sha256", algorithm: "sha256", checksum: hex.EncodeToString(sha256Sum[:])},
+		{name: "sha256 upper case", algorithm: "sha256", checksum: strings.ToUpper(hex.EncodeToString(sha256Sum[:]))},
+		{name: "sha512", algorithm: "sha512", checksum: hex.EncodeToString(sha512Sum[:])},
+		{name: "mismatch", algorithm: "sha256", checksum: hex.EncodeToString(sha512Sum[:32]), expectedErr: "checksum mismatch"},
+		{name: "wrong algorithm", algorithm: "sha512", checksum: hex.EncodeToString(sha256Sum[:]), expectedErr: "checksum mismatch"},
+		{name: "unsupported algorithm", algorithm: "md5", checksum: "abcd", expectedErr: "unsupported checksum algorithm md5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyChecksum(data, tt.algorithm, tt.checksum)
+
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("expected error %q, found %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+// archive returns a tar or zip archive with the given files
+func archive(t *testing.T, kind string, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	switch kind {
+	case "zip":
+		zipWriter := zip.NewWriter(&buf)
+		for name, content := range files {
+			w, err := zipWriter.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := zipWriter.Close(); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		tarWriter := tar.NewWriter(&buf)
+		if err := tarWriter.WriteHeader(&tar.Header{Name: "bin/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+			t.Fatal(err)
+		}
+		for name, content := range files {
+			if err := tarWriter.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o755, Size: int64(len(content))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tarWriter.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tarWriter.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	if _, err := gzipWriter.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractBinary(t *testing.T) {
+	files := map[string]string{"README.md": "readme", "bin/appd": "binary", "bin/appd.sig": "signature"}
+
+	tests := []struct {
+		name        string
+		downloadUrl string
+		data        []byte
+		expectedErr string
+	}{
+		{name: "plain binary", downloadUrl: "https://example.com/appd", data: []byte("binary")},
+		{name: "tar", downloadUrl: "https://example.com/appd.tar", data: archive(t, "tar", files)},
+		{name: "tar.gz", downloadUrl: "https://example.com/appd.tar.gz", data: gzipped(t, archive(t, "tar", files))},
+		{name: "tgz with query", downloadUrl: "https://example.com/appd.tgz?a=1", data: gzipped(t, archive(t, "tar", files))},
+		{name: "zip", downloadUrl: "https://example.com/appd.zip", data: archive(t, "zip", files)},
+		{name: "tar without binary", downloadUrl: "https://example.com/appd.tar", data: archive(t, "tar", map[string]string{"bin/otherd": "binary"}), expectedErr: "binary appd not found in tar archive"},
+		{name: "zip without binary", downloadUrl: "https://example.com/appd.zip", data: archive(t, "zip", map[string]string{"bin/otherd": "binary"}), expectedErr: "binary appd not found in zip archive"},
+		{name: "tar.gz which is not gzipped", downloadUrl: "https://example.com/appd.tar.gz", data: archive(t, "tar", files), expectedErr: "failed to read gzip archive"},
+		{name: "invalid zip", downloadUrl: "https://example.com/appd.zip", data: []byte("binary"), expectedErr: "failed to read zip archive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			binary, err := extractBinary(tt.downloadUrl, tt.data, "appd")
+
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("expected error %q, found %v", tt.expectedErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(binary) != "binary" {
+				t.Fatalf("expected binary to be extracted, found %q", binary)
+			}
+		})
+	}
+}
+
+func TestDownload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/appd" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// the query of signed urls has to arrive untouched
+		if r.URL.RawQuery != "X-Amz-Signature=a%2Fb%3D&X-Amz-Expires=3600" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte("binary"))
+	}))
+	defer server.Close()
+
+	downloadUrl, _, _, err := parseBinaryUrl(server.URL + "/appd?X-Amz-Signature=a%2Fb%3D&checksum=sha256:abcd&X-Amz-Expires=3600")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := download(downloadUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "binary" {
+		t.Fatalf("expected binary to be downloaded, found %q", data)
+	}
+
+	if _, err := download(server.URL + "/missing"); err == nil || !strings.Contains(err.Error(), "got status code 404") {
+		t.Fatalf("expected download of missing file to fail, found %v", err)
+	}
+}
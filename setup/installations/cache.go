@@ -0,0 +1,146 @@
+package installations
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// cacheKey identifies a built binary. Binaries with the same key are
+// identical and can therefore be reused across chains and home directories
+type cacheKey struct {
+	GitRepo        string             `json:"git_repo"`
+	Version        string             `json:"version"`
+	GoVersion      string             `json:"go_version"`
+	LibwasmVersion string             `json:"libwasm_version"`
+	Platform       string             `json:"platform"`
+	Recipe         *types.BuildRecipe `json:"recipe,omitempty"`
+}
+
+// getCacheDir returns the content-addressed directory of the binary in
+// the build cache under "$HOME/.ksync/binaries/<hash>"
+func getCacheDir(gitRepo, version, goVersion, libwasmVersion string, recipe *types.BuildRecipe) (string, cacheKey, error) {
+	key := cacheKey{
+		GitRepo:        gitRepo,
+		Version:        version,
+		GoVersion:      goVersion,
+		LibwasmVersion: libwasmVersion,
+		Platform:       fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+		Recipe:         recipe,
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		return "", key, fmt.Errorf("failed to marshal cache key: %w", err)
+	}
+
+	cachePath, err := utils.GetKsyncDir("binaries")
+	if err != nil {
+		return "", key, err
+	}
+
+	return filepath.Join(cachePath, utils.CreateSha256Checksum(data)), key, nil
+}
+
+// isCached returns true if the cache entry contains the daemon binary
+func isCached(cacheDir, daemonName string) bool {
+	info, err := os.Stat(filepath.Join(cacheDir, daemonName))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// loadFromCache copies the cached files into the output path. The files are not
+// linked, else the node or a later build writing to the output path in place would
+// also change the binaries of every other home directory using the cache entry
+func loadFromCache(cacheDir, outputPath, daemonName string) (bool, error) {
+	if !isCached(cacheDir, daemonName) {
+		return false, nil
+	}
+
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to read cache directory %s: %w", cacheDir, err)
+	}
+
+	if err := os.MkdirAll(outputPath, 0o755); err != nil {
+		return false, fmt.Errorf("failed to create directory %s: %w", outputPath, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == "key.json" {
+			continue
+		}
+
+		dst := filepath.Join(outputPath, entry.Name())
+
+		// remove existing files, they could still be links into the cache from
+		// earlier versions which would otherwise get overwritten in place
+		_ = os.Remove(dst)
+
+		if err := copyFile(filepath.Join(cacheDir, entry.Name()), dst); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// saveToCache stores the given files of the output path in the build cache, the
+// first file has to be the daemon binary. The files are written to a temporary
+// directory first which is renamed once complete so that concurrent setups never
+// see a partial cache entry
+func saveToCache(cacheDir string, key cacheKey, outputPath string, files []string) error {
+	if isCached(cacheDir, files[0]) {
+		return nil
+	}
+
+	// an incomplete entry would prevent the rename below
+	_ = os.RemoveAll(cacheDir)
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(cacheDir), "tmp-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary cache directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, file := range files {
+		if err := copyFile(filepath.Join(outputPath, file), filepath.Join(tmpDir, file)); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(key, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache key: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "key.json"), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cache key: %w", err)
+	}
+
+	if err := os.Rename(tmpDir, cacheDir); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("failed to move binaries to cache %s: %w", cacheDir, err)
+	}
+
+	return nil
+}
+
+// getBuiltFiles returns the names of the daemon binary and the
+// libwasmvm library inside the output path
+func getBuiltFiles(outputPath, daemonName string) ([]string, error) {
+	files := []string{daemonName}
+
+	libs, err := filepath.Glob(filepath.Join(outputPath, "libwasmvm*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find libwasmvm in %s: %w", outputPath, err)
+	}
+
+	for _, lib := range libs {
+		files = append(files, filepath.Base(lib))
+	}
+
+	return files, nil
+}
@@ -0,0 +1,171 @@
+package installations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/types"
+)
+
+func TestGetCacheDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	cacheDir, key, err := getCacheDir("https://github.com/osmosis-labs/osmosis", "v25.0.0", "1.22", "v1.5.2", &types.BuildRecipe{BuildCmd: "make install"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if filepath.Dir(cacheDir) != filepath.Join(home, ".ksync", "binaries") {
+		t.Fatalf("expected cache dir in %s, found %s", filepath.Join(home, ".ksync", "binaries"), cacheDir)
+	}
+
+	if key.Version != "v25.0.0" || key.Platform == "" {
+		t.Fatalf("expected key to contain version and platform, found %+v", key)
+	}
+
+	tests := []struct {
+		name           string
+		version        string
+		goVersion      string
+		libwasmVersion string
+		recipe         *types.BuildRecipe
+		same           bool
+	}{
+		{name: "same binary", version: "v25.0.0", goVersion: "1.22", libwasmVersion: "v1.5.2", recipe: &types.BuildRecipe{BuildCmd: "make install"}, same: true},
+		{name: "other version", version: "v25.0.1", goVersion: "1.22", libwasmVersion: "v1.5.2", recipe: &types.BuildRecipe{BuildCmd: "make install"}},
+		{name: "other go version", version: "v25.0.0", goVersion: "1.21", libwasmVersion: "v1.5.2", recipe: &types.BuildRecipe{BuildCmd: "make install"}},
+		{name: "other libwasm version", version: "v25.0.0", goVersion: "1.22", libwasmVersion: "v1.5.3", recipe: &types.BuildRecipe{BuildCmd: "make install"}},
+		{name: "other recipe", version: "v25.0.0", goVersion: "1.22", libwasmVersion: "v1.5.2", recipe: &types.BuildRecipe{BuildCmd: "make build"}},
+		{name: "no recipe", version: "v25.0.0", goVersion: "1.22", libwasmVersion: "v1.5.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			otherDir, _, err := getCacheDir("https://github.com/osmosis-labs/osmosis", tt.version, tt.goVersion, tt.libwasmVersion, tt.recipe)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if (otherDir == cacheDir) != tt.same {
+				t.Fatalf("expected same cache dir to be %t, found %s and %s", tt.same, cacheDir, otherDir)
+			}
+		})
+	}
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func expectFile(t *testing.T, path, expected string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != expected {
+		t.Fatalf("expected %s to contain %q, found %q", path, expected, data)
+	}
+}
+
+func TestSaveAndLoadFromCache(t *testing.T) {
+	cacheDir := filepath.Join(t.TempDir(), "binaries", "hash")
+	if err := os.MkdirAll(filepath.Dir(cacheDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	buildPath := t.TempDir()
+	writeTestFiles(t, buildPath, map[string]string{"osmosisd": "binary", "libwasmvm.x86_64.so": "library", "go.mod": "source"})
+
+	if err := saveToCache(cacheDir, cacheKey{Version: "v25.0.0"}, buildPath, []string{"osmosisd", "libwasmvm.x86_64.so"}); err != nil {
+		t.Fatal(err)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "cosmovisor", "upgrades", "v25", "bin")
+
+	found, err := loadFromCache(cacheDir, outputPath, "osmosisd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatal("expected binary to be found in cache")
+	}
+
+	expectFile(t, filepath.Join(outputPath, "osmosisd"), "binary")
+	expectFile(t, filepath.Join(outputPath, "libwasmvm.x86_64.so"), "library")
+
+	for _, name := range []string{"key.json", "go.mod"} {
+		if _, err := os.Stat(filepath.Join(outputPath, name)); !os.IsNotExist(err) {
+			t.Fatalf("expected %s not to be loaded from cache, found %v", name, err)
+		}
+	}
+
+	info, err := os.Stat(filepath.Join(outputPath, "osmosisd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0o100 == 0 {
+		t.Fatalf("expected loaded binary to be executable, found %s", info.Mode())
+	}
+
+	// writing to the loaded binary in place must not change the cache entry
+	if err := os.WriteFile(filepath.Join(outputPath, "osmosisd"), []byte("corrupted"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	expectFile(t, filepath.Join(cacheDir, "osmosisd"), "binary")
+}
+
+func TestLoadFromCacheWithoutDaemon(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{name: "empty entry"},
+		{name: "only key", files: map[string]string{"key.json": "{}"}},
+		{name: "only library", files: map[string]string{"key.json": "{}", "libwasmvm.x86_64.so": "library"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheDir := filepath.Join(t.TempDir(), "hash")
+			if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			writeTestFiles(t, cacheDir, tt.files)
+
+			outputPath := filepath.Join(t.TempDir(), "bin")
+
+			found, err := loadFromCache(cacheDir, outputPath, "osmosisd")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if found {
+				t.Fatal("expected incomplete cache entry not to be found")
+			}
+
+			// the incomplete entry gets replaced by the next build
+			buildPath := t.TempDir()
+			writeTestFiles(t, buildPath, map[string]string{"osmosisd": "binary"})
+
+			if err := saveToCache(cacheDir, cacheKey{}, buildPath, []string{"osmosisd"}); err != nil {
+				t.Fatal(err)
+			}
+
+			expectFile(t, filepath.Join(cacheDir, "osmosisd"), "binary")
+		})
+	}
+}
+
+func TestLoadFromCacheMissingEntry(t *testing.T) {
+	found, err := loadFromCache(filepath.Join(t.TempDir(), "missing"), t.TempDir(), "osmosisd")
+	if err != nil || found {
+		t.Fatalf("expected missing cache entry not to be found, found %t (%v)", found, err)
+	}
+}
@@ -0,0 +1,390 @@
+package installations
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/KYVENetwork/ksync/app/source"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/types"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	genesisDownloadRetries  = 3
+	genesisProgressInterval = 2 * time.Second
+)
+
+// genesisSource is a location where the genesis file of a chain can be
+// obtained from. The checksum has the format "<algorithm>:<hex>" and is
+// optional, if it is not set the genesis is only verified by its content
+type genesisSource struct {
+	name     string
+	location string
+	checksum string
+	local    bool
+}
+
+// installGenesis obtains the genesis file from the first source which succeeds, verifies
+// it and writes it to the genesis path. The genesis is first written to a temporary file
+// so a truncated download never ends up as the genesis of the node
+func installGenesis(chainSchema *types.ChainSchema, genesisPath string) error {
+	sources := getGenesisSources(chainSchema)
+	if len(sources) == 0 {
+		return fmt.Errorf("no genesis source found for chain %s, please provide one with --genesis-url or --genesis-file", chainSchema.ChainId)
+	}
+
+	tmpPath := fmt.Sprintf("%s.tmp", genesisPath)
+	defer os.Remove(tmpPath)
+
+	var errs []error
+
+	for _, s := range sources {
+		retries := genesisDownloadRetries
+		if s.local {
+			retries = 1
+		}
+
+		for attempt := 1; attempt <= retries; attempt++ {
+			err := fetchGenesis(s, tmpPath)
+			if err == nil {
+				err = verifyGenesis(tmpPath, chainSchema.ChainId)
+			}
+
+			if err == nil {
+				if err := os.Rename(tmpPath, genesisPath); err != nil {
+					return fmt.Errorf("failed to move genesis to %s: %w", genesisPath, err)
+				}
+
+				sendProgress(fmt.Sprintf("verified genesis from %s", s.name))
+				return nil
+			}
+
+			sendProgress(fmt.Sprintf("failed to obtain genesis from %s (attempt %d/%d): %s", s.name, attempt, retries, err))
+			errs = append(errs, fmt.Errorf("%s: %w", s.location, err))
+
+			if attempt < retries {
+				time.Sleep(time.Duration(attempt) * 5 * time.Second)
+			}
+		}
+	}
+
+	return fmt.Errorf("failed to obtain genesis from all sources: %w", errors.Join(errs...))
+}
+
+// getGenesisSources returns the genesis sources in the order they should be
+// tried. If a local file is provided it is the only source. The checksum of
+// --genesis-checksum only belongs to the local file or the custom url, the other
+// sources can serve the genesis in a different compression
+func getGenesisSources(chainSchema *types.ChainSchema) []genesisSource {
+	if flags.GenesisFile != "" {
+		return []genesisSource{{name: "local file", location: flags.GenesisFile, checksum: flags.GenesisChecksum, local: true}}
+	}
+
+	sources := make([]genesisSource, 0)
+
+	if flags.GenesisUrl != "" {
+		sources = append(sources, newGenesisSource("custom url", flags.GenesisUrl, flags.GenesisChecksum))
+	}
+
+	if chainSchema.Codebase.Genesis.GenesisUrl != "" {
+		sources = append(sources, newGenesisSource("chain registry", chainSchema.Codebase.Genesis.GenesisUrl, ""))
+	}
+
+	if sourceInfo, err := source.NewSource(chainSchema.ChainId); err == nil {
+		if mirrorUrl, mirrorChecksum := sourceInfo.GetGenesisMirror(); mirrorUrl != "" {
+			sources = append(sources, newGenesisSource("KYVE mirror", mirrorUrl, mirrorChecksum))
+		}
+	}
+
+	return sources
+}
+
+// newGenesisSource creates a genesis source from a url which can contain the
+// checksum as "?checksum=<algorithm>:<hex>" like the binaries in the chain registry
+func newGenesisSource(name, genesisUrl, checksum string) genesisSource {
+	if downloadUrl, algorithm, value, err := parseBinaryUrl(genesisUrl); err == nil {
+		genesisUrl = downloadUrl
+
+		if checksum == "" {
+			checksum = fmt.Sprintf("%s:%s", algorithm, value)
+		}
+	}
+
+	return genesisSource{name: name, location: genesisUrl, checksum: checksum}
+}
+
+// fetchGenesis streams the genesis from the source into the given path. The
+// checksum is verified against the raw data before it gets decompressed
+func fetchGenesis(s genesisSource, outputPath string) error {
+	var raw io.Reader
+	var size int64
+
+	if s.local {
+		f, err := os.Open(s.location)
+		if err != nil {
+			return fmt.Errorf("failed to open genesis file: %w", err)
+		}
+		defer f.Close()
+
+		if info, err := f.Stat(); err == nil {
+			size = info.Size()
+		}
+
+		raw = f
+	} else {
+		response, err := http.Get(s.location)
+		if err != nil {
+			return fmt.Errorf("failed to download genesis: %w", err)
+		}
+		defer response.Body.Close()
+
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to download genesis: got status code %d", response.StatusCode)
+		}
+
+		size = response.ContentLength
+		raw = response.Body
+	}
+
+	var hasher hash.Hash
+	var algorithm, checksum string
+
+	if s.checksum != "" {
+		var found bool
+		algorithm, checksum, found = strings.Cut(s.checksum, ":")
+		if !found {
+			return fmt.Errorf("invalid genesis checksum %s, expected format \"<algorithm>:<hex>\"", s.checksum)
+		}
+
+		switch algorithm {
+		case "sha256":
+			hasher = sha256.New()
+		case "sha512":
+			hasher = sha512.New()
+		default:
+			return fmt.Errorf("unsupported checksum algorithm %s", algorithm)
+		}
+
+		raw = io.TeeReader(raw, hasher)
+	}
+
+	raw = &progressReader{reader: raw, total: size, name: s.name}
+
+	data, closeData, err := decompressGenesis(s.location, raw)
+	if err != nil {
+		return err
+	}
+	defer closeData()
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", outputPath, err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, data); err != nil {
+		return fmt.Errorf("failed to write genesis: %w", err)
+	}
+
+	if hasher != nil {
+		// read the remaining data like tar padding so the checksum covers the whole file
+		if _, err := io.Copy(io.Discard, raw); err != nil {
+			return fmt.Errorf("failed to read genesis: %w", err)
+		}
+
+		if sum := hex.EncodeToString(hasher.Sum(nil)); !strings.EqualFold(sum, checksum) {
+			return fmt.Errorf("genesis checksum mismatch, expected = %s, found = %s", checksum, sum)
+		}
+	}
+
+	return out.Close()
+}
+
+// decompressGenesis returns the plain genesis of gzip compressed or tar archived
+// genesis files. Gzip is detected by its magic bytes, tar archives by the extension
+func decompressGenesis(location string, raw io.Reader) (io.Reader, func(), error) {
+	path := strings.Split(location, "?")[0]
+	closeData := func() {}
+
+	buffered := bufio.NewReader(raw)
+	data := io.Reader(buffered)
+
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read gzip genesis: %w", err)
+		}
+
+		data = gzipReader
+		closeData = func() { gzipReader.Close() }
+	}
+
+	if strings.HasSuffix(path, ".tar") || strings.HasSuffix(path, ".tar.gz") || strings.HasSuffix(path, ".tgz") {
+		tarReader := tar.NewReader(data)
+
+		for {
+			header, err := tarReader.Next()
+			if err != nil {
+				closeData()
+				return nil, nil, fmt.Errorf("failed to find genesis in tar archive: %w", err)
+			}
+
+			if header.Typeflag == tar.TypeReg && strings.HasSuffix(header.Name, ".json") {
+				return tarReader, closeData, nil
+			}
+		}
+	}
+
+	return data, closeData, nil
+}
+
+// verifyGenesis walks through the entire genesis to detect truncated or otherwise
+// invalid files and checks that the chain id and the initial height are valid
+func verifyGenesis(genesisPath, chainId string) error {
+	f, err := os.Open(genesisPath)
+	if err != nil {
+		return fmt.Errorf("failed to open genesis: %w", err)
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	decoder.UseNumber()
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("genesis is not a json object")
+	}
+
+	genesisChainId := ""
+	initialHeight := int64(1)
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read genesis, the file might be truncated: %w", err)
+		}
+
+		switch token {
+		case "chain_id":
+			if err := decoder.Decode(&genesisChainId); err != nil {
+				return fmt.Errorf("failed to read chain id from genesis: %w", err)
+			}
+		case "initial_height":
+			var value interface{}
+			if err := decoder.Decode(&value); err != nil {
+				return fmt.Errorf("failed to read initial height from genesis: %w", err)
+			}
+
+			initialHeight, err = strconv.ParseInt(fmt.Sprintf("%v", value), 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to parse initial height %v from genesis: %w", value, err)
+			}
+		default:
+			if err := skipJsonValue(decoder); err != nil {
+				return fmt.Errorf("failed to read genesis, the file might be truncated: %w", err)
+			}
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return fmt.Errorf("failed to read genesis, the file might be truncated: %w", err)
+	}
+
+	if genesisChainId != chainId {
+		return fmt.Errorf("genesis chain id %s does not match expected chain id %s", genesisChainId, chainId)
+	}
+
+	if initialHeight < 1 {
+		return fmt.Errorf("invalid initial height %d in genesis", initialHeight)
+	}
+
+	return nil
+}
+
+// skipJsonValue reads the next value of the decoder without keeping it in memory
+func skipJsonValue(decoder *json.Decoder) error {
+	depth := 0
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// progressReader reports the progress of the genesis download to the installation program
+type progressReader struct {
+	reader   io.Reader
+	total    int64
+	read     int64
+	name     string
+	lastSent time.Time
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+
+	if time.Since(r.lastSent) >= genesisProgressInterval || err == io.EOF {
+		r.lastSent = time.Now()
+
+		if r.total > 0 {
+			sendProgress(fmt.Sprintf("loading genesis from %s: %s / %s (%.1f%%)", r.name, formatBytes(r.read), formatBytes(r.total), float64(r.read)*100/float64(r.total)))
+		} else {
+			sendProgress(fmt.Sprintf("loading genesis from %s: %s", r.name, formatBytes(r.read)))
+		}
+	}
+
+	return n, err
+}
+
+func sendProgress(msg string) {
+	if program != nil {
+		program.Send(msg)
+	}
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// genesisFilePath returns the path of the genesis file inside the node home
+func genesisFilePath(homePath string) string {
+	return filepath.Join(homePath, "config", "genesis.json")
+}
@@ -0,0 +1,323 @@
+package installations
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/types"
+)
+
+const testGenesisChecksum = "sha256:" + "aa"
+
+func newTestChainSchema(t *testing.T) *types.ChainSchema {
+	t.Helper()
+
+	registryPath := filepath.Join(t.TempDir(), "registry.yml")
+	registry := "" +
+		"test-1:\n" +
+		"  codebase:\n" +
+		"    genesis-url: https://mirror.example/genesis.json.gz\n" +
+		"    genesis-checksum: sha256:cc\n"
+
+	if err := os.WriteFile(registryPath, []byte(registry), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registryUrl, genesisFile, genesisUrl, genesisChecksum := flags.RegistryUrl, flags.GenesisFile, flags.GenesisUrl, flags.GenesisChecksum
+	t.Cleanup(func() {
+		flags.RegistryUrl, flags.GenesisFile, flags.GenesisUrl, flags.GenesisChecksum = registryUrl, genesisFile, genesisUrl, genesisChecksum
+	})
+	flags.RegistryUrl = registryPath
+
+	chainSchema := &types.ChainSchema{ChainId: "test-1"}
+	chainSchema.Codebase.Genesis.GenesisUrl = "https://registry.example/genesis.tar.gz?checksum=sha256:bb"
+
+	return chainSchema
+}
+
+func TestGetGenesisSources(t *testing.T) {
+	tests := []struct {
+		name        string
+		genesisFile string
+		genesisUrl  string
+		expected    []genesisSource
+	}{
+		{
+			name:        "local file",
+			genesisFile: "/tmp/genesis.json",
+			expected: []genesisSource{
+				{name: "local file", location: "/tmp/genesis.json", checksum: testGenesisChecksum, local: true},
+			},
+		},
+		{
+			name:       "custom url",
+			genesisUrl: "https://custom.example/genesis.json",
+			expected: []genesisSource{
+				{name: "custom url", location: "https://custom.example/genesis.json", checksum: testGenesisChecksum},
+				{name: "chain registry", location: "https://registry.example/genesis.tar.gz", checksum: "sha256:bb"},
+				{name: "KYVE mirror", location: "https://mirror.example/genesis.json.gz", checksum: "sha256:cc"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chainSchema := newTestChainSchema(t)
+			flags.GenesisFile, flags.GenesisUrl, flags.GenesisChecksum = tt.genesisFile, tt.genesisUrl, testGenesisChecksum
+
+			if sources := getGenesisSources(chainSchema); !reflect.DeepEqual(sources, tt.expected) {
+				t.Fatalf("expected sources %+v, found %+v", tt.expected, sources)
+			}
+		})
+	}
+}
+
+func testGenesis(chainId, initialHeight string) []byte {
+	return []byte(fmt.Sprintf(`{"genesis_time":"2024-06-01T00:00:00Z","chain_id":"%s","initial_height":%s,"app_state":{"bank":{"balances":[{"address":"a","coins":[]}]}}}`, chainId, initialHeight))
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// tarBytes creates a tar archive with the given files in order
+func tarBytes(t *testing.T, files ...[2]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	for _, file := range files {
+		if err := writer.WriteHeader(&tar.Header{Name: file[0], Mode: 0o644, Size: int64(len(file[1])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(file[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func sha256Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestFetchGenesis(t *testing.T) {
+	genesis := testGenesis("test-1", `"1"`)
+	gzipped := gzipBytes(t, genesis)
+	archived := tarBytes(t, [2]string{"README.md", "genesis of test-1"}, [2]string{"config/genesis.json", string(genesis)})
+	archivedGzipped := gzipBytes(t, archived)
+
+	tests := []struct {
+		name        string
+		file        string
+		data        []byte
+		checksum    string
+		expectedErr string
+	}{
+		{name: "plain genesis", file: "genesis.json", data: genesis},
+		{name: "plain genesis with checksum", file: "genesis.json", data: genesis, checksum: sha256Checksum(genesis)},
+		{name: "gzip genesis", file: "genesis.json.gz", data: gzipped},
+		{name: "gzip genesis detected by magic bytes", file: "genesis", data: gzipped},
+		{name: "checksum over the compressed file", file: "genesis.json.gz", data: gzipped, checksum: sha256Checksum(gzipped)},
+		{name: "checksum over the decompressed file", file: "genesis.json.gz", data: gzipped, checksum: sha256Checksum(genesis), expectedErr: "checksum mismatch"},
+		{name: "wrong checksum", file: "genesis.json", data: genesis, checksum: "sha256:" + strings.Repeat("00", 32), expectedErr: "checksum mismatch"},
+		{name: "unsupported checksum algorithm", file: "genesis.json", data: genesis, checksum: "md5:00", expectedErr: "unsupported checksum algorithm"},
+		{name: "invalid checksum", file: "genesis.json", data: genesis, checksum: "00", expectedErr: "invalid genesis checksum"},
+		{name: "tar archive", file: "genesis.tar", data: archived},
+		{name: "tar gz archive", file: "genesis.tar.gz", data: archivedGzipped, checksum: sha256Checksum(archivedGzipped)},
+		{name: "tgz archive", file: "genesis.tgz", data: archivedGzipped},
+		{name: "tar archive without genesis", file: "genesis.tar", data: tarBytes(t, [2]string{"README.md", "no genesis"}), expectedErr: "failed to find genesis in tar archive"},
+	}
+
+	for _, tt := range tests {
+		for _, local := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s local=%t", tt.name, local), func(t *testing.T) {
+				dir := t.TempDir()
+
+				s := genesisSource{name: tt.name, checksum: tt.checksum, local: local}
+
+				if local {
+					s.location = filepath.Join(dir, tt.file)
+					if err := os.WriteFile(s.location, tt.data, 0o644); err != nil {
+						t.Fatal(err)
+					}
+				} else {
+					server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						_, _ = w.Write(tt.data)
+					}))
+					defer server.Close()
+
+					s.location = fmt.Sprintf("%s/%s?version=1", server.URL, tt.file)
+				}
+
+				outputPath := filepath.Join(dir, "genesis.json.tmp")
+				err := fetchGenesis(s, outputPath)
+
+				if tt.expectedErr != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+						t.Fatalf("expected error %q, found %v", tt.expectedErr, err)
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				data, err := os.ReadFile(outputPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(data, genesis) {
+					t.Fatalf("expected plain genesis, found %q", data)
+				}
+			})
+		}
+	}
+}
+
+func TestFetchGenesisServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := fetchGenesis(genesisSource{name: "custom url", location: server.URL + "/genesis.json"}, filepath.Join(t.TempDir(), "genesis.json"))
+	if err == nil || !strings.Contains(err.Error(), "status code 404") {
+		t.Fatalf("expected status code error, found %v", err)
+	}
+}
+
+func TestVerifyGenesis(t *testing.T) {
+	genesis := testGenesis("test-1", `"1"`)
+
+	tests := []struct {
+		name        string
+		data        []byte
+		expectedErr string
+	}{
+		{name: "valid genesis", data: genesis},
+		{name: "initial height as number", data: testGenesis("test-1", "5")},
+		{name: "initial height as string", data: testGenesis("test-1", `"5"`)},
+		{name: "without initial height", data: []byte(`{"chain_id":"test-1","app_state":{}}`)},
+		{name: "truncated in a value", data: genesis[:len(genesis)-20], expectedErr: "truncated"},
+		{name: "truncated at the end", data: genesis[:len(genesis)-1], expectedErr: "truncated"},
+		{name: "empty file", data: []byte{}, expectedErr: "not a json object"},
+		{name: "json array", data: []byte(`[]`), expectedErr: "not a json object"},
+		{name: "wrong chain id", data: testGenesis("test-2", `"1"`), expectedErr: "genesis chain id test-2 does not match expected chain id test-1"},
+		{name: "missing chain id", data: []byte(`{"initial_height":"1"}`), expectedErr: "does not match expected chain id"},
+		{name: "zero initial height", data: testGenesis("test-1", `"0"`), expectedErr: "invalid initial height 0"},
+		{name: "invalid initial height", data: testGenesis("test-1", `"one"`), expectedErr: "failed to parse initial height"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			genesisPath := filepath.Join(t.TempDir(), "genesis.json")
+			if err := os.WriteFile(genesisPath, tt.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := verifyGenesis(genesisPath, "test-1")
+
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("expected error %q, found %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestInstallGenesisFromFile(t *testing.T) {
+	genesis := testGenesis("test-1", `"1"`)
+
+	tests := []struct {
+		name        string
+		file        string
+		data        []byte
+		expectedErr string
+	}{
+		{name: "gzip genesis", file: "genesis.json.gz", data: gzipBytes(t, genesis)},
+		{name: "tar genesis", file: "genesis.tar", data: tarBytes(t, [2]string{"genesis.json", string(genesis)})},
+		{name: "truncated genesis", file: "genesis.json", data: genesis[:len(genesis)/2], expectedErr: "truncated"},
+		{name: "genesis of another chain", file: "genesis.json", data: testGenesis("test-2", `"1"`), expectedErr: "does not match expected chain id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chainSchema := newTestChainSchema(t)
+			dir := t.TempDir()
+
+			flags.GenesisFile, flags.GenesisUrl, flags.GenesisChecksum = filepath.Join(dir, tt.file), "", ""
+			if err := os.WriteFile(flags.GenesisFile, tt.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			genesisPath := filepath.Join(dir, "config", "genesis.json")
+			if err := os.MkdirAll(filepath.Dir(genesisPath), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			err := installGenesis(chainSchema, genesisPath)
+
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("expected error %q, found %v", tt.expectedErr, err)
+				}
+
+				// an invalid genesis never ends up as the genesis of the node
+				if _, err := os.Stat(genesisPath); !os.IsNotExist(err) {
+					t.Fatalf("expected no genesis to be written, found %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(genesisPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(data, genesis) {
+				t.Fatalf("expected plain genesis, found %q", data)
+			}
+		})
+	}
+}
@@ -2,20 +2,17 @@ package installations
 
 import (
 	"bytes"
-	"compress/gzip"
 	"errors"
 	"fmt"
 	"github.com/KYVENetwork/ksync/flags"
 	"github.com/KYVENetwork/ksync/types"
-	"github.com/KYVENetwork/ksync/utils"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 )
@@ -35,6 +32,8 @@ ARG TARGET_GOOS
 ARG TARGET_GOARCH
 ARG DAEMON_NAME
 ARG BUILD_CMD="build"
+ARG BUILD_ARGS
+ARG BUILD_ENV
 
 ENV GOOS=$TARGET_GOOS
 ENV GOARCH=$TARGET_GOARCH
@@ -45,7 +44,8 @@ RUN apt update && apt upgrade -y
 
 RUN git clone --depth 1 --branch $VERSION $GIT_REPO repo \
     && cd repo/$SUBFOLDER \
-    && make $BUILD_CMD ENV=mainnet GO_VERSION=$GO_VERSION LAVA_BINARY=lavad \
+    && if [ -n "$BUILD_ENV" ] ; then eval "export $BUILD_ENV" ; fi \
+    && make $BUILD_CMD GO_VERSION=$GO_VERSION $BUILD_ARGS \
     && mv $BINARY_PATH /app/$DAEMON_NAME \
     && cd /app \
     && rm -r repo
@@ -68,7 +68,7 @@ var (
 )
 
 func InstallGenesisSyncBinaries(chainSchema *types.ChainSchema, upgrades []types.Upgrade) error {
-	program = tea.NewProgram(newModel(append([]types.Upgrade{{Name: "Cosmovisor", Version: "v1.7.0"}}, upgrades...)))
+	program = newProgram(newModel(append([]types.Upgrade{{Name: "Cosmovisor", Version: cosmovisorVersion}}, upgrades...)))
 
 	go func() {
 		program.Run()
@@ -77,15 +77,15 @@ func InstallGenesisSyncBinaries(chainSchema *types.ChainSchema, upgrades []types
 	homePath := strings.ReplaceAll(chainSchema.NodeHome, "$HOME", os.Getenv("HOME"))
 	genesisPath := fmt.Sprintf("%s/cosmovisor/genesis/bin", homePath)
 
-	if err := buildCosmovisor(fmt.Sprintf("%s/go/bin/", os.Getenv("HOME"))); err != nil {
+	if err := installCosmovisor(fmt.Sprintf("%s/go/bin/", os.Getenv("HOME"))); err != nil {
 		return err
 	}
 
-	if err := buildUpgradeBinary(upgrades[0], chainSchema, genesisPath); err != nil {
+	if err := installUpgradeBinary(upgrades[0], chainSchema, genesisPath); err != nil {
 		return err
 	}
 
-	if _, err := os.Stat(fmt.Sprintf("%s/config/genesis.json", homePath)); errors.Is(err, os.ErrNotExist) {
+	if _, err := os.Stat(genesisFilePath(homePath)); errors.Is(err, os.ErrNotExist) {
 		if flags.Moniker == "" {
 			flags.Moniker = "ksync"
 		}
@@ -99,34 +99,7 @@ func InstallGenesisSyncBinaries(chainSchema *types.ChainSchema, upgrades []types
 			return fmt.Errorf("failed to run chain init: %w", err)
 		}
 
-		out, err := os.Create(fmt.Sprintf("%s/config/genesis.json", homePath))
-		if err != nil {
-			program.Quit()
-			program.Wait()
-			return err
-		}
-		defer out.Close()
-
-		resp, err := http.Get(chainSchema.Codebase.Genesis.GenesisUrl)
-		if err != nil {
-			program.Quit()
-			program.Wait()
-			return err
-		}
-		defer resp.Body.Close()
-
-		data := resp.Body
-
-		if strings.HasSuffix(chainSchema.Codebase.Genesis.GenesisUrl, ".gz") {
-			data, err = gzip.NewReader(resp.Body)
-			if err != nil {
-				program.Quit()
-				program.Wait()
-				return err
-			}
-		}
-
-		if _, err := io.Copy(out, data); err != nil {
+		if err := installGenesis(chainSchema, genesisFilePath(homePath)); err != nil {
 			program.Quit()
 			program.Wait()
 			return err
@@ -152,7 +125,7 @@ func InstallGenesisSyncBinaries(chainSchema *types.ChainSchema, upgrades []types
 	for _, upgrade := range upgrades[1:] {
 		outputPath := fmt.Sprintf("%s/cosmovisor/upgrades/%s/bin", homePath, upgrade.Name)
 
-		if err := buildUpgradeBinary(upgrade, chainSchema, outputPath); err != nil {
+		if err := installUpgradeBinary(upgrade, chainSchema, outputPath); err != nil {
 			return err
 		}
 	}
@@ -164,7 +137,7 @@ func InstallGenesisSyncBinaries(chainSchema *types.ChainSchema, upgrades []types
 func InstallStateSyncBinaries(chainSchema *types.ChainSchema, upgrades []types.Upgrade) error {
 	upgrade := upgrades[len(upgrades)-1]
 
-	program = tea.NewProgram(newModel(append([]types.Upgrade{{Name: "Cosmovisor"}}, upgrade)))
+	program = newProgram(newModel(append([]types.Upgrade{{Name: "Cosmovisor"}}, upgrade)))
 
 	go func() {
 		program.Run()
@@ -173,49 +146,28 @@ func InstallStateSyncBinaries(chainSchema *types.ChainSchema, upgrades []types.U
 	homePath := strings.ReplaceAll(chainSchema.NodeHome, "$HOME", os.Getenv("HOME"))
 	binaryPath := fmt.Sprintf("%s/cosmovisor/upgrades/%s/bin", homePath, upgrade.Name)
 
-	if err := buildCosmovisor(fmt.Sprintf("%s/go/bin/", os.Getenv("HOME"))); err != nil {
+	if err := installCosmovisor(fmt.Sprintf("%s/go/bin/", os.Getenv("HOME"))); err != nil {
 		return err
 	}
 
-	if err := buildUpgradeBinary(upgrade, chainSchema, binaryPath); err != nil {
+	if err := installUpgradeBinary(upgrade, chainSchema, binaryPath); err != nil {
 		return err
 	}
 
-	if _, err := os.Stat(fmt.Sprintf("%s/config/genesis.json", homePath)); errors.Is(err, os.ErrNotExist) {
+	if _, err := os.Stat(genesisFilePath(homePath)); errors.Is(err, os.ErrNotExist) {
 		moniker := flags.Moniker
 		if moniker == "" {
 			moniker = "ksync"
 		}
 
-		cmd := exec.Command(fmt.Sprintf("%s/%s", binaryPath, chainSchema.DaemonName), "init", flags.Moniker, "--chain-id", chainSchema.ChainId)
+		cmd := exec.Command(fmt.Sprintf("%s/%s", binaryPath, chainSchema.DaemonName), "init", moniker, "--chain-id", chainSchema.ChainId)
 		cmd.Env = append(os.Environ(), fmt.Sprintf("LD_LIBRARY_PATH=%s", binaryPath))
 
 		if err := cmd.Run(); err != nil {
 			return fmt.Errorf("failed to run chain init: %w", err)
 		}
 
-		out, err := os.Create(fmt.Sprintf("%s/config/genesis.json", homePath))
-		if err != nil {
-			return err
-		}
-		defer out.Close()
-
-		resp, err := http.Get(chainSchema.Codebase.Genesis.GenesisUrl)
-		if err != nil {
-			return err
-		}
-		defer resp.Body.Close()
-
-		data := resp.Body
-
-		if strings.HasSuffix(chainSchema.Codebase.Genesis.GenesisUrl, ".gz") {
-			data, err = gzip.NewReader(resp.Body)
-			if err != nil {
-				return err
-			}
-		}
-
-		if _, err := io.Copy(out, data); err != nil {
+		if err := installGenesis(chainSchema, genesisFilePath(homePath)); err != nil {
 			return err
 		}
 	}
@@ -230,6 +182,16 @@ func InstallStateSyncBinaries(chainSchema *types.ChainSchema, upgrades []types.U
 	return nil
 }
 
+// newProgram creates the program showing the installation progress. In non-interactive
+// mode we do not read from stdin since there might be no terminal attached
+func newProgram(m model) *tea.Program {
+	if flags.NonInteractive {
+		return tea.NewProgram(m, tea.WithInput(nil))
+	}
+
+	return tea.NewProgram(m)
+}
+
 func buildCosmovisor(outputPath string) error {
 	cmd := exec.Command("docker", "build")
 
@@ -241,11 +203,11 @@ func buildCosmovisor(outputPath string) error {
 		cmd.Args = append(cmd.Args, "--platform", fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH))
 	}
 
-	cmd.Args = append(cmd.Args, "--build-arg", "BASE_IMAGE=golang:1.23")
-	cmd.Args = append(cmd.Args, "--build-arg", "VERSION=cosmovisor/v1.7.0")
-	cmd.Args = append(cmd.Args, "--build-arg", "GIT_REPO=https://github.com/cosmos/cosmos-sdk")
+	cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("BASE_IMAGE=golang:%s", cosmovisorGoVersion))
+	cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("VERSION=cosmovisor/%s", cosmovisorVersion))
+	cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("GIT_REPO=%s", cosmovisorRepo))
 	cmd.Args = append(cmd.Args, "--build-arg", "BINARY_PATH=cosmovisor")
-	cmd.Args = append(cmd.Args, "--build-arg", "GO_VERSION=1.23")
+	cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("GO_VERSION=%s", cosmovisorGoVersion))
 	cmd.Args = append(cmd.Args, "--build-arg", "SUBFOLDER=tools/cosmovisor")
 	cmd.Args = append(cmd.Args, "--build-arg", "BUILD_CMD=cosmovisor")
 	cmd.Args = append(cmd.Args, "--build-arg", "DAEMON_NAME=cosmovisor")
@@ -274,7 +236,7 @@ func buildCosmovisor(outputPath string) error {
 
 	program.Send(types.Upgrade{
 		Name:            "Cosmovisor",
-		Version:         "v1.7.0",
+		Version:         cosmovisorVersion,
 		InstallDuration: time.Since(start),
 	})
 
@@ -285,18 +247,15 @@ func buildUpgradeBinary(upgrade types.Upgrade, chainSchema *types.ChainSchema, o
 	libwasmPath := ""
 
 	if upgrade.LibwasmVersion != "" {
-		libwasmPath = fmt.Sprintf("/go/pkg/mod/github.com/!cosm!wasm/wasmvm@%s/internal/api/libwasmvm.x86_64.so", upgrade.LibwasmVersion)
-
-		// before wasmvm v1.1.0 there was no "internal" folder yet
-		libwasmVersions := strings.Split(upgrade.LibwasmVersion, ".")
-		if libwasmVersions[0] == "v1" && libwasmVersions[1] == "0" {
-			libwasmPath = fmt.Sprintf("/go/pkg/mod/github.com/!cosm!wasm/wasmvm@%s/api/libwasmvm.x86_64.so", upgrade.LibwasmVersion)
+		// on macOS the binaries are built for linux/amd64
+		goarch := runtime.GOARCH
+		if runtime.GOOS == "darwin" {
+			goarch = "amd64"
+		}
 
-			if strings.Contains(upgrade.LibwasmVersion, "beta") {
-				libwasmPath = fmt.Sprintf("/go/pkg/mod/github.com/!cosm!wasm/wasmvm@%s/api/libwasmvm.so", upgrade.LibwasmVersion)
-			}
-		} else if libwasmVersions[0] == "v2" {
-			libwasmPath = fmt.Sprintf("/go/pkg/mod/github.com/!cosm!wasm/wasmvm/v2@%s/internal/api/libwasmvm.x86_64.so", upgrade.LibwasmVersion)
+		var err error
+		if libwasmPath, err = getLibwasmPath("/go/pkg/mod", goarch, upgrade); err != nil {
+			return err
 		}
 	}
 
@@ -316,21 +275,31 @@ func buildUpgradeBinary(upgrade types.Upgrade, chainSchema *types.ChainSchema, o
 	cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("GO_VERSION=%s", upgrade.GoVersion))
 	cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("DAEMON_NAME=%s", chainSchema.DaemonName))
 
-	exceptions := utils.Exceptions[chainSchema.ChainId]
+	recipe := upgrade.Recipe
 
-	if exceptions.Subfolder != "" {
-		cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("SUBFOLDER=%s", exceptions.Subfolder))
+	if recipe.Subfolder != "" {
+		cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("SUBFOLDER=%s", recipe.Subfolder))
 	}
 
 	binaryPath := fmt.Sprintf("build/%s", chainSchema.DaemonName)
-	if exceptions.BinaryPath != "" {
-		binaryPath = exceptions.BinaryPath
+	if recipe.BinaryPath != "" {
+		binaryPath = recipe.BinaryPath
 	}
 
 	cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("BINARY_PATH=%s", binaryPath))
+	cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("BUILD_CMD=%s", recipe.BuildCmd))
+
+	if len(recipe.BuildArgs) > 0 {
+		cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("BUILD_ARGS=%s", strings.Join(recipe.BuildArgs, " ")))
+	}
+
+	if len(recipe.Env) > 0 {
+		buildEnv := make([]string, 0, len(recipe.Env))
+		for _, key := range getSortedKeys(recipe.Env) {
+			buildEnv = append(buildEnv, fmt.Sprintf("%s='%s'", key, strings.ReplaceAll(recipe.Env[key], "'", `'\''`)))
+		}
 
-	if exceptions.BuildCmd != "" {
-		cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("BUILD_CMD=%s", exceptions.BuildCmd))
+		cmd.Args = append(cmd.Args, "--build-arg", fmt.Sprintf("BUILD_ENV=%s", strings.Join(buildEnv, " ")))
 	}
 
 	if libwasmPath != "" {
@@ -365,6 +334,60 @@ func buildUpgradeBinary(upgrade types.Upgrade, chainSchema *types.ChainSchema, o
 	return nil
 }
 
+// getLibwasmName returns the name of the pre-built libwasmvm shared library for the architecture
+func getLibwasmName(goarch string) (string, error) {
+	switch goarch {
+	case "amd64":
+		return "libwasmvm.x86_64.so", nil
+	case "arm64":
+		return "libwasmvm.aarch64.so", nil
+	default:
+		return "", fmt.Errorf("no pre-built libwasmvm available for architecture %s", goarch)
+	}
+}
+
+// getLibwasmPath returns the path of the libwasmvm shared library of the
+// upgrade inside the go module cache, unless the build recipe defines it
+func getLibwasmPath(modCachePath, goarch string, upgrade types.Upgrade) (string, error) {
+	libwasmVersion := upgrade.LibwasmVersion
+
+	if upgrade.Recipe.LibwasmPath != "" {
+		return strings.NewReplacer("{modcache}", modCachePath, "{version}", libwasmVersion).Replace(upgrade.Recipe.LibwasmPath), nil
+	}
+
+	libName, err := getLibwasmName(goarch)
+	if err != nil {
+		return "", err
+	}
+
+	libwasmPath := fmt.Sprintf("%s/github.com/!cosm!wasm/wasmvm@%s/internal/api/%s", modCachePath, libwasmVersion, libName)
+
+	// before wasmvm v1.1.0 there was no "internal" folder yet
+	libwasmVersions := strings.Split(libwasmVersion, ".")
+	if libwasmVersions[0] == "v1" && libwasmVersions[1] == "0" {
+		libwasmPath = fmt.Sprintf("%s/github.com/!cosm!wasm/wasmvm@%s/api/%s", modCachePath, libwasmVersion, libName)
+
+		if strings.Contains(libwasmVersion, "beta") {
+			libwasmPath = fmt.Sprintf("%s/github.com/!cosm!wasm/wasmvm@%s/api/libwasmvm.so", modCachePath, libwasmVersion)
+		}
+	} else if libwasmVersions[0] == "v2" {
+		libwasmPath = fmt.Sprintf("%s/github.com/!cosm!wasm/wasmvm/v2@%s/internal/api/%s", modCachePath, libwasmVersion, libName)
+	}
+
+	return libwasmPath, nil
+}
+
+// getSortedKeys returns the keys of the map in a deterministic order
+func getSortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
 type CmdWriter struct{}
 
 func (w *CmdWriter) Write(p []byte) (n int, err error) {
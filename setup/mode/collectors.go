@@ -58,7 +58,7 @@ func FetchUpgrades(chainSchema *types.ChainSchema) ([]types.Upgrade, error) {
 	upgrades := make([]types.Upgrade, 0)
 
 	for index, version := range versionsResponse.Versions {
-		upgrade := types.Upgrade{Version: version.Tag}
+		upgrade := types.Upgrade{Version: version.Tag, Binaries: version.Binaries}
 
 		if upgrade.Version == "" {
 			upgrade.Version = version.RecommendedVersion
@@ -70,11 +70,16 @@ func FetchUpgrades(chainSchema *types.ChainSchema) ([]types.Upgrade, error) {
 			upgrade.Name = version.Name
 		}
 
+		upgrade.Recipe, err = utils.GetBuildRecipe(chainSchema.ChainId, upgrade)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get build recipe for upgrade %s: %w", upgrade.Name, err)
+		}
+
 		repo := strings.ReplaceAll(chainSchema.Codebase.GitRepoUrl, "https://github.com/", "https://raw.githubusercontent.com/")
 
 		goModUrl := fmt.Sprintf("%s/refs/tags/%s/go.mod", repo, upgrade.Version)
-		if utils.Exceptions[chainSchema.ChainId].Subfolder != "" {
-			goModUrl = fmt.Sprintf("%s/refs/tags/%s/%s/go.mod", repo, upgrade.Version, utils.Exceptions[chainSchema.ChainId].Subfolder)
+		if upgrade.Recipe.Subfolder != "" {
+			goModUrl = fmt.Sprintf("%s/refs/tags/%s/%s/go.mod", repo, upgrade.Version, upgrade.Recipe.Subfolder)
 		}
 
 		result, err = utils.GetFromUrlWithErr(goModUrl)
@@ -110,6 +115,14 @@ func FetchUpgrades(chainSchema *types.ChainSchema) ([]types.Upgrade, error) {
 			}
 		}
 
+		if upgrade.Recipe.GoVersion != "" {
+			upgrade.GoVersion = upgrade.Recipe.GoVersion
+		}
+
+		if upgrade.Recipe.LibwasmVersion != "" {
+			upgrade.LibwasmVersion = upgrade.Recipe.LibwasmVersion
+		}
+
 		upgrades = append(upgrades, upgrade)
 	}
 
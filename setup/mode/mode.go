@@ -11,6 +11,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -25,30 +26,60 @@ var (
 	setupMode         int
 )
 
+const (
+	ModeExit = iota
+	ModeInstall
+	ModeStateSync
+	ModeBlockSync
+)
+
+// ParseSetupMode parses the setup mode provided with --mode
+func ParseSetupMode(name string) (int, error) {
+	switch name {
+	case "install":
+		return ModeInstall, nil
+	case "state-sync":
+		return ModeStateSync, nil
+	case "block-sync":
+		return ModeBlockSync, nil
+	default:
+		return 0, fmt.Errorf("setup mode has to be either \"install\", \"state-sync\" or \"block-sync\", instead found \"%s\"", name)
+	}
+}
+
 func SelectSetupMode() (*types.ChainSchema, []types.Upgrade, int, error) {
-	p := tea.NewProgram(newModel())
-	go func() {
-		p.Run()
-	}()
+	// we only show the selection if the setup mode was not already
+	// provided with the flags
+	var p *tea.Program
+	if flags.SetupMode == "" {
+		p = tea.NewProgram(newModel())
+		go func() {
+			p.Run()
+		}()
+	}
+
+	quit := func() {
+		if p != nil {
+			p.Quit()
+			p.Wait()
+		}
+	}
 
 	chainSchema, err := FetchChainSchema()
 	if err != nil {
-		p.Quit()
-		p.Wait()
+		quit()
 		return nil, nil, 0, err
 	}
 
 	sourceInfo, err := source.NewSource(chainSchema.ChainId)
 	if err != nil {
-		p.Quit()
-		p.Wait()
+		quit()
 		return nil, nil, 0, err
 	}
 
 	upgrades, err := FetchUpgrades(chainSchema)
 	if err != nil {
-		p.Quit()
-		p.Wait()
+		quit()
 		return nil, nil, 0, err
 	}
 
@@ -60,50 +91,58 @@ func SelectSetupMode() (*types.ChainSchema, []types.Upgrade, int, error) {
 	}
 
 	if runtime.GOOS == "darwin" && !canRunDarwin {
-		p.Quit()
-		p.Wait()
+		quit()
 		return nil, nil, 0, fmt.Errorf("chain binaries contain cosmwasm, unable to cross-compile for darwin")
 	}
 
 	modes := []string{"1. Install binary with Cosmovisor from source"}
 
-	chainRest, err := func() (string, error) {
-		if flags.ChainRest != "" {
-			return strings.TrimSuffix(flags.ChainRest, "/"), nil
-		}
-
-		switch flags.ChainId {
-		case utils.ChainIdMainnet:
-			return utils.RestEndpointMainnet, nil
-		case utils.ChainIdKaon:
-			return utils.RestEndpointKaon, nil
-		case utils.ChainIdKorellia:
-			return utils.RestEndpointKorellia, nil
-		default:
-			return "", fmt.Errorf("flag --chain-id has to be either \"%s\", \"%s\" or \"%s\"", utils.ChainIdMainnet, utils.ChainIdKaon, utils.ChainIdKorellia)
-		}
-	}()
+	chainRest, err := utils.GetChainRest()
 	if err != nil {
-		p.Quit()
-		p.Wait()
+		quit()
 		return nil, nil, 0, err
 	}
 
+	canStateSync, canBlockSync := false, false
+
 	if poolId, err := sourceInfo.GetSourceSnapshotPoolId(); err == nil {
 		snapshotCollector, err := collector.NewKyveSnapshotCollector(poolId, chainRest)
 		if err != nil {
-			p.Quit()
-			p.Wait()
+			quit()
 			return nil, nil, 0, err
 		}
-		flags.TargetHeight = snapshotCollector.GetLatestAvailableHeight()
-		modes = append(modes, fmt.Sprintf("2. Install binaries and state-sync to latest height %d", flags.TargetHeight))
+
+		// only sync to the latest available snapshot height if no target height was provided
+		if flags.TargetHeight == 0 {
+			flags.TargetHeight = snapshotCollector.GetLatestAvailableHeight()
+		}
+
+		canStateSync = true
+		modes = append(modes, fmt.Sprintf("2. Install binaries and state-sync to height %d", flags.TargetHeight))
 	}
 
 	if _, err := sourceInfo.GetSourceBlockPoolId(); err == nil {
+		canBlockSync = true
 		modes = append(modes, "3. Install binaries and block-sync from genesis to live height")
 	}
 
+	if p == nil {
+		mode, err := ParseSetupMode(flags.SetupMode)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		if mode == ModeStateSync && !canStateSync {
+			return nil, nil, 0, fmt.Errorf("state-sync is not available for %s, no snapshot pool was found", flags.Source)
+		}
+
+		if mode == ModeBlockSync && !canBlockSync {
+			return nil, nil, 0, fmt.Errorf("block-sync is not available for %s, no block pool was found", flags.Source)
+		}
+
+		return chainSchema, upgrades, mode, nil
+	}
+
 	modes = append(modes, fmt.Sprintf("%d. Exit", len(modes)+1))
 
 	height, err := FetchLatestHeight(chainSchema)
@@ -162,9 +201,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			if len(m.modes) > 0 {
 				if strings.Contains(m.modes[m.cursor], "Exit") {
-					setupMode = 0
+					setupMode = ModeExit
 				} else {
-					setupMode = m.cursor + 1
+					// the modes are prefixed with their number, we can not use the cursor
+					// directly since the state-sync mode is not available for every chain
+					setupMode, _ = strconv.Atoi(strings.SplitN(m.modes[m.cursor], ".", 2)[0])
 				}
 
 				m.quitting = true
@@ -201,11 +242,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) View() string {
 	if m.quitting {
-		if setupMode == 1 {
+		if setupMode == ModeInstall {
 			return fmt.Sprintf("%s Selected binary installation\n", checkMark)
-		} else if setupMode == 2 {
-			return fmt.Sprintf("%s Selected binary installation with state-sync to latest height\n", checkMark)
-		} else if setupMode == 3 {
+		} else if setupMode == ModeStateSync {
+			return fmt.Sprintf("%s Selected binary installation with state-sync to height %d\n", checkMark, flags.TargetHeight)
+		} else if setupMode == ModeBlockSync {
 			return fmt.Sprintf("%s Selected binary installation with block-sync from genesis to live height\n", checkMark)
 		} else {
 			return fmt.Sprintf("%s Selected exit\n", errorMark)
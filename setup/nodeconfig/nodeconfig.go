@@ -0,0 +1,382 @@
+package nodeconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// File is a TOML file like config.toml or app.toml of a node. Only the values
+// which get updated are rewritten, so comments and the layout of the file stay
+// the same. Keys are addressed with "<table>.<key>", root keys without a table
+type File struct {
+	path  string
+	lines []string
+}
+
+type entry struct {
+	table   string
+	name    string
+	start   int
+	end     int
+	indent  string
+	value   string
+	comment string
+}
+
+func ConfigPath(homePath string) string {
+	return filepath.Join(homePath, "config", "config.toml")
+}
+
+func AppPath(homePath string) string {
+	return filepath.Join(homePath, "config", "app.toml")
+}
+
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return &File{
+		path:  path,
+		lines: strings.Split(string(data), "\n"),
+	}, nil
+}
+
+func (f *File) Path() string {
+	return f.path
+}
+
+// Has returns true if the key is defined in the file
+func (f *File) Has(key string) bool {
+	_, found := f.find(key)
+	return found
+}
+
+// Get returns the raw TOML value of the key, strings are still quoted
+func (f *File) Get(key string) (string, bool) {
+	e, found := f.find(key)
+	if !found {
+		return "", false
+	}
+
+	return e.value, true
+}
+
+// Set updates the value of the key or adds it to the end of its table if it does not
+// exist yet. Supported values are strings, booleans, integers, floats and string slices
+func (f *File) Set(key string, value interface{}) error {
+	formatted, err := formatValue(value)
+	if err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+
+	f.setRaw(key, formatted)
+	return nil
+}
+
+// SetFromString sets a value given as text, e.g. from the command line. The type is
+// derived from the current value of the key, if the key does not exist yet booleans
+// and numbers are written as they are and everything else as a string
+func (f *File) SetFromString(key, value string) error {
+	current, found := f.Get(key)
+
+	switch {
+	case found && (strings.HasPrefix(current, "\"") || strings.HasPrefix(current, "'")):
+		return f.Set(key, value)
+	case found && strings.HasPrefix(current, "[") && strings.HasPrefix(value, "["):
+		f.setRaw(key, value)
+		return nil
+	case isBool(value) || isNumber(value):
+		f.setRaw(key, value)
+		return nil
+	case found && (isBool(current) || isNumber(current)):
+		return fmt.Errorf("failed to set %s: expected a value of the same type as %s, found %s", key, current, value)
+	default:
+		return f.Set(key, value)
+	}
+}
+
+// Save writes the file atomically with its original permissions
+func (f *File) Save() error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(f.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmpPath := fmt.Sprintf("%s.tmp", f.path)
+	if err := os.WriteFile(tmpPath, []byte(strings.Join(f.lines, "\n")), mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmpPath, err)
+	}
+
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		return fmt.Errorf("failed to move %s to %s: %w", tmpPath, f.path, err)
+	}
+
+	return nil
+}
+
+func (f *File) setRaw(key, value string) {
+	table, name := splitKey(key)
+
+	if e, found := f.find(key); found {
+		line := fmt.Sprintf("%s%s = %s", e.indent, e.name, value)
+		if e.comment != "" {
+			line = fmt.Sprintf("%s %s", line, e.comment)
+		}
+
+		f.replaceLines(e.start, e.end, line)
+		return
+	}
+
+	line := fmt.Sprintf("%s = %s", name, value)
+
+	start, end, found := f.findTable(table)
+	if !found {
+		if f.lines[len(f.lines)-1] == "" {
+			f.lines = append(f.lines[:len(f.lines)-1], "", fmt.Sprintf("[%s]", table), line, "")
+		} else {
+			f.lines = append(f.lines, "", fmt.Sprintf("[%s]", table), line)
+		}
+		return
+	}
+
+	// insert the key after the last value of the table so we do not
+	// end up in between the comments describing the next table
+	insert, indent := start, ""
+	for i := start; i < end; i++ {
+		if e, ok := parseEntry(f.lines, i); ok {
+			insert, indent = e.end, e.indent
+			i = e.end - 1
+		}
+	}
+
+	f.replaceLines(insert, insert, indent+line)
+}
+
+// replaceLines replaces the lines from start to end (exclusive) with the given line
+func (f *File) replaceLines(start, end int, line string) {
+	lines := make([]string, 0, len(f.lines)+1)
+	lines = append(lines, f.lines[:start]...)
+	lines = append(lines, line)
+	lines = append(lines, f.lines[end:]...)
+	f.lines = lines
+}
+
+// findTable returns the range of lines belonging to the table. For the root table
+// the range ends before the first table header
+func (f *File) findTable(table string) (int, int, bool) {
+	start, found := 0, table == ""
+
+	for i := 0; i < len(f.lines); i++ {
+		header, isHeader := parseHeader(f.lines[i])
+		if !isHeader {
+			// lines of values spanning multiple lines can start with "[" as well
+			if e, ok := parseEntry(f.lines, i); ok {
+				i = e.end - 1
+			}
+			continue
+		}
+
+		if found {
+			return start, i, true
+		}
+
+		if header == table {
+			start, found = i+1, true
+		}
+	}
+
+	return start, len(f.lines), found
+}
+
+func (f *File) find(key string) (entry, bool) {
+	table, name := splitKey(key)
+	currentTable := ""
+
+	for i := 0; i < len(f.lines); i++ {
+		if header, isHeader := parseHeader(f.lines[i]); isHeader {
+			currentTable = header
+			continue
+		}
+
+		e, ok := parseEntry(f.lines, i)
+		if !ok {
+			continue
+		}
+
+		if currentTable == table && e.name == name {
+			e.table = currentTable
+			return e, true
+		}
+
+		// skip the remaining lines of values spanning multiple lines
+		i = e.end - 1
+	}
+
+	return entry{}, false
+}
+
+// splitKey splits the key into table and name. Table names can contain dots
+// themselves, therefore only the last dot separates the name
+func splitKey(key string) (string, string) {
+	if index := strings.LastIndex(key, "."); index >= 0 {
+		return key[:index], key[index+1:]
+	}
+
+	return "", key
+}
+
+func parseHeader(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "[") {
+		return "", false
+	}
+
+	if index := strings.Index(trimmed, "#"); index >= 0 {
+		trimmed = strings.TrimSpace(trimmed[:index])
+	}
+
+	trimmed = strings.TrimPrefix(strings.TrimSuffix(trimmed, "]"), "[")
+	trimmed = strings.TrimPrefix(strings.TrimSuffix(trimmed, "]"), "[")
+
+	return strings.TrimSpace(trimmed), true
+}
+
+// parseEntry parses the key value pair starting at the given line. Arrays
+// can span multiple lines, end is the first line after the entry
+func parseEntry(lines []string, start int) (entry, bool) {
+	line := lines[start]
+	trimmed := strings.TrimSpace(line)
+
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return entry{}, false
+	}
+
+	name, rest, found := strings.Cut(trimmed, "=")
+	if !found {
+		return entry{}, false
+	}
+
+	e := entry{
+		name:   strings.Trim(strings.TrimSpace(name), "\"'"),
+		start:  start,
+		end:    start + 1,
+		indent: line[:len(line)-len(strings.TrimLeft(line, " \t"))],
+	}
+
+	e.value, e.comment = splitComment(strings.TrimSpace(rest))
+
+	if strings.HasPrefix(e.value, "[") {
+		depth := bracketDepth(e.value)
+		for depth > 0 && e.end < len(lines) {
+			value, comment := splitComment(strings.TrimSpace(lines[e.end]))
+			e.value = fmt.Sprintf("%s %s", e.value, value)
+			e.comment = comment
+			depth += bracketDepth(value)
+			e.end++
+		}
+	}
+
+	return e, true
+}
+
+// splitComment splits a raw value from its inline comment while
+// ignoring "#" characters inside of strings
+func splitComment(raw string) (string, string) {
+	var quote rune
+	escaped := false
+
+	for i, c := range raw {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '#':
+			return strings.TrimSpace(raw[:i]), raw[i:]
+		}
+	}
+
+	return raw, ""
+}
+
+func bracketDepth(value string) int {
+	value, _ = splitComment(value)
+	depth := 0
+	var quote rune
+	escaped := false
+
+	for _, c := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		case quote == 0 && c == '[':
+			depth++
+		case quote == 0 && c == ']':
+			depth--
+		}
+	}
+
+	return depth
+}
+
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return quote(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []string:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, quote(item))
+		}
+		return fmt.Sprintf("[%s]", strings.Join(items, ", ")), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+func quote(value string) string {
+	value = strings.ReplaceAll(value, "\\", "\\\\")
+	value = strings.ReplaceAll(value, "\"", "\\\"")
+	return fmt.Sprintf("\"%s\"", value)
+}
+
+func isBool(value string) bool {
+	return value == "true" || value == "false"
+}
+
+// isNumber returns true for integers and floats, special float values
+// like "inf" or "nan" are not considered to be numbers
+func isNumber(value string) bool {
+	digits := strings.TrimLeft(value, "+-")
+	if digits == "" || digits[0] < '0' || digits[0] > '9' {
+		return false
+	}
+
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
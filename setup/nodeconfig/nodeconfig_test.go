@@ -0,0 +1,222 @@
+package nodeconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testConfig = `# root comment
+moniker = "node"
+
+[mempool]
+size = 5000
+nested = [
+  ["a", "b"],
+  ["c"],
+]
+sinks = [
+  { name = "a", tags = ["x", "y"] },
+  { name = "b]" },
+]
+labels = { chain = "test-1", tags = [["a", "b"]] } # inline table
+escaped = ["a\"]", "b"]
+
+# comment of the p2p table
+[p2p]
+seeds = ""
+`
+
+func loadTestConfig(t *testing.T) *File {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{key: "moniker", expected: `"node"`},
+		{key: "mempool.size", expected: "5000"},
+		{key: "mempool.nested", expected: `[ ["a", "b"], ["c"], ]`},
+		{key: "mempool.sinks", expected: `[ { name = "a", tags = ["x", "y"] }, { name = "b]" }, ]`},
+		{key: "mempool.labels", expected: `{ chain = "test-1", tags = [["a", "b"]] }`},
+		{key: "mempool.escaped", expected: `["a\"]", "b"]`},
+		{key: "p2p.seeds", expected: `""`},
+	}
+
+	f := loadTestConfig(t)
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, found := f.Get(tt.key)
+			if !found {
+				t.Fatalf("expected %s to be found", tt.key)
+			}
+
+			if value != tt.expected {
+				t.Fatalf("expected %s, found %s", tt.expected, value)
+			}
+		})
+	}
+
+	// keys inside of inline tables and arrays are no keys of the table
+	for _, key := range []string{"mempool.name", "mempool.chain", "a", "c"} {
+		if f.Has(key) {
+			t.Fatalf("expected %s not to be found", key)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		value    interface{}
+		expected string
+	}{
+		{
+			name:  "new key after multi-line arrays",
+			key:   "mempool.recheck",
+			value: true,
+			expected: `# root comment
+moniker = "node"
+
+[mempool]
+size = 5000
+nested = [
+  ["a", "b"],
+  ["c"],
+]
+sinks = [
+  { name = "a", tags = ["x", "y"] },
+  { name = "b]" },
+]
+labels = { chain = "test-1", tags = [["a", "b"]] } # inline table
+escaped = ["a\"]", "b"]
+recheck = true
+
+# comment of the p2p table
+[p2p]
+seeds = ""
+`,
+		},
+		{
+			name:  "new key in the root table",
+			key:   "proxy_app",
+			value: "tcp://127.0.0.1:26658",
+			expected: `# root comment
+moniker = "node"
+proxy_app = "tcp://127.0.0.1:26658"
+
+[mempool]
+size = 5000
+nested = [
+  ["a", "b"],
+  ["c"],
+]
+sinks = [
+  { name = "a", tags = ["x", "y"] },
+  { name = "b]" },
+]
+labels = { chain = "test-1", tags = [["a", "b"]] } # inline table
+escaped = ["a\"]", "b"]
+
+# comment of the p2p table
+[p2p]
+seeds = ""
+`,
+		},
+		{
+			name:  "multi-line array",
+			key:   "mempool.nested",
+			value: []string{"d"},
+			expected: `# root comment
+moniker = "node"
+
+[mempool]
+size = 5000
+nested = ["d"]
+sinks = [
+  { name = "a", tags = ["x", "y"] },
+  { name = "b]" },
+]
+labels = { chain = "test-1", tags = [["a", "b"]] } # inline table
+escaped = ["a\"]", "b"]
+
+# comment of the p2p table
+[p2p]
+seeds = ""
+`,
+		},
+		{
+			name:  "key after multi-line arrays",
+			key:   "p2p.seeds",
+			value: "id@host:26656",
+			expected: `# root comment
+moniker = "node"
+
+[mempool]
+size = 5000
+nested = [
+  ["a", "b"],
+  ["c"],
+]
+sinks = [
+  { name = "a", tags = ["x", "y"] },
+  { name = "b]" },
+]
+labels = { chain = "test-1", tags = [["a", "b"]] } # inline table
+escaped = ["a\"]", "b"]
+
+# comment of the p2p table
+[p2p]
+seeds = "id@host:26656"
+`,
+		},
+		{
+			name:  "new table",
+			key:   "statesync.enable",
+			value: true,
+			expected: testConfig + `
+[statesync]
+enable = true
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := loadTestConfig(t)
+
+			if err := f.Set(tt.key, tt.value); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := f.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(f.Path())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(data) != tt.expected {
+				t.Fatalf("expected:\n%s\nfound:\n%s", tt.expected, data)
+			}
+		})
+	}
+}
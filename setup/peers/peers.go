@@ -3,11 +3,14 @@ package peers
 import (
 	"fmt"
 	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/setup/nodeconfig"
 	"github.com/KYVENetwork/ksync/types"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"math/rand"
 	"os"
 	"strings"
+	"time"
 )
 
 var (
@@ -15,11 +18,28 @@ var (
 	dotStyle          = helpStyle.UnsetMargins()
 	selectedItemStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170"))
 	checkMark         = lipgloss.NewStyle().Foreground(lipgloss.Color("42")).SetString("✓")
+	errorMark         = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).SetString("x")
 	selectedPeers     = make([]types.Peer, 0)
 )
 
-func SelectPeers(name string, peers []types.Peer) ([]types.Peer, error) {
-	_, err := tea.NewProgram(newModel(name, peers)).Run()
+func SelectPeers(name, chainId string, protocolVersion types.ProtocolVersion, peers []types.Peer) ([]types.Peer, error) {
+	var probes []types.PeerProbe
+
+	if flags.SkipPeerProbe {
+		for _, peer := range peers {
+			probes = append(probes, types.PeerProbe{Peer: peer, Reachable: true})
+		}
+	} else {
+		fmt.Printf("Probing %d %s ...\n", len(peers), name)
+		probes = ProbePeers(peers, chainId, protocolVersion)
+	}
+
+	// if a peer strategy was provided with the flags we do not need to ask the user
+	if flags.PeerStrategy != "" {
+		return FilterPeers(GetReachablePeers(probes), flags.PeerStrategy, flags.PeerCount, flags.PeerProviders)
+	}
+
+	_, err := tea.NewProgram(newModel(name, probes)).Run()
 	if err != nil {
 		return nil, err
 	}
@@ -27,69 +47,120 @@ func SelectPeers(name string, peers []types.Peer) ([]types.Peer, error) {
 	return selectedPeers, nil
 }
 
-func SavePeers(chainSchema *types.ChainSchema, seedsArr, persistentPeersArr []types.Peer) error {
-	seeds := ""
-	for index, peer := range seedsArr {
-		if index > 0 {
-			seeds += ","
+// FilterPeers selects the peers based on the given strategy. Available strategies are
+// "all", "none", "best" which picks the first count peers of the ranked peers, "random"
+// which picks count random peers and "provider" which only picks the peers from the
+// given comma separated providers
+func FilterPeers(peers []types.Peer, strategy string, count int64, providers string) ([]types.Peer, error) {
+	switch strategy {
+	case "all":
+		return peers, nil
+	case "best":
+		if count > 0 && int64(len(peers)) > count {
+			return peers[:count], nil
 		}
 
-		seeds += fmt.Sprintf("%s@%s", peer.Id, peer.Address)
-	}
+		return peers, nil
+	case "none":
+		return make([]types.Peer, 0), nil
+	case "random":
+		if count <= 0 {
+			return nil, fmt.Errorf("peer count has to be greater than zero for peer strategy \"random\"")
+		}
+
+		shuffled := make([]types.Peer, len(peers))
+		copy(shuffled, peers)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
 
-	persistentPeers := ""
-	for index, peer := range persistentPeersArr {
-		if index > 0 {
-			persistentPeers += ","
+		if int64(len(shuffled)) > count {
+			shuffled = shuffled[:count]
 		}
 
-		persistentPeers += fmt.Sprintf("%s@%s", peer.Id, peer.Address)
+		return shuffled, nil
+	case "provider":
+		if providers == "" {
+			return nil, fmt.Errorf("peer providers have to be specified for peer strategy \"provider\"")
+		}
+
+		filtered := make([]types.Peer, 0)
+		for _, peer := range peers {
+			for _, provider := range strings.Split(providers, ",") {
+				if strings.EqualFold(strings.TrimSpace(provider), peer.Provider) {
+					filtered = append(filtered, peer)
+					break
+				}
+			}
+		}
+
+		return filtered, nil
+	default:
+		return nil, fmt.Errorf("peer strategy has to be either \"all\", \"none\", \"best\", \"random\" or \"provider\", instead found \"%s\"", strategy)
+	}
+}
+
+func SavePeers(chainSchema *types.ChainSchema, seedsArr, persistentPeersArr []types.Peer) error {
+	seeds := make([]string, 0, len(seedsArr))
+	for _, peer := range seedsArr {
+		seeds = append(seeds, fmt.Sprintf("%s@%s", peer.Id, peer.Address))
+	}
+
+	persistentPeers := make([]string, 0, len(persistentPeersArr))
+	for _, peer := range persistentPeersArr {
+		persistentPeers = append(persistentPeers, fmt.Sprintf("%s@%s", peer.Id, peer.Address))
 	}
 
 	homePath := strings.ReplaceAll(chainSchema.NodeHome, "$HOME", os.Getenv("HOME"))
-	data, err := os.ReadFile(fmt.Sprintf("%s/config/config.toml", homePath))
+
+	config, err := nodeconfig.Load(nodeconfig.ConfigPath(homePath))
 	if err != nil {
 		return err
 	}
-	config := make([]string, 0)
-
-	for _, line := range strings.Split(string(data), "\n") {
-		if strings.HasPrefix(line, "moniker = ") {
-			config = append(config, fmt.Sprintf("moniker = \"%s\"", flags.Moniker))
-		} else if strings.HasPrefix(line, "seeds = ") {
-			config = append(config, fmt.Sprintf("seeds = \"%s\"", seeds))
-		} else if strings.HasPrefix(line, "persistent_peers = ") {
-			config = append(config, fmt.Sprintf("persistent_peers = \"%s\"", persistentPeers))
-		} else if strings.HasPrefix(line, "pyroscope_profile_types = ") {
-			config = append(config, "pyroscope_profile_types = \"\"")
-		} else {
-			config = append(config, line)
+
+	if flags.Moniker != "" {
+		if err := config.Set("moniker", flags.Moniker); err != nil {
+			return err
 		}
 	}
 
-	if err := os.WriteFile(fmt.Sprintf("%s/config/config.toml", homePath), []byte(strings.Join(config, "\n")), 0644); err != nil {
+	if err := config.Set("p2p.seeds", strings.Join(seeds, ",")); err != nil {
 		return err
 	}
 
-	return nil
+	if err := config.Set("p2p.persistent_peers", strings.Join(persistentPeers, ",")); err != nil {
+		return err
+	}
+
+	if config.Has("instrumentation.pyroscope_profile_types") {
+		if err := config.Set("instrumentation.pyroscope_profile_types", ""); err != nil {
+			return err
+		}
+	}
+
+	return config.Save()
 }
 
 type model struct {
 	name     string
-	peers    []types.Peer
+	probes   []types.PeerProbe
 	cursor   int
 	selected map[int]struct{}
 	quitting bool
 }
 
-func newModel(name string, peers []types.Peer) model {
+func newModel(name string, probes []types.PeerProbe) model {
+	// only peers which passed the probe are selected by default
 	selected := make(map[int]struct{})
-	for i := range peers {
-		selected[i] = struct{}{}
+	for i, probe := range probes {
+		if probe.Reachable {
+			selected[i] = struct{}{}
+		}
 	}
+
+	selectedPeers = GetReachablePeers(probes)
+
 	return model{
 		name:     name,
-		peers:    peers,
+		probes:   probes,
 		selected: selected,
 	}
 }
@@ -114,7 +185,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "j", "down":
-			if m.cursor < len(m.peers)-1 {
+			if m.cursor < len(m.probes)-1 {
 				m.cursor++
 			}
 
@@ -122,16 +193,20 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			_, ok := m.selected[m.cursor]
 			if ok {
 				delete(m.selected, m.cursor)
-			} else {
+			} else if m.probes[m.cursor].Reachable {
+				// unreachable peers can not be selected since they would only slow down the node
 				m.selected[m.cursor] = struct{}{}
 			}
 		}
 	}
 
+	// keep the ranking of the probes in the selection
 	selectedPeers = make([]types.Peer, 0)
 
-	for i := range m.selected {
-		selectedPeers = append(selectedPeers, m.peers[i])
+	for i, probe := range m.probes {
+		if _, ok := m.selected[i]; ok {
+			selectedPeers = append(selectedPeers, probe.Peer)
+		}
 	}
 
 	return m, nil
@@ -139,12 +214,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m model) View() string {
 	if m.quitting {
-		return fmt.Sprintf("%s Saved %d out of %d %s in config.toml\n", checkMark, len(m.selected), len(m.peers), m.name)
+		return fmt.Sprintf("%s Saved %d out of %d %s in config.toml\n", checkMark, len(m.selected), len(m.probes), m.name)
 	}
 
 	s := fmt.Sprintf("Select or deselect %s that should be included\n\n", m.name)
 
-	for i, peer := range m.peers {
+	for i, probe := range m.probes {
 		cursor := " "
 		if m.cursor == i {
 			cursor = ">"
@@ -155,7 +230,12 @@ func (m model) View() string {
 			checked = "x"
 		}
 
-		line := fmt.Sprintf("%s [%s] %s %s", cursor, checked, peer.Provider, dotStyle.Render(fmt.Sprintf("%s@%s", peer.Id, peer.Address)))
+		status := dotStyle.Render(fmt.Sprintf("(%s)", probe.Latency.Round(time.Millisecond)))
+		if !probe.Reachable {
+			status = errorMark.Render() + " " + dotStyle.Render(fmt.Sprintf("(%s)", probe.Error))
+		}
+
+		line := fmt.Sprintf("%s [%s] %s %s %s", cursor, checked, probe.Peer.Provider, dotStyle.Render(fmt.Sprintf("%s@%s", probe.Peer.Id, probe.Peer.Address)), status)
 		if m.cursor == i {
 			s += selectedItemStyle.Render(line) + "\n"
 		} else {
@@ -0,0 +1,87 @@
+package peers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/types"
+)
+
+// testPeers are ranked by their latency like the result of ProbePeers
+var testPeers = []types.Peer{
+	{Id: "a", Address: "1.1.1.1:26656", Provider: "Polkachu"},
+	{Id: "b", Address: "2.2.2.2:26656", Provider: "AutoStake"},
+	{Id: "c", Address: "3.3.3.3:26656", Provider: "polkachu"},
+	{Id: "d", Address: "4.4.4.4:26656", Provider: "Lavender.Five"},
+}
+
+func TestFilterPeers(t *testing.T) {
+	tests := []struct {
+		name        string
+		strategy    string
+		count       int64
+		providers   string
+		expected    []types.Peer
+		expectedErr bool
+	}{
+		{name: "all", strategy: "all", count: 1, expected: testPeers},
+		{name: "none", strategy: "none", expected: []types.Peer{}},
+		{name: "best keeps the ranking", strategy: "best", count: 2, expected: testPeers[:2]},
+		{name: "best without count", strategy: "best", expected: testPeers},
+		{name: "best with more than available", strategy: "best", count: 10, expected: testPeers},
+		{name: "random without count", strategy: "random", expectedErr: true},
+		{name: "provider ignores case and spaces", strategy: "provider", providers: "polkachu, lavender.five", expected: []types.Peer{testPeers[0], testPeers[2], testPeers[3]}},
+		{name: "provider without peers", strategy: "provider", providers: "unknown", expected: []types.Peer{}},
+		{name: "provider without providers", strategy: "provider", expectedErr: true},
+		{name: "unknown strategy", strategy: "fastest", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peers, err := FilterPeers(testPeers, tt.strategy, tt.count, tt.providers)
+
+			if tt.expectedErr {
+				if err == nil {
+					t.Fatalf("expected strategy %s to fail, found %v", tt.strategy, peers)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(peers, tt.expected) {
+				t.Fatalf("expected peers %v, found %v", tt.expected, peers)
+			}
+		})
+	}
+}
+
+func TestFilterPeersRandom(t *testing.T) {
+	original := append([]types.Peer(nil), testPeers...)
+
+	for _, count := range []int64{1, 3, 10} {
+		peers, err := FilterPeers(testPeers, "random", count, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := min(count, int64(len(testPeers))); int64(len(peers)) != expected {
+			t.Fatalf("expected %d peers, found %d", expected, len(peers))
+		}
+
+		seen := make(map[string]bool)
+		for _, peer := range peers {
+			if seen[peer.Id] {
+				t.Fatalf("expected distinct peers, found %s twice", peer.Id)
+			}
+			seen[peer.Id] = true
+		}
+	}
+
+	// the ranking of the probed peers must not be changed by shuffling
+	if !reflect.DeepEqual(testPeers, original) {
+		t.Fatalf("expected peers to be unchanged, found %v", testPeers)
+	}
+}
@@ -0,0 +1,152 @@
+package peers
+
+import (
+	"fmt"
+	"github.com/KYVENetwork/cometbft/v38/crypto/ed25519"
+	"github.com/KYVENetwork/cometbft/v38/libs/protoio"
+	"github.com/KYVENetwork/cometbft/v38/p2p"
+	"github.com/KYVENetwork/cometbft/v38/p2p/conn"
+	tmp2p "github.com/KYVENetwork/cometbft/v38/proto/cometbft/v38/p2p"
+	"github.com/KYVENetwork/ksync/types"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	probeTimeout     = 5 * time.Second
+	probeConcurrency = 32
+)
+
+// ProbePeers probes all peers concurrently and returns the results ranked by
+// reachability first and latency second. The protocol version is announced in our
+// node info and should match the engine of the chain
+func ProbePeers(peers []types.Peer, chainId string, protocolVersion types.ProtocolVersion) []types.PeerProbe {
+	probes := make([]types.PeerProbe, len(peers))
+	privKey := ed25519.GenPrivKey()
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, probeConcurrency)
+
+	for i, peer := range peers {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func(i int, peer types.Peer) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			probes[i] = probePeer(peer, chainId, protocolVersion, privKey)
+		}(i, peer)
+	}
+
+	wg.Wait()
+
+	sort.SliceStable(probes, func(i, j int) bool {
+		if probes[i].Reachable != probes[j].Reachable {
+			return probes[i].Reachable
+		}
+
+		return probes[i].Latency < probes[j].Latency
+	})
+
+	return probes
+}
+
+// GetReachablePeers returns the peers of all probes which passed the handshake
+func GetReachablePeers(probes []types.PeerProbe) []types.Peer {
+	reachable := make([]types.Peer, 0)
+
+	for _, probe := range probes {
+		if probe.Reachable {
+			reachable = append(reachable, probe.Peer)
+		}
+	}
+
+	return reachable
+}
+
+// probePeer dials the peer and performs the P2P handshake. The latency is the time
+// of the TCP dial, afterward we verify that the node id of the peer matches and that
+// the peer is part of the expected network
+func probePeer(peer types.Peer, chainId string, protocolVersion types.ProtocolVersion, privKey ed25519.PrivKey) types.PeerProbe {
+	probe := types.PeerProbe{Peer: peer}
+
+	start := time.Now()
+
+	c, err := net.DialTimeout("tcp", peer.Address, probeTimeout)
+	if err != nil {
+		probe.Error = fmt.Sprintf("failed to dial: %s", err)
+		return probe
+	}
+	defer c.Close()
+
+	probe.Latency = time.Since(start)
+
+	if err := c.SetDeadline(time.Now().Add(probeTimeout)); err != nil {
+		probe.Error = fmt.Sprintf("failed to set deadline: %s", err)
+		return probe
+	}
+
+	secretConn, err := conn.MakeSecretConnection(c, privKey)
+	if err != nil {
+		probe.Error = fmt.Sprintf("failed to establish secret connection: %s", err)
+		return probe
+	}
+
+	if remoteId := p2p.PubKeyToID(secretConn.RemotePubKey()); !strings.EqualFold(string(remoteId), peer.Id) {
+		probe.Error = fmt.Sprintf("node id mismatch, expected = %s, found = %s", peer.Id, remoteId)
+		return probe
+	}
+
+	nodeInfo, err := exchangeNodeInfo(secretConn, chainId, protocolVersion, privKey)
+	if err != nil {
+		probe.Error = fmt.Sprintf("failed to exchange node info: %s", err)
+		return probe
+	}
+
+	if nodeInfo.Network != chainId {
+		probe.Error = fmt.Sprintf("network mismatch, expected = %s, found = %s", chainId, nodeInfo.Network)
+		return probe
+	}
+
+	probe.Reachable = true
+	return probe
+}
+
+// exchangeNodeInfo sends our node info while reading the node info of the peer
+// like it is done during the handshake of the P2P transport
+func exchangeNodeInfo(c net.Conn, chainId string, protocolVersion types.ProtocolVersion, privKey ed25519.PrivKey) (*tmp2p.DefaultNodeInfo, error) {
+	ourNodeInfo := p2p.DefaultNodeInfo{
+		ProtocolVersion: p2p.NewProtocolVersion(protocolVersion.P2P, protocolVersion.Block, protocolVersion.App),
+		DefaultNodeID:   p2p.PubKeyToID(privKey.PubKey()),
+		ListenAddr:      "tcp://0.0.0.0:26656",
+		Network:         chainId,
+		Version:         "ksync",
+		Channels:        []byte{},
+		Moniker:         "ksync-probe",
+	}
+
+	// the peer might close the connection after reading our node info, so
+	// only the node info of the peer is required for the probe to succeed.
+	// We still wait for the write before the connection gets closed, it is
+	// bounded by the deadline of the connection
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		_, _ = protoio.NewDelimitedWriter(c).WriteMsg(ourNodeInfo.ToProto())
+	}()
+
+	var peerNodeInfo tmp2p.DefaultNodeInfo
+	_, err := protoio.NewDelimitedReader(c, p2p.MaxNodeInfoSize()).ReadMsg(&peerNodeInfo)
+
+	<-written
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &peerNodeInfo, nil
+}
@@ -0,0 +1,199 @@
+package peers
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KYVENetwork/cometbft/v38/crypto/ed25519"
+	"github.com/KYVENetwork/cometbft/v38/libs/protoio"
+	"github.com/KYVENetwork/cometbft/v38/p2p"
+	"github.com/KYVENetwork/cometbft/v38/p2p/conn"
+	tmp2p "github.com/KYVENetwork/cometbft/v38/proto/cometbft/v38/p2p"
+	"github.com/KYVENetwork/ksync/types"
+)
+
+var testProtocolVersion = types.ProtocolVersion{P2P: 8, Block: 11, App: 3}
+
+// testNode is a local listener which performs the p2p handshake like a node of
+// the given network and reports the node info it received from the probe
+type testNode struct {
+	peer     types.Peer
+	received chan *tmp2p.DefaultNodeInfo
+}
+
+func startTestNode(t *testing.T, network string) *testNode {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	privKey := ed25519.GenPrivKey()
+	node := &testNode{
+		peer:     types.Peer{Id: string(p2p.PubKeyToID(privKey.PubKey())), Address: listener.Addr().String()},
+		received: make(chan *tmp2p.DefaultNodeInfo, 1),
+	}
+
+	go func() {
+		c, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		secretConn, err := conn.MakeSecretConnection(c, privKey)
+		if err != nil {
+			return
+		}
+
+		nodeInfo := p2p.DefaultNodeInfo{
+			ProtocolVersion: p2p.NewProtocolVersion(8, 11, 0),
+			DefaultNodeID:   p2p.ID(node.peer.Id),
+			ListenAddr:      node.peer.Address,
+			Network:         network,
+			Version:         "0.38.0",
+			Moniker:         "test",
+		}
+
+		written := make(chan struct{})
+		go func() {
+			defer close(written)
+			_, _ = protoio.NewDelimitedWriter(secretConn).WriteMsg(nodeInfo.ToProto())
+		}()
+		defer func() { <-written }()
+
+		var probeNodeInfo tmp2p.DefaultNodeInfo
+		if _, err := protoio.NewDelimitedReader(secretConn, p2p.MaxNodeInfoSize()).ReadMsg(&probeNodeInfo); err != nil {
+			return
+		}
+		node.received <- &probeNodeInfo
+	}()
+
+	return node
+}
+
+// unreachablePeer returns a peer on a port where nothing is listening
+func unreachablePeer(t *testing.T) types.Peer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	return types.Peer{Id: string(p2p.PubKeyToID(ed25519.GenPrivKey().PubKey())), Address: address}
+}
+
+func TestProbePeer(t *testing.T) {
+	tests := []struct {
+		name        string
+		peer        func(t *testing.T) (types.Peer, *testNode)
+		expectedErr string
+	}{
+		{
+			name: "reachable",
+			peer: func(t *testing.T) (types.Peer, *testNode) {
+				node := startTestNode(t, "test-1")
+				return node.peer, node
+			},
+		},
+		{
+			name: "node id mismatch",
+			peer: func(t *testing.T) (types.Peer, *testNode) {
+				node := startTestNode(t, "test-1")
+				return types.Peer{Id: unreachablePeer(t).Id, Address: node.peer.Address}, nil
+			},
+			expectedErr: "node id mismatch",
+		},
+		{
+			name: "network mismatch",
+			peer: func(t *testing.T) (types.Peer, *testNode) {
+				node := startTestNode(t, "other-1")
+				return node.peer, nil
+			},
+			expectedErr: "network mismatch, expected = test-1, found = other-1",
+		},
+		{
+			name: "unreachable",
+			peer: func(t *testing.T) (types.Peer, *testNode) {
+				return unreachablePeer(t), nil
+			},
+			expectedErr: "failed to dial",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peer, node := tt.peer(t)
+
+			probe := probePeer(peer, "test-1", testProtocolVersion, ed25519.GenPrivKey())
+
+			if tt.expectedErr != "" {
+				if probe.Reachable || !strings.Contains(probe.Error, tt.expectedErr) {
+					t.Fatalf("expected probe to fail with %q, found %+v", tt.expectedErr, probe)
+				}
+				return
+			}
+
+			if !probe.Reachable || probe.Error != "" || probe.Latency <= 0 {
+				t.Fatalf("expected peer to be reachable, found %+v", probe)
+			}
+
+			// the node info of the probe has to be fully written before the connection gets closed
+			var received *tmp2p.DefaultNodeInfo
+			select {
+			case received = <-node.received:
+			case <-time.After(probeTimeout):
+				t.Fatal("expected node to receive the node info of the probe")
+			}
+
+			if received.Network != "test-1" {
+				t.Fatalf("expected probe to announce network test-1, found %s", received.Network)
+			}
+
+			expected := tmp2p.ProtocolVersion{P2P: testProtocolVersion.P2P, Block: testProtocolVersion.Block, App: testProtocolVersion.App}
+			if received.ProtocolVersion != expected {
+				t.Fatalf("expected probe to announce protocol version %+v, found %+v", expected, received.ProtocolVersion)
+			}
+		})
+	}
+}
+
+func TestProbePeers(t *testing.T) {
+	first := startTestNode(t, "test-1")
+	wrongNetwork := startTestNode(t, "other-1")
+	unreachable := unreachablePeer(t)
+	second := startTestNode(t, "test-1")
+
+	probes := ProbePeers([]types.Peer{unreachable, first.peer, wrongNetwork.peer, second.peer}, "test-1", testProtocolVersion)
+
+	if len(probes) != 4 {
+		t.Fatalf("expected 4 probes, found %d", len(probes))
+	}
+
+	// reachable peers are ranked first by their latency
+	if !probes[0].Reachable || !probes[1].Reachable || probes[0].Latency > probes[1].Latency {
+		t.Fatalf("expected reachable peers ranked by latency first, found %+v", probes)
+	}
+
+	for _, probe := range probes[2:] {
+		if probe.Reachable {
+			t.Fatalf("expected unreachable peers last, found %+v", probes)
+		}
+	}
+
+	reachable := GetReachablePeers(probes)
+	if len(reachable) != 2 {
+		t.Fatalf("expected 2 reachable peers, found %+v", reachable)
+	}
+
+	for _, peer := range reachable {
+		if peer != first.peer && peer != second.peer {
+			t.Fatalf("expected only the peers of the network to be reachable, found %+v", reachable)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"fmt"
+	"github.com/KYVENetwork/ksync/types"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	KindSystemd = "systemd"
+	KindCompose = "compose"
+	KindK8s     = "k8s"
+)
+
+// baseImage has the same glibc as the golang image the binaries are built in
+const baseImage = "debian:bookworm-slim"
+
+const systemdUnitTemplate = `[Unit]
+Description=%s node
+After=network-online.target
+
+[Service]
+User=%s
+ExecStart=%s
+Restart=always
+RestartSec=5
+LimitNOFILE=65535
+%s
+[Install]
+WantedBy=multi-user.target
+`
+
+const composeTemplate = `services:
+  %s:
+    image: %s
+    container_name: %s
+    restart: unless-stopped
+    user: "%s:%s"
+    network_mode: host
+    entrypoint: [%s]
+    environment:
+%s    volumes:
+%s`
+
+const k8sTemplate = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: %[1]s
+spec:
+  serviceName: %[1]s
+  replicas: 1
+  selector:
+    matchLabels:
+      app: %[1]s
+  template:
+    metadata:
+      labels:
+        app: %[1]s
+    spec:
+      securityContext:
+        runAsUser: %[7]s
+        runAsGroup: %[8]s
+      containers:
+        - name: %[1]s
+          image: %[2]s
+          command: [%[3]s]
+          env:
+%[4]s          ports:
+            - name: p2p
+              containerPort: 26656
+            - name: rpc
+              containerPort: 26657
+          volumeMounts:
+%[5]s      # the node home and the binaries are mounted from the host where the setup ran,
+      # replace the host paths with persistent volumes to run the node on other hosts
+      volumes:
+%[6]s---
+apiVersion: v1
+kind: Service
+metadata:
+  name: %[1]s
+spec:
+  selector:
+    app: %[1]s
+  ports:
+    - name: p2p
+      port: 26656
+    - name: rpc
+      port: 26657
+`
+
+// GetCosmovisorCommand returns the command which runs the node of the chain with the
+// cosmovisor installed by the setup. The library path points to the "current" symlink
+// so that cosmovisor upgrades also switch to the libraries of the upgrade
+func GetCosmovisorCommand(chainSchema *types.ChainSchema) types.NodeCommand {
+	homePath := strings.ReplaceAll(chainSchema.NodeHome, "$HOME", os.Getenv("HOME"))
+
+	return types.NodeCommand{
+		Binary: filepath.Join(os.Getenv("HOME"), "go", "bin", "cosmovisor"),
+		Args:   []string{"run", "start", "--home", homePath},
+		Env: []string{
+			fmt.Sprintf("DAEMON_NAME=%s", chainSchema.DaemonName),
+			fmt.Sprintf("DAEMON_HOME=%s", homePath),
+			fmt.Sprintf("LD_LIBRARY_PATH=%s", filepath.Join(homePath, "cosmovisor", "current", "bin")),
+			"DAEMON_RESTART_AFTER_UPGRADE=true",
+		},
+	}
+}
+
+// ValidateKinds checks that every service kind can be generated
+func ValidateKinds(kinds []string) error {
+	for _, kind := range kinds {
+		switch strings.TrimSpace(kind) {
+		case KindSystemd, KindCompose, KindK8s:
+		default:
+			return fmt.Errorf("service kind has to be either \"%s\", \"%s\" or \"%s\", instead found \"%s\"", KindSystemd, KindCompose, KindK8s, kind)
+		}
+	}
+
+	return nil
+}
+
+// Generate writes the service files of the given kinds for the node command to the
+// output directory and returns the paths of the written files
+func Generate(name, homePath string, command types.NodeCommand, kinds []string, outputDir string) ([]string, error) {
+	if err := ValidateKinds(kinds); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory %s: %w", outputDir, err)
+	}
+
+	paths := make([]string, 0)
+
+	for _, kind := range kinds {
+		var path, content string
+
+		switch strings.TrimSpace(kind) {
+		case KindSystemd:
+			path, content = filepath.Join(outputDir, fmt.Sprintf("%s.service", name)), GetSystemdUnit(name, command)
+		case KindCompose:
+			path, content = filepath.Join(outputDir, "docker-compose.yml"), GetCompose(name, homePath, command)
+		case KindK8s:
+			path, content = filepath.Join(outputDir, fmt.Sprintf("%s.k8s.yml", name)), GetK8sManifest(name, homePath, command)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// GetSystemdUnit returns a systemd unit which runs the node command
+func GetSystemdUnit(name string, command types.NodeCommand) string {
+	execStart := make([]string, 0, len(command.Args)+1)
+	for _, arg := range append([]string{command.Binary}, command.Args...) {
+		if strings.ContainsAny(arg, " \t\"") {
+			arg = fmt.Sprintf("\"%s\"", strings.ReplaceAll(arg, "\"", "\\\""))
+		}
+		execStart = append(execStart, arg)
+	}
+
+	environment := ""
+	for _, env := range command.Env {
+		environment += fmt.Sprintf("Environment=\"%s\"\n", env)
+	}
+
+	username, _, _ := getUser()
+
+	return fmt.Sprintf(systemdUnitTemplate, name, username, strings.Join(execStart, " "), environment)
+}
+
+// GetCompose returns a docker compose file which runs the node command. The node home
+// and the binary are mounted with the same paths as on the host, so the command and
+// the library path work unchanged inside the container
+func GetCompose(name, homePath string, command types.NodeCommand) string {
+	environment := ""
+	for _, env := range command.Env {
+		environment += fmt.Sprintf("      - %s\n", env)
+	}
+
+	volumes := ""
+	for _, path := range getMounts(homePath, command) {
+		volumes += fmt.Sprintf("      - %s:%s\n", path, path)
+	}
+
+	_, uid, gid := getUser()
+
+	return fmt.Sprintf(composeTemplate, name, baseImage, name, uid, gid, quoteArgs(command), environment, volumes)
+}
+
+// GetK8sManifest returns a StatefulSet and a Service which run the node command
+func GetK8sManifest(name, homePath string, command types.NodeCommand) string {
+	environment := ""
+	for _, env := range command.Env {
+		key, value, _ := strings.Cut(env, "=")
+		environment += fmt.Sprintf("            - name: %s\n              value: \"%s\"\n", key, value)
+	}
+
+	volumeMounts, volumes := "", ""
+	for i, path := range getMounts(homePath, command) {
+		volumeMounts += fmt.Sprintf("            - name: volume-%d\n              mountPath: %s\n", i, path)
+		volumes += fmt.Sprintf("        - name: volume-%d\n          hostPath:\n            path: %s\n", i, path)
+	}
+
+	_, uid, gid := getUser()
+
+	return fmt.Sprintf(k8sTemplate, name, baseImage, quoteArgs(command), environment, volumeMounts, volumes, uid, gid)
+}
+
+// getUser returns the name, uid and gid of the user which runs the node. This is the
+// user running the setup, since the node home and the binaries belong to it
+func getUser() (string, string, string) {
+	if name := os.Getenv("USER"); name != "" {
+		if u, err := user.Lookup(name); err == nil {
+			return u.Username, u.Uid, u.Gid
+		}
+	}
+
+	if u, err := user.Current(); err == nil {
+		return u.Username, u.Uid, u.Gid
+	}
+
+	return "root", "0", "0"
+}
+
+// getMounts returns the node home and the binary if it is located outside of the home
+func getMounts(homePath string, command types.NodeCommand) []string {
+	mounts := []string{homePath}
+
+	if !strings.HasPrefix(command.Binary, homePath+string(filepath.Separator)) {
+		mounts = append(mounts, command.Binary)
+	}
+
+	return mounts
+}
+
+func quoteArgs(command types.NodeCommand) string {
+	args := make([]string, 0, len(command.Args)+1)
+	for _, arg := range append([]string{command.Binary}, command.Args...) {
+		args = append(args, fmt.Sprintf("\"%s\"", strings.ReplaceAll(arg, "\"", "\\\"")))
+	}
+
+	return strings.Join(args, ", ")
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/types"
+)
+
+var testCommand = types.NodeCommand{
+	Binary: "/home/node/go/bin/cosmovisor",
+	Args:   []string{"run", "start", "--home", "/home/node/.osmosisd"},
+	Env:    []string{"DAEMON_NAME=osmosisd"},
+}
+
+func TestValidateKinds(t *testing.T) {
+	if err := ValidateKinds([]string{"systemd", " compose", "k8s "}); err != nil {
+		t.Fatalf("expected valid kinds, found %s", err)
+	}
+
+	if err := ValidateKinds([]string{"systemd", "kubernetes"}); err == nil {
+		t.Fatal("expected unknown kind to fail")
+	}
+}
+
+func TestGenerateRejectsUnknownKindsBeforeWriting(t *testing.T) {
+	outputDir := filepath.Join(t.TempDir(), "services")
+
+	if _, err := Generate("osmosisd", "/home/node/.osmosisd", testCommand, []string{"systemd", "kubernetes"}, outputDir); err == nil {
+		t.Fatal("expected unknown kind to fail")
+	}
+
+	if _, err := os.Stat(outputDir); !os.IsNotExist(err) {
+		t.Fatalf("expected no files to be written, found %v", err)
+	}
+}
+
+func TestServicesRunAsSystemdUser(t *testing.T) {
+	current, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("USER", current.Username)
+
+	if unit := GetSystemdUnit("osmosisd", testCommand); !strings.Contains(unit, fmt.Sprintf("User=%s\n", current.Username)) {
+		t.Fatalf("expected systemd unit to run as %s, found:\n%s", current.Username, unit)
+	}
+
+	if compose := GetCompose("osmosisd", "/home/node/.osmosisd", testCommand); !strings.Contains(compose, fmt.Sprintf("    user: \"%s:%s\"\n", current.Uid, current.Gid)) {
+		t.Fatalf("expected compose service to run as %s:%s, found:\n%s", current.Uid, current.Gid, compose)
+	}
+
+	securityContext := fmt.Sprintf("      securityContext:\n        runAsUser: %s\n        runAsGroup: %s\n", current.Uid, current.Gid)
+	if manifest := GetK8sManifest("osmosisd", "/home/node/.osmosisd", testCommand); !strings.Contains(manifest, securityContext) {
+		t.Fatalf("expected pod to run as %s:%s, found:\n%s", current.Uid, current.Gid, manifest)
+	}
+}
@@ -2,18 +2,33 @@ package setup
 
 import (
 	"fmt"
+	"github.com/KYVENetwork/ksync/app"
 	"github.com/KYVENetwork/ksync/flags"
 	"github.com/KYVENetwork/ksync/setup/installations"
 	"github.com/KYVENetwork/ksync/setup/mode"
+	"github.com/KYVENetwork/ksync/setup/nodeconfig"
 	"github.com/KYVENetwork/ksync/setup/peers"
+	"github.com/KYVENetwork/ksync/setup/services"
 	"github.com/KYVENetwork/ksync/setup/sources"
 	"github.com/KYVENetwork/ksync/sync/blocksync"
 	"github.com/KYVENetwork/ksync/sync/statesync"
+	"github.com/KYVENetwork/ksync/types"
 	"os"
+	"strconv"
 	"strings"
 )
 
 func Start() error {
+	if err := validateFlags(); err != nil {
+		return err
+	}
+
+	if flags.NonInteractive {
+		if err := validateNonInteractive(); err != nil {
+			return err
+		}
+	}
+
 	if err := sources.SelectSource(); err != nil {
 		return err
 	}
@@ -23,11 +38,11 @@ func Start() error {
 		return err
 	}
 
-	if setupMode == 0 {
+	if setupMode == mode.ModeExit {
 		return nil
 	}
 
-	if setupMode == 2 {
+	if setupMode == mode.ModeStateSync {
 		if err := installations.InstallStateSyncBinaries(chainSchema, upgrades); err != nil {
 			return err
 		}
@@ -37,12 +52,14 @@ func Start() error {
 		}
 	}
 
-	seeds, err := peers.SelectPeers("seeds", chainSchema.Peers.Seeds)
+	protocolVersion := app.GetProtocolVersion(chainSchema.Codebase.Consensus.Type, chainSchema.Codebase.Consensus.Version)
+
+	seeds, err := peers.SelectPeers("seeds", chainSchema.ChainId, protocolVersion, chainSchema.Peers.Seeds)
 	if err != nil {
 		return err
 	}
 
-	persistentPeers, err := peers.SelectPeers("persistent peers", chainSchema.Peers.PersistentPeers)
+	persistentPeers, err := peers.SelectPeers("persistent peers", chainSchema.ChainId, protocolVersion, chainSchema.Peers.PersistentPeers)
 	if err != nil {
 		return err
 	}
@@ -51,6 +68,10 @@ func Start() error {
 		return err
 	}
 
+	if err := configureApp(chainSchema); err != nil {
+		return err
+	}
+
 	flags.DaemonName = chainSchema.DaemonName
 	flags.DaemonHome = strings.ReplaceAll(chainSchema.NodeHome, "$HOME", os.Getenv("HOME"))
 	flags.BinaryPath = fmt.Sprintf("%s/go/bin/cosmovisor", os.Getenv("HOME"))
@@ -59,16 +80,120 @@ func Start() error {
 	flags.Reset = true
 	flags.Y = true
 
-	if setupMode == 1 {
+	if flags.GenerateServices != "" {
+		outputDir := flags.ServicesOutputDir
+		if outputDir == "" {
+			outputDir = "."
+		}
+
+		paths, err := services.Generate(chainSchema.DaemonName, flags.DaemonHome, services.GetCosmovisorCommand(chainSchema), strings.Split(flags.GenerateServices, ","), outputDir)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			fmt.Printf("Generated %s\n", path)
+		}
+	}
+
+	if setupMode == mode.ModeInstall {
 		fmt.Println("Successfully completed setup, to run Cosmovisor please export the following environment variables before:")
 		fmt.Println(fmt.Sprintf("> export DAEMON_NAME=%s DAEMON_HOME=%s LD_LIBRARY_PATH=%s/cosmovisor/current/bin", flags.DaemonName, flags.DaemonHome, flags.DaemonHome))
 		fmt.Println(fmt.Sprintf("> %s/go/bin/cosmovisor run version", os.Getenv("HOME")))
 		return nil
-	} else if setupMode == 2 {
+	} else if setupMode == mode.ModeStateSync {
 		return statesync.Start()
-	} else if setupMode == 3 {
+	} else if setupMode == mode.ModeBlockSync {
 		return blocksync.Start()
 	}
 
 	return nil
 }
+
+// validateFlags fails fast on flag combinations which are invalid in every mode
+func validateFlags() error {
+	if flags.GenesisChecksum != "" && flags.GenesisUrl == "" && flags.GenesisFile == "" {
+		return fmt.Errorf("--genesis-checksum requires --genesis-url or --genesis-file")
+	}
+
+	// the services are generated after the binaries were built, so we check the kinds before
+	if flags.GenerateServices != "" {
+		if err := services.ValidateKinds(strings.Split(flags.GenerateServices, ",")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateNonInteractive fails fast if answers are missing which would otherwise
+// be asked interactively
+func validateNonInteractive() error {
+	if flags.Source == "" {
+		return fmt.Errorf("source is required in non-interactive mode, please provide it with --source")
+	}
+
+	if flags.SetupMode == "" {
+		return fmt.Errorf("setup mode is required in non-interactive mode, please provide it with --mode")
+	}
+
+	if _, err := mode.ParseSetupMode(flags.SetupMode); err != nil {
+		return err
+	}
+
+	// without a strategy the best verified peers are selected, which are all
+	// verified peers like in the interactive selection if no count is given
+	if flags.PeerStrategy == "" {
+		flags.PeerStrategy = "best"
+	}
+
+	if _, err := peers.FilterPeers(nil, flags.PeerStrategy, flags.PeerCount, flags.PeerProviders); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// configureApp applies the app settings of the setup to the app.toml. The minimum gas
+// prices are taken from the fee tokens of the chain registry if they are not set yet
+func configureApp(chainSchema *types.ChainSchema) error {
+	homePath := strings.ReplaceAll(chainSchema.NodeHome, "$HOME", os.Getenv("HOME"))
+
+	config, err := nodeconfig.Load(nodeconfig.AppPath(homePath))
+	if err != nil {
+		return err
+	}
+
+	if flags.AppPruning != "" {
+		if err := config.Set("pruning", flags.AppPruning); err != nil {
+			return err
+		}
+	}
+
+	if flags.AppSnapshotInterval > 0 {
+		if err := config.Set("state-sync.snapshot-interval", flags.AppSnapshotInterval); err != nil {
+			return err
+		}
+	}
+
+	if flags.EnableApi {
+		if err := config.Set("api.enable", true); err != nil {
+			return err
+		}
+	}
+
+	if gasPrices, _ := config.Get("minimum-gas-prices"); gasPrices == "" || gasPrices == "\"\"" {
+		prices := make([]string, 0, len(chainSchema.Fees.FeeTokens))
+		for _, feeToken := range chainSchema.Fees.FeeTokens {
+			prices = append(prices, fmt.Sprintf("%s%s", strconv.FormatFloat(feeToken.LowGasPrice, 'f', -1, 64), feeToken.Denom))
+		}
+
+		if len(prices) > 0 {
+			if err := config.Set("minimum-gas-prices", strings.Join(prices, ",")); err != nil {
+				return err
+			}
+		}
+	}
+
+	return config.Save()
+}
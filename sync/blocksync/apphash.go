@@ -0,0 +1,104 @@
+package blocksync
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/KYVENetwork/ksync/app"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/utils"
+	"strings"
+)
+
+type headerHashes struct {
+	AppHash         string `json:"app_hash"`
+	LastResultsHash string `json:"last_results_hash"`
+}
+
+// getAppHashCheckInterval returns the interval of the app hash checks. A reference
+// rpc without an interval enables the check on every block
+func getAppHashCheckInterval() int64 {
+	if flags.AppHashCheckInterval <= 0 && flags.AppHashRpc != "" {
+		return 1
+	}
+
+	return flags.AppHashCheckInterval
+}
+
+// isAppHashCheckHeight returns true if the app hash has to be checked after the block
+func isAppHashCheckHeight(interval, height int64) bool {
+	return interval > 0 && height%interval == 0
+}
+
+// checkAppHash compares the app hash and last results hash the app produced by executing
+// the block at the given height with the hashes committed in the header of the next block
+// and optionally with the header of a reference rpc. This catches non-determinism and app
+// version mismatches at the exact height where they happen
+func checkAppHash(app *app.CosmosApp, height int64, nextRawBlock []byte, referenceRpc string) error {
+	appHash, lastResultsHash := app.ConsensusEngine.GetStateHashes()
+
+	computed := headerHashes{
+		AppHash:         fmt.Sprintf("%X", appHash),
+		LastResultsHash: fmt.Sprintf("%X", lastResultsHash),
+	}
+
+	var nextBlock struct {
+		Header headerHashes `json:"header"`
+	}
+
+	if err := json.Unmarshal(nextRawBlock, &nextBlock); err != nil {
+		return fmt.Errorf("failed to unmarshal header of block %d: %w", height+1, err)
+	}
+
+	if err := compareHashes(height, computed, nextBlock.Header, fmt.Sprintf("header of block %d", height+1)); err != nil {
+		return err
+	}
+
+	if referenceRpc == "" {
+		return nil
+	}
+
+	data, err := utils.GetFromUrl(fmt.Sprintf("%s/block?height=%d", strings.TrimSuffix(referenceRpc, "/"), height+1))
+	if err != nil {
+		return fmt.Errorf("failed to get block %d from reference rpc: %w", height+1, err)
+	}
+
+	var blockResponse struct {
+		Result struct {
+			Block struct {
+				Header headerHashes `json:"header"`
+			} `json:"block"`
+		} `json:"result"`
+	}
+
+	if err := json.Unmarshal(data, &blockResponse); err != nil {
+		return fmt.Errorf("failed to unmarshal block %d from reference rpc: %w", height+1, err)
+	}
+
+	return compareHashes(height, computed, blockResponse.Result.Block.Header, fmt.Sprintf("reference rpc %s", referenceRpc))
+}
+
+func compareHashes(height int64, computed, expected headerHashes, source string) error {
+	appHashMatches := strings.EqualFold(computed.AppHash, expected.AppHash)
+	lastResultsHashMatches := strings.EqualFold(computed.LastResultsHash, expected.LastResultsHash)
+
+	if appHashMatches && lastResultsHashMatches {
+		logger.Logger.Debug().Int64("height", height).Str("source", source).Msg("app hash and last results hash match")
+		return nil
+	}
+
+	logger.Logger.Error().Int64("height", height).Str("source", source).Msg("app diverged after executing block")
+	logger.Logger.Error().Bool("match", appHashMatches).Str("computed", computed.AppHash).Str("expected", expected.AppHash).Msg("app_hash")
+	logger.Logger.Error().Bool("match", lastResultsHashMatches).Str("computed", computed.LastResultsHash).Str("expected", expected.LastResultsHash).Msg("last_results_hash")
+
+	return utils.NewClassErrorf(
+		utils.ErrAppExecution,
+		"app diverged from %s after executing block %d: app_hash computed = %s expected = %s, last_results_hash computed = %s expected = %s",
+		source,
+		height,
+		computed.AppHash,
+		expected.AppHash,
+		computed.LastResultsHash,
+		expected.LastResultsHash,
+	)
+}
@@ -0,0 +1,141 @@
+package blocksync
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/app"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+)
+
+func TestGetAppHashCheckInterval(t *testing.T) {
+	tests := []struct {
+		interval int64
+		rpc      string
+		expected int64
+	}{
+		{interval: 0, expected: 0},
+		{interval: 100, expected: 100},
+		{interval: 0, rpc: "http://localhost:26657", expected: 1},
+		{interval: 100, rpc: "http://localhost:26657", expected: 100},
+	}
+
+	interval, rpc := flags.AppHashCheckInterval, flags.AppHashRpc
+	t.Cleanup(func() {
+		flags.AppHashCheckInterval, flags.AppHashRpc = interval, rpc
+	})
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("interval %d with rpc %q", tt.interval, tt.rpc), func(t *testing.T) {
+			flags.AppHashCheckInterval, flags.AppHashRpc = tt.interval, tt.rpc
+
+			if interval := getAppHashCheckInterval(); interval != tt.expected {
+				t.Fatalf("expected interval %d, found %d", tt.expected, interval)
+			}
+		})
+	}
+}
+
+// stateHashesEngine returns the state hashes of an executed block
+type stateHashesEngine struct {
+	types.Engine
+	appHash, lastResultsHash []byte
+}
+
+func (engine stateHashesEngine) GetStateHashes() ([]byte, []byte) {
+	return engine.appHash, engine.lastResultsHash
+}
+
+func TestIsAppHashCheckHeight(t *testing.T) {
+	tests := []struct {
+		interval, height int64
+		expected         bool
+	}{
+		{interval: 0, height: 100, expected: false},
+		{interval: 1, height: 101, expected: true},
+		{interval: 100, height: 100, expected: true},
+		{interval: 100, height: 150, expected: false},
+	}
+
+	for _, tt := range tests {
+		if check := isAppHashCheckHeight(tt.interval, tt.height); check != tt.expected {
+			t.Fatalf("expected check at height %d with interval %d to be %t, found %t", tt.height, tt.interval, tt.expected, check)
+		}
+	}
+}
+
+func TestCheckAppHash(t *testing.T) {
+	const (
+		appHash         = "AA11"
+		lastResultsHash = "BB22"
+		otherHash       = "CC33"
+	)
+
+	engine := stateHashesEngine{appHash: []byte{0xaa, 0x11}, lastResultsHash: []byte{0xbb, 0x22}}
+
+	header := func(appHash, lastResultsHash string) string {
+		return fmt.Sprintf(`{"header":{"height":"101","app_hash":"%s","last_results_hash":"%s"}}`, appHash, lastResultsHash)
+	}
+
+	tests := []struct {
+		name        string
+		nextBlock   string
+		rpcBlock    string
+		expectedErr string
+	}{
+		{name: "match", nextBlock: header(appHash, lastResultsHash)},
+		{name: "match with lowercase hashes", nextBlock: header(strings.ToLower(appHash), strings.ToLower(lastResultsHash))},
+		{name: "app hash mismatch in next header", nextBlock: header(otherHash, lastResultsHash), expectedErr: "header of block 101"},
+		{name: "last results hash mismatch in next header", nextBlock: header(appHash, otherHash), expectedErr: "header of block 101"},
+		{name: "match with reference rpc", nextBlock: header(appHash, lastResultsHash), rpcBlock: header(appHash, lastResultsHash)},
+		{name: "app hash mismatch with reference rpc", nextBlock: header(appHash, lastResultsHash), rpcBlock: header(otherHash, lastResultsHash), expectedErr: "reference rpc"},
+		{name: "last results hash mismatch with reference rpc", nextBlock: header(appHash, lastResultsHash), rpcBlock: header(appHash, otherHash), expectedErr: "reference rpc"},
+		{name: "next header is checked before the reference rpc", nextBlock: header(otherHash, lastResultsHash), rpcBlock: header(appHash, lastResultsHash), expectedErr: "header of block 101"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			referenceRpc := ""
+
+			if tt.rpcBlock != "" {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path != "/block" || r.URL.Query().Get("height") != "101" {
+						t.Errorf("unexpected request %s", r.URL)
+					}
+
+					_, _ = fmt.Fprintf(w, `{"result":{"block":%s}}`, tt.rpcBlock)
+				}))
+				defer server.Close()
+
+				referenceRpc = server.URL + "/"
+			}
+
+			err := checkAppHash(&app.CosmosApp{ConsensusEngine: engine}, 100, []byte(tt.nextBlock), referenceRpc)
+
+			if tt.expectedErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("expected error %q, found %v", tt.expectedErr, err)
+			}
+
+			if !errors.Is(err, utils.ErrAppExecution) {
+				t.Fatalf("expected app execution error, found %v", err)
+			}
+
+			if exitCode := utils.GetExitCode(err); exitCode == utils.ExitCodeError {
+				t.Fatalf("expected dedicated exit code for app execution errors, found %d", exitCode)
+			}
+		})
+	}
+}
@@ -14,6 +14,11 @@ import (
 func Start() error {
 	logger.Logger.Info().Msg("starting block-sync")
 
+	// without a target height the sync never finishes, so there would be no handoff
+	if flags.Handoff && flags.TargetHeight == 0 && flags.TargetUpgrade == "" && flags.TargetTime == "" {
+		return fmt.Errorf("handoff requires a target height, please provide it with --target-height")
+	}
+
 	app, err := app.NewCosmosApp()
 	if err != nil {
 		return fmt.Errorf("failed to init cosmos app: %w", err)
@@ -28,11 +33,15 @@ func Start() error {
 	continuationHeight := app.GetContinuationHeight()
 	metrics.SetContinuationHeight(continuationHeight)
 
-	blockCollector, err := getBlockCollector(app)
+	blockCollector, err := GetBlockCollector(app)
 	if err != nil {
 		return err
 	}
 
+	if err := ResolveTargetHeight(app, func() (types.BlockCollector, error) { return blockCollector, nil }); err != nil {
+		return err
+	}
+
 	if err := PerformBlockSyncValidationChecks(blockCollector, continuationHeight, flags.TargetHeight); err != nil {
 		return fmt.Errorf("block-sync validation checks failed: %w", err)
 	}
@@ -53,12 +62,12 @@ func Start() error {
 
 	// we only pass the snapshot collector to the block executor if we are creating
 	// state-sync snapshots with serve-snapshots
-	if err := StartBlockSyncExecutor(app, blockCollector, nil); err != nil {
+	if err := StartBlockSyncExecutor(app, blockCollector, nil, nil); err != nil {
 		return fmt.Errorf("failed to start block-sync executor: %w", err)
 	}
 
 	logger.Logger.Info().Str("duration", metrics.GetSyncDuration().String()).Msgf("successfully finished block-sync by reaching target height %d", flags.TargetHeight)
-	return nil
+	return app.Handoff()
 }
 
 // PerformBlockSyncValidationChecks makes boundary checks if app can be block-synced from the given
@@ -92,7 +101,7 @@ func PerformBlockSyncValidationChecks(blockCollector types.BlockCollector, conti
 	return nil
 }
 
-func getBlockCollector(app *app.CosmosApp) (types.BlockCollector, error) {
+func GetBlockCollector(app *app.CosmosApp) (types.BlockCollector, error) {
 	if flags.BlockRpc != "" {
 		blockCollector, err := collector.NewRpcBlockCollector(flags.BlockRpc, flags.BlockRpcReqTimeout)
 		if err != nil {
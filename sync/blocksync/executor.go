@@ -16,7 +16,7 @@ var (
 	errorCh = make(chan error)
 )
 
-func StartBlockSyncExecutor(app *app.CosmosApp, blockCollector types.BlockCollector, snapshotCollector types.SnapshotCollector) error {
+func StartBlockSyncExecutor(app *app.CosmosApp, blockCollector types.BlockCollector, snapshotCollector types.SnapshotCollector, snapshotExporter *SnapshotExporter) error {
 	if blockCollector == nil {
 		return fmt.Errorf("block collector can't be nil")
 	}
@@ -27,6 +27,16 @@ func StartBlockSyncExecutor(app *app.CosmosApp, blockCollector types.BlockCollec
 
 	continuationHeight := app.GetContinuationHeight()
 
+	// the app creates snapshots on this interval if KSYNC serves or exports them
+	snapshotInterval := int64(0)
+	if snapshotCollector != nil {
+		snapshotInterval = snapshotCollector.GetInterval()
+	} else if snapshotExporter != nil {
+		snapshotInterval = snapshotExporter.GetInterval()
+	}
+
+	appHashCheckInterval := getAppHashCheckInterval()
+
 	go blockCollector.StreamBlocks(blockCh, errorCh, continuationHeight, flags.TargetHeight)
 
 	appHeight, err := app.ConsensusEngine.GetAppHeight()
@@ -42,6 +52,14 @@ func StartBlockSyncExecutor(app *app.CosmosApp, blockCollector types.BlockCollec
 		go app.ConsensusEngine.StartRPCServer(flags.RpcServerPort)
 	}
 
+	// the snapshot p2p server can only be started after the bootstrap since
+	// applying the first block over P2P uses the same P2P listen address
+	if flags.SnapshotP2P && snapshotCollector != nil {
+		if err := app.StartSnapshotP2PServer(); err != nil {
+			return fmt.Errorf("failed to start snapshot p2p server: %w", err)
+		}
+	}
+
 	snapshotPoolHeight := int64(0)
 
 	// if KSYNC has already fetched 3 * snapshot_interval ahead of the snapshot pool we wait
@@ -67,7 +85,14 @@ func StartBlockSyncExecutor(app *app.CosmosApp, blockCollector types.BlockCollec
 		}
 	}
 
-	block := <-blockCh
+	// the collector can already fail before it sends the first block
+	var block *types.BlockItem
+
+	select {
+	case err := <-errorCh:
+		return fmt.Errorf("error in block collector: %w", err)
+	case block = <-blockCh:
+	}
 
 	for {
 		select {
@@ -80,74 +105,77 @@ func StartBlockSyncExecutor(app *app.CosmosApp, blockCollector types.BlockCollec
 				// before we return we check if this is due to an upgrade, if we are running
 				// with cosmovisor, and it is indeed due to an upgrade we restart the binary
 				// and the cosmos app to apply it
-				if !app.IsCosmovisor() || !utils.IsUpgradeHeight(app.GetHomePath(), block.Height) {
+				// the engine returns the class of the error, e.g. blocks which fail the validation
+				if !utils.IsUpgradeHeight(app.GetHomePath(), block.Height) {
 					return fmt.Errorf("failed to apply block in engine: %w", err)
 				}
 
+				// without cosmovisor the upgrade has to be installed manually
+				if !app.IsCosmovisor() {
+					return utils.NewClassErrorf(utils.ErrUpgradeRequired, "failed to apply block %d which requires an upgrade, install it and restart: %w", block.Height, err)
+				}
+
 				app.StopAll()
 
 				if err := app.LoadConsensusEngine(); err != nil {
 					return fmt.Errorf("failed to reload engine: %w", err)
 				}
 
-				if snapshotCollector != nil {
-					if err := app.StartAll(snapshotCollector.GetInterval()); err != nil {
-						return err
-					}
-				} else {
-					if err := app.StartAll(0); err != nil {
-						return err
-					}
+				if err := app.StartAll(snapshotInterval); err != nil {
+					return err
 				}
 
 				if err := app.ConsensusEngine.DoHandshake(); err != nil {
 					return fmt.Errorf("failed to do handshake: %w", err)
 				}
 
+				// the handshake replayed the upgrade block with the new binary, so we check
+				// its state like for every other block since an upgrade is the most likely
+				// place for the app to diverge
+				if isAppHashCheckHeight(appHashCheckInterval, block.Height) {
+					if err := checkAppHash(app, block.Height, nextBlock.Block, flags.AppHashRpc); err != nil {
+						return err
+					}
+				}
+
 				block = nextBlock
 				continue
 			}
 
-			if snapshotCollector != nil {
-				// prune unused blocks for serve-snapshots
-				if flags.Pruning && block.Height%utils.PruningInterval == 0 {
-					// Because we sync 3 * snapshot_interval ahead we keep the latest
-					// 6 * snapshot_interval blocks and prune everything before that
-					pruneFromHeight := app.ConsensusEngine.GetBaseHeight()
-					pruneToHeight := app.ConsensusEngine.GetHeight() - (utils.SnapshotPruningWindowFactor * snapshotCollector.GetInterval())
-
-					if pruneToHeight > pruneFromHeight {
-						if err := app.ConsensusEngine.PruneBlocks(pruneToHeight); err != nil {
-							return fmt.Errorf("failed to prune blocks from %d to %d: %w", pruneFromHeight, pruneToHeight, err)
-						}
+			// compare the state the app computed with the header of the next block
+			// and optionally with a reference rpc if enabled
+			if isAppHashCheckHeight(appHashCheckInterval, block.Height) {
+				if err := checkAppHash(app, block.Height, nextBlock.Block, flags.AppHashRpc); err != nil {
+					return err
+				}
+			}
+
+			// prune unused blocks for serve-snapshots and export-snapshots
+			if snapshotInterval > 0 && flags.Pruning && block.Height%utils.PruningInterval == 0 {
+				// Because serve-snapshots syncs 3 * snapshot_interval ahead we keep the
+				// latest 6 * snapshot_interval blocks and prune everything before that
+				pruneFromHeight := app.ConsensusEngine.GetBaseHeight()
+				pruneToHeight := app.ConsensusEngine.GetHeight() - (utils.SnapshotPruningWindowFactor * snapshotInterval)
 
-						logger.Logger.Info().Msgf("successfully pruned blocks from %d to %d", pruneFromHeight, pruneToHeight)
-					} else {
-						logger.Logger.Info().Msg("found no blocks to prune. Continuing ...")
+				if pruneToHeight > pruneFromHeight {
+					if err := app.ConsensusEngine.PruneBlocks(pruneToHeight); err != nil {
+						return fmt.Errorf("failed to prune blocks from %d to %d: %w", pruneFromHeight, pruneToHeight, err)
 					}
+
+					logger.Logger.Info().Msgf("successfully pruned blocks from %d to %d", pruneFromHeight, pruneToHeight)
+				} else {
+					logger.Logger.Info().Msg("found no blocks to prune. Continuing ...")
 				}
+			}
 
+			if snapshotCollector != nil {
 				// wait until snapshot got created if we are on the snapshot interval,
 				// else if KSYNC moves to fast the snapshot can not be properly written
 				// to disk. We check if the initial app height is smaller than the current
 				// applied height since in this case the app has not created the snapshot yet.
 				if block.Height%snapshotCollector.GetInterval() == 0 && appHeight < block.Height {
-					for {
-						logger.Logger.Info().Msg(fmt.Sprintf("waiting until snapshot at height %d is created by cosmos app", block.Height))
-
-						found, err := app.ConsensusEngine.IsSnapshotAvailable(block.Height)
-						if err != nil {
-							return fmt.Errorf("failed to check if snapshot is available at height %d: %w", block.Height, err)
-						}
-
-						if !found {
-							logger.Logger.Info().Msg(fmt.Sprintf("snapshot at height %d was not created yet. Waiting ...", block.Height))
-							time.Sleep(10 * time.Second)
-							continue
-						}
-
-						logger.Logger.Info().Msg(fmt.Sprintf("snapshot at height %d was successfully created. Continuing ...", block.Height))
-						break
+					if err := waitForSnapshot(app, block.Height); err != nil {
+						return err
 					}
 
 					// refresh snapshot pool height here, because we don't want to fetch this on every block
@@ -176,6 +204,13 @@ func StartBlockSyncExecutor(app *app.CosmosApp, blockCollector types.BlockCollec
 				}
 			}
 
+			// export the snapshots created by the app to the local snapshot directory
+			if snapshotExporter != nil {
+				if err := snapshotExporter.onBlockApplied(app, block.Height, appHeight); err != nil {
+					return fmt.Errorf("failed to export snapshot: %w", err)
+				}
+			}
+
 			metrics.SetLatestHeight(block.Height)
 
 			// stop with block execution if we have reached our target height
@@ -0,0 +1,199 @@
+package blocksync
+
+import (
+	"encoding/json"
+	"fmt"
+	tmJson "github.com/KYVENetwork/cometbft/v34/libs/json"
+	"github.com/KYVENetwork/ksync/app"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/types"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SnapshotExporter writes the snapshots the app creates on the snapshot interval
+// to a local directory. Every snapshot gets its own folder named after its height
+// which contains one file per chunk in the SnapshotDataItem layout of KYVE
+// snapshot pools, so every chunk contains the snapshot, state, seen commit and block
+type SnapshotExporter struct {
+	dir      string
+	interval int64
+}
+
+func NewSnapshotExporter(dir string, interval int64) (*SnapshotExporter, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("snapshot interval has to be greater than zero")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory %s: %w", dir, err)
+	}
+
+	return &SnapshotExporter{
+		dir:      dir,
+		interval: interval,
+	}, nil
+}
+
+func (exporter *SnapshotExporter) GetInterval() int64 {
+	return exporter.interval
+}
+
+// GetTargetHeight returns the height block-sync has to reach for exporting all snapshots
+// up to the target height. Since snapshots are exported two blocks after their height a
+// snapshot at the target height or one below requires the sync to continue after it
+func (exporter *SnapshotExporter) GetTargetHeight(targetHeight int64) int64 {
+	if targetHeight <= 0 {
+		return targetHeight
+	}
+
+	if snapshotHeight := targetHeight - targetHeight%exporter.interval; snapshotHeight > 0 && snapshotHeight > targetHeight-2 {
+		return snapshotHeight + 2
+	}
+
+	return targetHeight
+}
+
+// onBlockApplied gets called after every applied block. It waits until the app created
+// the snapshot on the interval and exports it once the blocks required for rebuilding
+// the state are in the blockstore
+func (exporter *SnapshotExporter) onBlockApplied(app *app.CosmosApp, height, appHeight int64) error {
+	if height%exporter.interval == 0 && appHeight < height {
+		if err := waitForSnapshot(app, height); err != nil {
+			return err
+		}
+	}
+
+	// rebuilding the state of a snapshot at height h requires the blocks
+	// h+1 and h+2, so we export the snapshot two blocks later
+	snapshotHeight := height - 2
+
+	if snapshotHeight <= 0 || snapshotHeight%exporter.interval != 0 || appHeight >= snapshotHeight {
+		return nil
+	}
+
+	return exporter.exportSnapshot(app, snapshotHeight)
+}
+
+func (exporter *SnapshotExporter) exportSnapshot(app *app.CosmosApp, height int64) error {
+	snapshotDir := filepath.Join(exporter.dir, fmt.Sprintf("%d", height))
+
+	if _, err := os.Stat(snapshotDir); err == nil {
+		logger.Logger.Info().Msgf("snapshot at height %d was already exported. Skipping ...", height)
+		return nil
+	}
+
+	rawSnapshots, err := app.ConsensusEngine.GetSnapshots()
+	if err != nil {
+		return fmt.Errorf("failed to get snapshots: %w", err)
+	}
+
+	var snapshots []json.RawMessage
+	if err := json.Unmarshal(rawSnapshots, &snapshots); err != nil {
+		return fmt.Errorf("failed to unmarshal snapshots: %w", err)
+	}
+
+	var rawSnapshot json.RawMessage
+	var snapshot types.Snapshot
+
+	for _, s := range snapshots {
+		if err := tmJson.Unmarshal(s, &snapshot); err != nil {
+			return fmt.Errorf("failed to unmarshal snapshot: %w", err)
+		}
+
+		if int64(snapshot.Height) == height {
+			rawSnapshot = s
+			break
+		}
+	}
+
+	if rawSnapshot == nil {
+		return fmt.Errorf("snapshot at height %d not found in app", height)
+	}
+
+	state, err := app.ConsensusEngine.GetState(height)
+	if err != nil {
+		return fmt.Errorf("failed to get state at height %d: %w", height, err)
+	}
+
+	seenCommit, err := app.ConsensusEngine.GetSeenCommit(height)
+	if err != nil {
+		return fmt.Errorf("failed to get seen commit at height %d: %w", height, err)
+	}
+
+	block, err := app.ConsensusEngine.GetBlock(height)
+	if err != nil {
+		return fmt.Errorf("failed to get block at height %d: %w", height, err)
+	}
+
+	// we write the chunks into a temporary folder first and rename it once
+	// complete so that only fully exported snapshots are visible
+	tmpDir := fmt.Sprintf("%s.tmp", snapshotDir)
+
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return fmt.Errorf("failed to remove temporary snapshot directory %s: %w", tmpDir, err)
+	}
+
+	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create temporary snapshot directory %s: %w", tmpDir, err)
+	}
+
+	for chunkIndex := int64(0); chunkIndex < int64(snapshot.Chunks); chunkIndex++ {
+		rawChunk, err := app.ConsensusEngine.GetSnapshotChunk(height, int64(snapshot.Format), chunkIndex)
+		if err != nil {
+			return fmt.Errorf("failed to get snapshot chunk %d at height %d: %w", chunkIndex, height, err)
+		}
+
+		dataItem := types.SnapshotDataItem{Key: fmt.Sprintf("%d/%d", height, chunkIndex)}
+		dataItem.Value.ChunkIndex = uint32(chunkIndex)
+		dataItem.Value.Snapshot = rawSnapshot
+		dataItem.Value.State = state
+		dataItem.Value.SeenCommit = seenCommit
+		dataItem.Value.Block = block
+
+		if err := json.Unmarshal(rawChunk, &dataItem.Value.Chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal snapshot chunk %d at height %d: %w", chunkIndex, height, err)
+		}
+
+		data, err := json.Marshal(dataItem)
+		if err != nil {
+			return fmt.Errorf("failed to marshal snapshot chunk %d at height %d: %w", chunkIndex, height, err)
+		}
+
+		if err := os.WriteFile(filepath.Join(tmpDir, fmt.Sprintf("%d.json", chunkIndex)), data, 0o644); err != nil {
+			return fmt.Errorf("failed to write snapshot chunk %d at height %d: %w", chunkIndex, height, err)
+		}
+
+		logger.Logger.Debug().Int64("height", height).Int64("chunk", chunkIndex).Msg("exported snapshot chunk")
+	}
+
+	if err := os.Rename(tmpDir, snapshotDir); err != nil {
+		return fmt.Errorf("failed to move snapshot to %s: %w", snapshotDir, err)
+	}
+
+	logger.Logger.Info().Msgf("exported snapshot at height %d with %d chunks to %s", height, snapshot.Chunks, snapshotDir)
+	return nil
+}
+
+// waitForSnapshot waits until the app created the snapshot at the given height. Else,
+// if KSYNC moves to fast the snapshot can not be properly written to disk
+func waitForSnapshot(app *app.CosmosApp, height int64) error {
+	for {
+		logger.Logger.Info().Msg(fmt.Sprintf("waiting until snapshot at height %d is created by cosmos app", height))
+
+		found, err := app.ConsensusEngine.IsSnapshotAvailable(height)
+		if err != nil {
+			return fmt.Errorf("failed to check if snapshot is available at height %d: %w", height, err)
+		}
+
+		if !found {
+			logger.Logger.Info().Msg(fmt.Sprintf("snapshot at height %d was not created yet. Waiting ...", height))
+			time.Sleep(10 * time.Second)
+			continue
+		}
+
+		logger.Logger.Info().Msg(fmt.Sprintf("snapshot at height %d was successfully created. Continuing ...", height))
+		return nil
+	}
+}
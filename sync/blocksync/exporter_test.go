@@ -0,0 +1,109 @@
+package blocksync
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/app"
+	"github.com/KYVENetwork/ksync/types"
+)
+
+// fakeEngine serves a snapshot with three chunks at height 100, all other
+// methods of the engine are not implemented
+type fakeEngine struct {
+	types.Engine
+}
+
+func (fakeEngine) GetSnapshots() ([]byte, error) {
+	return []byte(`[{"height":"50","format":2,"chunks":1},{"height":"100","format":2,"chunks":3}]`), nil
+}
+
+func (fakeEngine) GetSnapshotChunk(height, format, chunk int64) ([]byte, error) {
+	return json.Marshal([]byte(fmt.Sprintf("chunk %d/%d/%d", height, format, chunk)))
+}
+
+func (fakeEngine) GetState(height int64) ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"state":"%d"}`, height)), nil
+}
+
+func (fakeEngine) GetSeenCommit(height int64) ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"commit":"%d"}`, height)), nil
+}
+
+func (fakeEngine) GetBlock(height int64) ([]byte, error) {
+	return []byte(fmt.Sprintf(`{"block":"%d"}`, height)), nil
+}
+
+func TestExportSnapshot(t *testing.T) {
+	exporter, err := NewSnapshotExporter(t.TempDir(), 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := exporter.exportSnapshot(&app.CosmosApp{ConsensusEngine: fakeEngine{}}, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	// every chunk has the same layout as the data items of a snapshot pool
+	for chunkIndex := 0; chunkIndex < 3; chunkIndex++ {
+		data, err := os.ReadFile(filepath.Join(exporter.dir, "100", fmt.Sprintf("%d.json", chunkIndex)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var dataItem types.SnapshotDataItem
+		if err := json.Unmarshal(data, &dataItem); err != nil {
+			t.Fatal(err)
+		}
+
+		if dataItem.Key != fmt.Sprintf("100/%d", chunkIndex) || dataItem.Value.ChunkIndex != uint32(chunkIndex) {
+			t.Fatalf("expected chunk %d of snapshot 100, found key %s and chunk %d", chunkIndex, dataItem.Key, dataItem.Value.ChunkIndex)
+		}
+
+		if string(dataItem.Value.Chunk) != fmt.Sprintf("chunk 100/2/%d", chunkIndex) {
+			t.Fatalf("expected chunk data of chunk %d, found %s", chunkIndex, dataItem.Value.Chunk)
+		}
+
+		if string(dataItem.Value.Snapshot) != `{"height":"100","format":2,"chunks":3}` ||
+			string(dataItem.Value.State) != `{"state":"100"}` ||
+			string(dataItem.Value.SeenCommit) != `{"commit":"100"}` ||
+			string(dataItem.Value.Block) != `{"block":"100"}` {
+			t.Fatalf("expected snapshot, state, seen commit and block in chunk %d, found %s", chunkIndex, data)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(exporter.dir, "100", "3.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected only 3 chunks, found %v", err)
+	}
+}
+
+func TestGetTargetHeight(t *testing.T) {
+	exporter, err := NewSnapshotExporter(t.TempDir(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		targetHeight int64
+		expected     int64
+	}{
+		{targetHeight: 0, expected: 0},
+		{targetHeight: 50, expected: 50},
+		{targetHeight: 100, expected: 102},
+		{targetHeight: 101, expected: 102},
+		{targetHeight: 102, expected: 102},
+		{targetHeight: 150, expected: 150},
+		{targetHeight: 200, expected: 202},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d", tt.targetHeight), func(t *testing.T) {
+			if targetHeight := exporter.GetTargetHeight(tt.targetHeight); targetHeight != tt.expected {
+				t.Fatalf("expected target height %d, found %d", tt.expected, targetHeight)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package blocksync
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/KYVENetwork/ksync/app"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/types"
+	"time"
+)
+
+// ResolveTargetHeight sets the target height from --target-upgrade or --target-time. The
+// target of an upgrade is the last height before the upgrade and the target of a time is
+// the last block created at or before that time. The block collector is only required
+// for --target-time
+func ResolveTargetHeight(app *app.CosmosApp, getBlockCollector func() (types.BlockCollector, error)) error {
+	if flags.TargetUpgrade == "" && flags.TargetTime == "" {
+		return nil
+	}
+
+	if flags.TargetHeight > 0 || (flags.TargetUpgrade != "" && flags.TargetTime != "") {
+		return fmt.Errorf("only one of --target-height, --target-upgrade and --target-time can be provided")
+	}
+
+	if flags.TargetUpgrade != "" {
+		upgradeHeight, err := app.Source.GetUpgradeHeight(flags.TargetUpgrade)
+		if err != nil {
+			return fmt.Errorf("failed to get height of upgrade %s: %w", flags.TargetUpgrade, err)
+		}
+
+		if upgradeHeight <= 1 {
+			return fmt.Errorf("upgrade %s has no height before it", flags.TargetUpgrade)
+		}
+
+		flags.TargetHeight = upgradeHeight - 1
+		logger.Logger.Info().Msgf("resolved target height %d from the last height before upgrade \"%s\"", flags.TargetHeight, flags.TargetUpgrade)
+		return nil
+	}
+
+	targetTime, err := time.Parse(time.RFC3339, flags.TargetTime)
+	if err != nil {
+		return fmt.Errorf("failed to parse target time %s, expected RFC3339 format like \"2024-06-01T00:00:00Z\": %w", flags.TargetTime, err)
+	}
+
+	blockCollector, err := getBlockCollector()
+	if err != nil {
+		return fmt.Errorf("failed to get blocks to resolve --target-time, provide a block source with --block-pool-id or --block-rpc: %w", err)
+	}
+
+	targetHeight, err := findHeightForTime(blockCollector, targetTime)
+	if err != nil {
+		return fmt.Errorf("failed to find height for target time %s: %w", flags.TargetTime, err)
+	}
+
+	flags.TargetHeight = targetHeight
+	logger.Logger.Info().Msgf("resolved target height %d from target time %s", flags.TargetHeight, flags.TargetTime)
+	return nil
+}
+
+// findHeightForTime binary searches the last block which was created at or before the
+// given time. Every probe gets all blocks retrieved along with the probed block, e.g. its
+// whole bundle, so the search only needs logarithmic many bundles and finishes inside one
+func findHeightForTime(blockCollector types.BlockCollector, targetTime time.Time) (int64, error) {
+	earliest, latest := blockCollector.GetEarliestAvailableHeight(), blockCollector.GetLatestAvailableHeight()
+
+	// invariant: the last block created at or before the target time is between low and high
+	low, high := earliest, latest
+
+	// narrow moves low and high to the blocks of the probe, since block times are
+	// monotonic only the last block at or before the target time is of interest
+	narrow := func(from int64, times []time.Time) {
+		last := -1
+		for i, blockTime := range times {
+			if blockTime.After(targetTime) {
+				break
+			}
+			last = i
+		}
+
+		if last < 0 {
+			high = min(high, from-1)
+			return
+		}
+
+		low = max(low, from+int64(last))
+
+		// the next block was created after the target time
+		if last < len(times)-1 {
+			high = min(high, from+int64(last))
+		}
+	}
+
+	from, times, err := getBlockTimes(blockCollector, earliest)
+	if err != nil {
+		return 0, err
+	}
+
+	if earliestTime := times[earliest-from]; earliestTime.After(targetTime) {
+		return 0, fmt.Errorf("earliest available block %d was created at %s after the target time", earliest, earliestTime.Format(time.RFC3339))
+	}
+
+	narrow(from, times)
+
+	// if a block after the target time was found the latest block can not be before it
+	if high == latest {
+		from, times, err = getBlockTimes(blockCollector, latest)
+		if err != nil {
+			return 0, err
+		}
+
+		if latestTime := times[latest-from]; latestTime.Before(targetTime) {
+			return 0, fmt.Errorf("latest available block %d was created at %s before the target time", latest, latestTime.Format(time.RFC3339))
+		}
+
+		narrow(from, times)
+	}
+
+	for low < high {
+		mid := low + (high-low+1)/2
+
+		from, times, err := getBlockTimes(blockCollector, mid)
+		if err != nil {
+			return 0, err
+		}
+
+		logger.Logger.Debug().Int64("from_height", from).Int64("to_height", from+int64(len(times))-1).Msg("searching target time")
+
+		narrow(from, times)
+	}
+
+	return low, nil
+}
+
+// getBlockTimes returns the height of the first block and the times of all blocks which
+// are retrieved along with the block of the given height
+func getBlockTimes(blockCollector types.BlockCollector, height int64) (int64, []time.Time, error) {
+	from, rawBlocks, err := blockCollector.GetBlocks(height)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get block %d: %w", height, err)
+	}
+
+	if height < from || height-from >= int64(len(rawBlocks)) {
+		return 0, nil, fmt.Errorf("block %d is not in the retrieved blocks %d to %d", height, from, from+int64(len(rawBlocks))-1)
+	}
+
+	times := make([]time.Time, len(rawBlocks))
+
+	for i, rawBlock := range rawBlocks {
+		var block struct {
+			Header struct {
+				Time time.Time `json:"time"`
+			} `json:"header"`
+		}
+
+		if err := json.Unmarshal(rawBlock, &block); err != nil {
+			return 0, nil, fmt.Errorf("failed to unmarshal block %d: %w", from+int64(i), err)
+		}
+
+		times[i] = block.Header.Time
+	}
+
+	return from, times, nil
+}
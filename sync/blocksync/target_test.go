@@ -0,0 +1,123 @@
+package blocksync
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/types"
+)
+
+var testGenesisTime = time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// bundledBlockCollector serves blocks in bundles like a block pool, the blocks are
+// created every five seconds and every tenth block takes a minute
+type bundledBlockCollector struct {
+	types.BlockCollector
+	earliest, latest, bundleSize int64
+	requests                     int
+}
+
+func (collector *bundledBlockCollector) blockTime(height int64) time.Time {
+	slow := (height - collector.earliest) / 10
+	return testGenesisTime.Add(time.Duration(height-collector.earliest-slow)*5*time.Second + time.Duration(slow)*time.Minute)
+}
+
+func (collector *bundledBlockCollector) GetEarliestAvailableHeight() int64 {
+	return collector.earliest
+}
+
+func (collector *bundledBlockCollector) GetLatestAvailableHeight() int64 {
+	return collector.latest
+}
+
+func (collector *bundledBlockCollector) GetBlocks(height int64) (int64, [][]byte, error) {
+	collector.requests++
+
+	from := height - (height-collector.earliest)%collector.bundleSize
+	to := min(from+collector.bundleSize-1, collector.latest)
+
+	blocks := make([][]byte, 0, to-from+1)
+	for h := from; h <= to; h++ {
+		blocks = append(blocks, []byte(fmt.Sprintf(`{"header":{"height":"%d","time":"%s"}}`, h, collector.blockTime(h).Format(time.RFC3339Nano))))
+	}
+
+	return from, blocks, nil
+}
+
+func TestFindHeightForTime(t *testing.T) {
+	collector := &bundledBlockCollector{earliest: 1, latest: 100_000, bundleSize: 100}
+
+	tests := []struct {
+		name       string
+		targetTime time.Time
+		expected   int64
+	}{
+		{name: "earliest block", targetTime: collector.blockTime(1), expected: 1},
+		{name: "latest block", targetTime: collector.blockTime(100_000), expected: 100_000},
+		{name: "block in the middle", targetTime: collector.blockTime(54_321), expected: 54_321},
+		{name: "between two blocks", targetTime: collector.blockTime(54_321).Add(time.Second), expected: 54_321},
+		{name: "last block of a bundle", targetTime: collector.blockTime(54_400), expected: 54_400},
+		{name: "first block of a bundle", targetTime: collector.blockTime(54_401), expected: 54_401},
+		{name: "before the first block of a bundle", targetTime: collector.blockTime(54_401).Add(-time.Second), expected: 54_400},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector.requests = 0
+
+			height, err := findHeightForTime(collector, tt.targetTime)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if height != tt.expected {
+				t.Fatalf("expected height %d, found %d", tt.expected, height)
+			}
+
+			// with 1000 bundles the search needs about log2(1000) bundles
+			if collector.requests > 12 {
+				t.Fatalf("expected at most 12 requests, found %d", collector.requests)
+			}
+		})
+	}
+}
+
+func TestFindHeightForTimeOutOfRange(t *testing.T) {
+	collector := &bundledBlockCollector{earliest: 1001, latest: 5000, bundleSize: 100}
+
+	for _, targetTime := range []time.Time{collector.blockTime(1001).Add(-time.Second), collector.blockTime(5000).Add(time.Second)} {
+		if height, err := findHeightForTime(collector, targetTime); err == nil {
+			t.Fatalf("expected target time %s to be out of range, found height %d", targetTime, height)
+		}
+	}
+}
+
+func TestResolveTargetHeightFromTime(t *testing.T) {
+	targetHeight, targetTime := flags.TargetHeight, flags.TargetTime
+	t.Cleanup(func() { flags.TargetHeight, flags.TargetTime = targetHeight, targetTime })
+
+	collector := &bundledBlockCollector{earliest: 1001, latest: 5000, bundleSize: 100}
+
+	flags.TargetHeight, flags.TargetTime = 0, collector.blockTime(2345).Format(time.RFC3339)
+
+	if err := ResolveTargetHeight(nil, func() (types.BlockCollector, error) { return collector, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	if flags.TargetHeight != 2345 {
+		t.Fatalf("expected target height 2345, found %d", flags.TargetHeight)
+	}
+
+	// state-sync has no block source if the source is not in the registry and no block flags were provided
+	flags.TargetHeight = 0
+
+	err := ResolveTargetHeight(nil, func() (types.BlockCollector, error) {
+		return nil, fmt.Errorf("failed to get block pool id: source with id \"unknown\" not found in registry")
+	})
+	if err == nil || !strings.Contains(err.Error(), "--block-pool-id or --block-rpc") {
+		t.Fatalf("expected error naming the block source flags, found %v", err)
+	}
+}
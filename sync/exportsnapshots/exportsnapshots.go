@@ -0,0 +1,64 @@
+package exportsnapshots
+
+import (
+	"fmt"
+	"github.com/KYVENetwork/ksync/app"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/metrics"
+	"github.com/KYVENetwork/ksync/sync/blocksync"
+)
+
+func Start() error {
+	logger.Logger.Info().Msg("starting export-snapshots")
+
+	app, err := app.NewCosmosApp()
+	if err != nil {
+		return fmt.Errorf("failed to init cosmos app: %w", err)
+	}
+
+	if flags.Reset {
+		if err := app.ConsensusEngine.ResetAll(true); err != nil {
+			return fmt.Errorf("failed to reset cosmos app: %w", err)
+		}
+	}
+
+	snapshotExporter, err := blocksync.NewSnapshotExporter(flags.SnapshotDir, flags.SnapshotInterval)
+	if err != nil {
+		return fmt.Errorf("failed to init snapshot exporter: %w", err)
+	}
+
+	if targetHeight := snapshotExporter.GetTargetHeight(flags.TargetHeight); targetHeight != flags.TargetHeight {
+		logger.Logger.Info().Msgf("syncing to height %d to export the snapshot at height %d", targetHeight, targetHeight-2)
+		flags.TargetHeight = targetHeight
+	}
+
+	continuationHeight := app.GetContinuationHeight()
+	metrics.SetContinuationHeight(continuationHeight)
+
+	blockCollector, err := blocksync.GetBlockCollector(app)
+	if err != nil {
+		return err
+	}
+
+	if err := blocksync.PerformBlockSyncValidationChecks(blockCollector, continuationHeight, flags.TargetHeight); err != nil {
+		return fmt.Errorf("block-sync validation checks failed: %w", err)
+	}
+
+	if err := app.AutoSelectBinaryVersion(continuationHeight); err != nil {
+		return fmt.Errorf("failed to auto select binary version: %w", err)
+	}
+
+	if err := app.StartAll(snapshotExporter.GetInterval()); err != nil {
+		return fmt.Errorf("failed to start app: %w", err)
+	}
+
+	defer app.StopAll()
+
+	if err := blocksync.StartBlockSyncExecutor(app, blockCollector, nil, snapshotExporter); err != nil {
+		return fmt.Errorf("failed to start block-sync executor: %w", err)
+	}
+
+	logger.Logger.Info().Str("duration", metrics.GetSyncDuration().String()).Msgf("successfully finished export-snapshots")
+	return nil
+}
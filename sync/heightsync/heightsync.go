@@ -3,18 +3,23 @@ package heightsync
 import (
 	"fmt"
 	"github.com/KYVENetwork/ksync/app"
-	"github.com/KYVENetwork/ksync/app/collector"
 	"github.com/KYVENetwork/ksync/flags"
 	"github.com/KYVENetwork/ksync/logger"
 	"github.com/KYVENetwork/ksync/metrics"
 	"github.com/KYVENetwork/ksync/sync/blocksync"
 	"github.com/KYVENetwork/ksync/sync/statesync"
+	"github.com/KYVENetwork/ksync/types"
 	"github.com/KYVENetwork/ksync/utils"
 )
 
 func Start() error {
 	logger.Logger.Info().Msg("starting height-sync")
 
+	// without a target height the sync never finishes, so there would be no handoff
+	if flags.Handoff && flags.TargetHeight == 0 && flags.TargetUpgrade == "" && flags.TargetTime == "" {
+		return fmt.Errorf("handoff requires a target height, please provide it with --target-height")
+	}
+
 	app, err := app.NewCosmosApp()
 	if err != nil {
 		return fmt.Errorf("failed to init cosmos app: %w", err)
@@ -26,24 +31,18 @@ func Start() error {
 		}
 	}
 
-	snapshotPoolId, err := app.Source.GetSourceSnapshotPoolId()
-	if err != nil {
-		return fmt.Errorf("failed to get snapshot pool id: %w", err)
-	}
-
-	blockPoolId, err := app.Source.GetSourceBlockPoolId()
+	snapshotCollector, err := statesync.GetSnapshotCollector(app)
 	if err != nil {
-		return fmt.Errorf("failed to get block pool id: %w", err)
+		return err
 	}
 
-	snapshotCollector, err := collector.NewKyveSnapshotCollector(snapshotPoolId, app.GetChainRest())
+	blockCollector, err := blocksync.GetBlockCollector(app)
 	if err != nil {
-		return fmt.Errorf("failed to init kyve snapshot collector: %w", err)
+		return err
 	}
 
-	blockCollector, err := collector.NewKyveBlockCollector(blockPoolId, app.GetChainRest())
-	if err != nil {
-		return fmt.Errorf("failed to init kyve block collector: %w", err)
+	if err := blocksync.ResolveTargetHeight(app, func() (types.BlockCollector, error) { return blockCollector, nil }); err != nil {
+		return err
 	}
 
 	snapshotHeight := snapshotCollector.GetSnapshotHeight(flags.TargetHeight, false)
@@ -102,13 +101,13 @@ func Start() error {
 			}
 		}
 
-		if err := blocksync.StartBlockSyncExecutor(app, blockCollector, nil); err != nil {
+		if err := blocksync.StartBlockSyncExecutor(app, blockCollector, nil, nil); err != nil {
 			return fmt.Errorf("failed to start block-sync executor: %w", err)
 		}
 	}
 
 	logger.Logger.Info().Str("duration", metrics.GetSyncDuration().String()).Msgf("successfully finished height-sync by reaching target height %d", flags.TargetHeight)
-	return nil
+	return app.Handoff()
 }
 
 func getUserConfirmation(y, canApplySnapshot bool, snapshotHeight, continuationHeight, targetHeight int64) (bool, error) {
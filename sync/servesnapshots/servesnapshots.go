@@ -109,7 +109,7 @@ func Start() error {
 
 		go startSnapshotApiServer(app)
 
-		if err := blocksync.StartBlockSyncExecutor(app, blockCollector, snapshotCollector); err != nil {
+		if err := blocksync.StartBlockSyncExecutor(app, blockCollector, snapshotCollector, nil); err != nil {
 			return fmt.Errorf("failed to start block-sync executor: %w", err)
 		}
 	}
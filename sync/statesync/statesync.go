@@ -7,6 +7,7 @@ import (
 	"github.com/KYVENetwork/ksync/flags"
 	"github.com/KYVENetwork/ksync/logger"
 	"github.com/KYVENetwork/ksync/metrics"
+	"github.com/KYVENetwork/ksync/sync/blocksync"
 	"github.com/KYVENetwork/ksync/types"
 	"github.com/KYVENetwork/ksync/utils"
 )
@@ -29,14 +30,13 @@ func Start() error {
 		return fmt.Errorf("app has to be reset for state-sync")
 	}
 
-	snapshotPoolId, err := app.Source.GetSourceSnapshotPoolId()
+	snapshotCollector, err := GetSnapshotCollector(app)
 	if err != nil {
-		return fmt.Errorf("failed to get snapshot pool id: %w", err)
+		return err
 	}
 
-	snapshotCollector, err := collector.NewKyveSnapshotCollector(snapshotPoolId, app.GetChainRest())
-	if err != nil {
-		return fmt.Errorf("failed to init kyve snapshot collector: %w", err)
+	if err := blocksync.ResolveTargetHeight(app, func() (types.BlockCollector, error) { return blocksync.GetBlockCollector(app) }); err != nil {
+		return err
 	}
 
 	snapshotHeight := snapshotCollector.GetSnapshotHeight(flags.TargetHeight, false)
@@ -69,7 +69,7 @@ func Start() error {
 	}
 
 	logger.Logger.Info().Str("duration", metrics.GetSyncDuration().String()).Msgf("successfully finished state-sync by applying snapshot at height %d", snapshotHeight)
-	return nil
+	return app.Handoff()
 }
 
 // PerformStateSyncValidationChecks makes boundary checks for the given snapshot height
@@ -90,6 +90,31 @@ func PerformStateSyncValidationChecks(snapshotCollector types.SnapshotCollector,
 	return nil
 }
 
+func GetSnapshotCollector(app *app.CosmosApp) (types.SnapshotCollector, error) {
+	if flags.SnapshotDir != "" {
+		snapshotCollector, err := collector.NewLocalSnapshotCollector(flags.SnapshotDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to init local snapshot collector: %w", err)
+		}
+
+		return snapshotCollector, nil
+	}
+
+	// if there is no entry in the source registry for the source
+	// and if no snapshot pool id was provided with the flags it would fail here
+	snapshotPoolId, err := app.Source.GetSourceSnapshotPoolId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot pool id: %w", err)
+	}
+
+	snapshotCollector, err := collector.NewKyveSnapshotCollector(snapshotPoolId, app.GetChainRest())
+	if err != nil {
+		return nil, fmt.Errorf("failed to init kyve snapshot collector: %w", err)
+	}
+
+	return snapshotCollector, nil
+}
+
 func getUserConfirmation(y bool, snapshotHeight, targetHeight int64) (bool, error) {
 	if y {
 		return true, nil
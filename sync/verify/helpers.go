@@ -0,0 +1,67 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/KYVENetwork/ksync/utils"
+	"strconv"
+	"strings"
+)
+
+// validatorsPerPage is the maximum page size allowed by the /validators rpc endpoint
+const validatorsPerPage = 100
+
+// getValidatorsFromRpc queries all pages of the validator set at the given height
+// from the rpc of the source chain and returns the validators as a raw json array
+func getValidatorsFromRpc(rpc string, height int64) ([]byte, error) {
+	validators := make([]json.RawMessage, 0)
+
+	for page := 1; ; page++ {
+		data, err := utils.GetFromUrl(fmt.Sprintf("%s/validators?height=%d&page=%d&per_page=%d", strings.TrimSuffix(rpc, "/"), height, page, validatorsPerPage))
+		if err != nil {
+			return nil, fmt.Errorf("failed to query validators at height %d: %w", height, err)
+		}
+
+		var response struct {
+			Result struct {
+				Validators []json.RawMessage `json:"validators"`
+				Total      string            `json:"total"`
+			} `json:"result"`
+		}
+
+		if err := json.Unmarshal(data, &response); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal validators response: %w", err)
+		}
+
+		total, err := strconv.Atoi(response.Result.Total)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse total validators %s: %w", response.Result.Total, err)
+		}
+
+		validators = append(validators, response.Result.Validators...)
+
+		if len(response.Result.Validators) == 0 || len(validators) >= total {
+			break
+		}
+	}
+
+	return json.Marshal(validators)
+}
+
+// getLastBlockHash extracts the hash of the previous block from the last commit
+// of the given raw block
+func getLastBlockHash(rawBlock []byte) (string, error) {
+	var block struct {
+		LastCommit struct {
+			BlockId struct {
+				Hash string `json:"hash"`
+			} `json:"block_id"`
+		} `json:"last_commit"`
+	}
+
+	if err := json.Unmarshal(rawBlock, &block); err != nil {
+		return "", fmt.Errorf("failed to unmarshal block: %w", err)
+	}
+
+	return block.LastCommit.BlockId.Hash, nil
+}
@@ -0,0 +1,85 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestGetValidatorsFromRpc(t *testing.T) {
+	tests := []struct {
+		name          string
+		total         int
+		expectedPages int
+	}{
+		{name: "single page", total: 5, expectedPages: 1},
+		{name: "full page", total: 100, expectedPages: 1},
+		{name: "multiple pages", total: 250, expectedPages: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := 0
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				pages++
+
+				if r.URL.Path != "/validators" || r.URL.Query().Get("height") != "42" {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+				perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+
+				validators := make([]string, 0)
+				for i := (page - 1) * perPage; i < min(page*perPage, tt.total); i++ {
+					validators = append(validators, fmt.Sprintf(`{"address":"%d"}`, i))
+				}
+
+				_, _ = fmt.Fprintf(w, `{"result":{"block_height":"42","validators":[%s],"count":"%d","total":"%d"}}`, strings.Join(validators, ","), len(validators), tt.total)
+			}))
+			defer server.Close()
+
+			rawValidators, err := getValidatorsFromRpc(server.URL+"/", 42)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var validators []struct {
+				Address string `json:"address"`
+			}
+			if err := json.Unmarshal(rawValidators, &validators); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(validators) != tt.total {
+				t.Fatalf("expected %d validators, found %d", tt.total, len(validators))
+			}
+
+			for i, validator := range validators {
+				if validator.Address != strconv.Itoa(i) {
+					t.Fatalf("expected validator %d at index %d, found %s", i, i, validator.Address)
+				}
+			}
+
+			if pages != tt.expectedPages {
+				t.Fatalf("expected %d pages, found %d", tt.expectedPages, pages)
+			}
+		})
+	}
+}
+
+func TestGetValidatorsFromRpcInvalidTotal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"result":{"validators":[],"total":""}}`)
+	}))
+	defer server.Close()
+
+	if _, err := getValidatorsFromRpc(server.URL, 42); err == nil {
+		t.Fatal("expected invalid total to fail")
+	}
+}
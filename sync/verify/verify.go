@@ -0,0 +1,171 @@
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/KYVENetwork/ksync/app"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/metrics"
+	"github.com/KYVENetwork/ksync/sync/blocksync"
+	"github.com/KYVENetwork/ksync/types"
+	"github.com/KYVENetwork/ksync/utils"
+	"os"
+	"time"
+)
+
+func Start() error {
+	logger.Logger.Info().Msg("starting verify")
+
+	app, err := app.NewCosmosApp()
+	if err != nil {
+		return fmt.Errorf("failed to init cosmos app: %w", err)
+	}
+
+	blockCollector, err := blocksync.GetBlockCollector(app)
+	if err != nil {
+		return err
+	}
+
+	startHeight := flags.StartHeight
+	if startHeight == 0 {
+		startHeight = app.Genesis.GetInitialHeight()
+	}
+
+	// we need the next block to verify the commit of a block, so without a
+	// target height we can only verify until the second latest block
+	targetHeight := flags.TargetHeight
+	if targetHeight == 0 {
+		targetHeight = blockCollector.GetLatestAvailableHeight() - 1
+	}
+
+	metrics.SetContinuationHeight(startHeight)
+
+	if err := blocksync.PerformBlockSyncValidationChecks(blockCollector, startHeight, targetHeight); err != nil {
+		return fmt.Errorf("verify validation checks failed: %w", err)
+	}
+
+	validatorRpc := flags.ValidatorRpc
+	if validatorRpc == "" {
+		validatorRpc = flags.BlockRpc
+	}
+
+	var getValidators func(height int64) ([]byte, error)
+	if validatorRpc != "" {
+		getValidators = func(height int64) ([]byte, error) {
+			return getValidatorsFromRpc(validatorRpc, height)
+		}
+	} else {
+		logger.Logger.Warn().Msg("no validator rpc provided, verification can only continue as long as the validator set does not change")
+	}
+
+	endBlockHash, err := StartVerifyExecutor(app, blockCollector, getValidators, startHeight, targetHeight)
+	if err != nil {
+		return err
+	}
+
+	attestation, err := createAttestation(app, startHeight, targetHeight, endBlockHash)
+	if err != nil {
+		return fmt.Errorf("failed to create attestation: %w", err)
+	}
+
+	if flags.AttestationPath != "" {
+		if err := os.WriteFile(flags.AttestationPath, attestation, 0644); err != nil {
+			return fmt.Errorf("failed to write attestation to %s: %w", flags.AttestationPath, err)
+		}
+
+		logger.Logger.Info().Msgf("wrote signed attestation to %s", flags.AttestationPath)
+	} else {
+		fmt.Println(string(attestation))
+	}
+
+	logger.Logger.Info().Str("duration", metrics.GetSyncDuration().String()).Msgf("successfully verified blocks from height %d to %d", startHeight, targetHeight)
+	return nil
+}
+
+// StartVerifyExecutor streams all blocks from the start height to the target height and verifies
+// them without executing them against the app. It returns the hash of the block at the target height
+// or an error containing the first invalid height
+func StartVerifyExecutor(app *app.CosmosApp, blockCollector types.BlockCollector, getValidators func(height int64) ([]byte, error), startHeight, targetHeight int64) (string, error) {
+	blockCh := make(chan *types.BlockItem, utils.BlockBuffer)
+	errorCh := make(chan error)
+
+	go blockCollector.StreamBlocks(blockCh, errorCh, startHeight, targetHeight)
+
+	// the collector can already fail before it sends the first block
+	var block *types.BlockItem
+
+	select {
+	case err := <-errorCh:
+		return "", fmt.Errorf("error in block collector: %w", err)
+	case block = <-blockCh:
+	}
+
+	for {
+		select {
+		case err := <-errorCh:
+			return "", fmt.Errorf("error in block collector: %w", err)
+		case nextBlock := <-blockCh:
+			logger.Logger.Debug().Int64("height", block.Height).Int64("next_height", nextBlock.Height).Msg("verifying blocks in engine")
+
+			if err := app.ConsensusEngine.VerifyBlock(block.Block, nextBlock.Block, getValidators); err != nil {
+				logger.Logger.Error().Int64("height", block.Height).Msgf("found first invalid block: %s", err)
+				return "", utils.NewClassErrorf(utils.ErrBlockValidation, "verification failed at height %d: %w", block.Height, err)
+			}
+
+			metrics.SetLatestHeight(block.Height)
+
+			if block.Height%utils.VerifyLogInterval == 0 {
+				logger.Logger.Info().Msgf("verified blocks up to height %d", block.Height)
+			}
+
+			if block.Height >= targetHeight {
+				return getLastBlockHash(nextBlock.Block)
+			}
+
+			block = nextBlock
+		}
+	}
+}
+
+// createAttestation creates a json attestation over the verified range which is
+// signed with the node key of the app
+func createAttestation(app *app.CosmosApp, startHeight, endHeight int64, endBlockHash string) ([]byte, error) {
+	attestation := types.Attestation{
+		ChainId:      app.Genesis.GetChainId(),
+		BlockRpc:     flags.BlockRpc,
+		StartHeight:  startHeight,
+		EndHeight:    endHeight,
+		EndBlockHash: endBlockHash,
+		KsyncVersion: utils.GetVersion(),
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if flags.BlockRpc == "" {
+		var err error
+		if attestation.BlockPoolId, err = app.Source.GetSourceBlockPoolId(); err != nil {
+			return nil, fmt.Errorf("failed to get block pool id: %w", err)
+		}
+	}
+
+	return signAttestation(app.ConsensusEngine, attestation)
+}
+
+// signAttestation signs the attestation with the node key of the engine, the
+// signature is created over the attestation without the signature itself
+func signAttestation(engine types.Engine, attestation types.Attestation) ([]byte, error) {
+	attestation.SignerAddress, attestation.PubKey = engine.GetNodeKey()
+	attestation.Signature = nil
+
+	payload, err := json.Marshal(attestation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal attestation payload: %w", err)
+	}
+
+	attestation.Signature, err = engine.SignWithNodeKey(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign attestation: %w", err)
+	}
+
+	return json.MarshalIndent(attestation, "", "  ")
+}
@@ -0,0 +1,115 @@
+package verify
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KYVENetwork/cometbft/v38/crypto/ed25519"
+	"github.com/KYVENetwork/ksync/types"
+)
+
+var errCollector = errors.New("collector failed")
+
+// failingBlockCollector fails before it sends any block
+type failingBlockCollector struct{}
+
+func (failingBlockCollector) GetEarliestAvailableHeight() int64 { return 1 }
+
+func (failingBlockCollector) GetLatestAvailableHeight() int64 { return 10 }
+
+func (failingBlockCollector) GetBlock(int64) ([]byte, error) { return nil, errCollector }
+
+func (failingBlockCollector) GetBlocks(int64) (int64, [][]byte, error) { return 0, nil, errCollector }
+
+func (failingBlockCollector) StreamBlocks(_ chan<- *types.BlockItem, errorCh chan<- error, _, _ int64) {
+	errorCh <- errCollector
+}
+
+func TestStartVerifyExecutorFailsBeforeFirstBlock(t *testing.T) {
+	done := make(chan error)
+
+	go func() {
+		_, err := StartVerifyExecutor(nil, failingBlockCollector{}, nil, 1, 10)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, errCollector) {
+			t.Fatalf("expected collector error, found %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("verify executor hangs if the collector fails before the first block")
+	}
+}
+
+// nodeKeyEngine signs with an in-memory node key
+type nodeKeyEngine struct {
+	types.Engine
+	privKey ed25519.PrivKey
+}
+
+func (engine nodeKeyEngine) GetNodeKey() (string, []byte) {
+	return engine.privKey.PubKey().Address().String(), engine.privKey.PubKey().Bytes()
+}
+
+func (engine nodeKeyEngine) SignWithNodeKey(payload []byte) ([]byte, error) {
+	return engine.privKey.Sign(payload)
+}
+
+func TestSignAttestation(t *testing.T) {
+	engine := nodeKeyEngine{privKey: ed25519.GenPrivKey()}
+
+	rawAttestation, err := signAttestation(engine, types.Attestation{
+		ChainId:      "osmosis-1",
+		BlockPoolId:  1,
+		StartHeight:  1,
+		EndHeight:    100,
+		EndBlockHash: "ABCDEF",
+		KsyncVersion: "v1.0.0",
+		Timestamp:    "2024-06-01T00:00:00Z",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var attestation types.Attestation
+	if err := json.Unmarshal(rawAttestation, &attestation); err != nil {
+		t.Fatal(err)
+	}
+
+	if address, _ := engine.GetNodeKey(); attestation.SignerAddress != address {
+		t.Fatalf("expected signer address %s, found %s", address, attestation.SignerAddress)
+	}
+
+	// a verifier only needs the attestation itself to check the signature
+	verify := func(attestation types.Attestation) bool {
+		signature := attestation.Signature
+		attestation.Signature = nil
+
+		payload, err := json.Marshal(attestation)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return ed25519.PubKey(attestation.PubKey).VerifySignature(payload, signature)
+	}
+
+	if !verify(attestation) {
+		t.Fatal("expected signature to be valid")
+	}
+
+	tampered := attestation
+	tampered.EndHeight = 101
+	if verify(tampered) {
+		t.Fatal("expected signature of tampered attestation to be invalid")
+	}
+
+	otherKey := attestation
+	otherKey.PubKey = ed25519.GenPrivKey().PubKey().Bytes()
+	if verify(otherKey) {
+		t.Fatal("expected signature to be invalid for another public key")
+	}
+}
@@ -10,5 +10,10 @@
 
 @test "KYVE: info on devnet" {
   run ./build/ksync info --opt-out --chain-id korellia-2
+  [ "$status" -eq 0 ]
+}
+
+@test "KYVE: info on unknown network" {
+  run ./build/ksync info --opt-out --chain-id unknown-1
   [ "$status" -eq 1 ]
 }
@@ -14,6 +14,11 @@ type BlockCollector interface {
 	// GetBlock gets the block for the given height
 	GetBlock(height int64) ([]byte, error)
 
+	// GetBlocks gets the block for the given height together with the blocks which are
+	// retrieved along with it, e.g. all blocks of its bundle. It also returns the height
+	// of the first block
+	GetBlocks(height int64) (int64, [][]byte, error)
+
 	// StreamBlocks takes a continuationHeight and a targetHeight and streams
 	// all blocks in order into a given block channel. This method exits once
 	// the target height is reached or runs indefinitely depending on the
@@ -78,6 +83,12 @@ type Engine interface {
 	// GetProxyAppAddress gets the proxy app address of the TSP connection
 	GetProxyAppAddress() string
 
+	// GetNodeKey gets the address and the public key of the node key
+	GetNodeKey() (address string, pubKey []byte)
+
+	// SignWithNodeKey signs the payload with the private key of the node key
+	SignWithNodeKey(payload []byte) ([]byte, error)
+
 	// StartProxyApp starts the proxy app connections to the app
 	StartProxyApp() error
 
@@ -96,6 +107,12 @@ type Engine interface {
 	// which is necessary, if the genesis file is bigger than 100MB
 	ApplyFirstBlockOverP2P(rawBlock, nextRawBlock []byte) error
 
+	// VerifyBlock takes a block at height n and n+1 and runs the header
+	// validation and light commit verification of ApplyBlock without
+	// executing the block against the app. Validator set changes are tracked
+	// and resolved with the getValidators function
+	VerifyBlock(rawBlock, nextRawBlock []byte, getValidators func(height int64) ([]byte, error)) error
+
 	// GetHeight gets the latest height stored in the blockstore.db
 	GetHeight() int64
 
@@ -105,6 +122,10 @@ type Engine interface {
 	// GetAppHeight gets over ABCI the latest block height tracked by the app
 	GetAppHeight() (int64, error)
 
+	// GetStateHashes gets the app hash and the last results hash of the
+	// state after the latest applied block
+	GetStateHashes() (appHash []byte, lastResultsHash []byte)
+
 	// GetSnapshots gets the available snapshots over ABCI from the app
 	GetSnapshots() ([]byte, error)
 
@@ -123,6 +144,15 @@ type Engine interface {
 	// /status, /block and /block_results
 	StartRPCServer(port int64)
 
+	// StartSnapshotP2PServer starts a P2P switch with a statesync reactor on the
+	// configured P2P listen address so that nodes using the built-in state-sync
+	// can request the snapshots of the app from KSYNC
+	StartSnapshotP2PServer() error
+
+	// StopSnapshotP2PServer stops the P2P switch and frees the P2P listen
+	// address again, it does nothing if the switch is not running
+	StopSnapshotP2PServer() error
+
 	// GetState rebuilds the requested state from the blockstore and state.db
 	GetState(height int64) ([]byte, error)
 
@@ -146,3 +176,21 @@ type Engine interface {
 	// to genesis state
 	ResetAll(keepAddrBook bool) error
 }
+
+// KyveQuerier is an interface defining the queries KSYNC makes against
+// a KYVE chain, since they can be either made over REST or gRPC
+type KyveQuerier interface {
+	// GetPool gets the pool with the given id
+	GetPool(poolId int64) (*PoolResponse, error)
+
+	// GetFinalizedBundlesPage gets a page of finalized bundles of the pool. The
+	// pagination key is the base64 encoded next key of the previous page
+	GetFinalizedBundlesPage(poolId, limit, offset int64, paginationKey string, reverse bool) ([]FinalizedBundle, string, error)
+
+	// GetFinalizedBundleById gets the finalized bundle with the given id
+	GetFinalizedBundleById(poolId, bundleId int64) (*FinalizedBundle, error)
+
+	// GetFinalizedBundleByIndex gets the finalized bundle which contains the
+	// data item with the given index
+	GetFinalizedBundleByIndex(poolId, index int64) (*FinalizedBundle, error)
+}
@@ -80,13 +80,23 @@ type Pagination struct {
 }
 
 type FinalizedBundle struct {
-	Id                string `json:"id,omitempty"`
-	StorageId         string `json:"storage_id,omitempty"`
-	StorageProviderId string `json:"storage_provider_id,omitempty"`
-	CompressionId     string `json:"compression_id,omitempty"`
-	FromKey           string `json:"from_key,omitempty"`
-	ToKey             string `json:"to_key,omitempty"`
-	DataHash          string `json:"data_hash,omitempty"`
+	PoolId            string       `json:"pool_id,omitempty"`
+	Id                string       `json:"id,omitempty"`
+	StorageId         string       `json:"storage_id,omitempty"`
+	StorageProviderId string       `json:"storage_provider_id,omitempty"`
+	CompressionId     string       `json:"compression_id,omitempty"`
+	FromKey           string       `json:"from_key,omitempty"`
+	ToKey             string       `json:"to_key,omitempty"`
+	DataHash          string       `json:"data_hash,omitempty"`
+	BundleSummary     string       `json:"bundle_summary,omitempty"`
+	FinalizedAt       *FinalizedAt `json:"finalized_at,omitempty"`
+}
+
+// FinalizedAt is the KYVE block height and the unix timestamp in seconds
+// at which a bundle was finalized
+type FinalizedAt struct {
+	Height    string `json:"height"`
+	Timestamp string `json:"timestamp"`
 }
 
 type FinalizedBundlesResponse = struct {
@@ -103,16 +113,16 @@ type SupportedChain = struct {
 	StatePoolId    string `json:"state_pool_id"`
 }
 
-type Networks struct {
-	Kaon *NetworkProperties `yaml:"kaon-1,omitempty"`
-	Kyve *NetworkProperties `yaml:"kyve-1,omitempty"`
-}
+// Networks maps the network keys of the source registry, e.g. "kyve-1", to the properties
+type Networks map[string]*NetworkProperties
 
 type NetworkProperties struct {
 	LatestBlockKey *string
 	LatestStateKey *string
 	BlockStartKey  *string
 	StateStartKey  *string
+	BlockRuntime   *string
+	StateConfig    *string
 	Integrations   *Integrations   `yaml:"integrations,omitempty"`
 	Pools          *[]Pool         `yaml:"pools,omitempty"`
 	SourceMetadata *SourceMetadata `yaml:"properties,omitempty"`
@@ -137,8 +147,10 @@ type Pool struct {
 }
 
 type Codebase struct {
-	GitUrl   string         `yaml:"git-url"`
-	Settings CosmosSettings `yaml:"settings"`
+	GitUrl          string         `yaml:"git-url"`
+	GenesisUrl      string         `yaml:"genesis-url,omitempty"`
+	GenesisChecksum string         `yaml:"genesis-checksum,omitempty"`
+	Settings        CosmosSettings `yaml:"settings"`
 }
 
 type CosmosSettings struct {
@@ -168,15 +180,110 @@ type Upgrade struct {
 	Version         string
 	GoVersion       string
 	LibwasmVersion  string
+	Binaries        map[string]string
+	Recipe          BuildRecipe
 	InstallDuration time.Duration
 }
 
+type BuildRecipe struct {
+	Subfolder      string                 `yaml:"subfolder"`
+	BuildCmd       string                 `yaml:"build_cmd"`
+	BuildArgs      []string               `yaml:"build_args"`
+	Env            map[string]string      `yaml:"env"`
+	BinaryPath     string                 `yaml:"binary_path"`
+	GoVersion      string                 `yaml:"go_version"`
+	LibwasmVersion string                 `yaml:"libwasm_version"`
+	LibwasmPath    string                 `yaml:"libwasm_path"`
+	Upgrades       map[string]BuildRecipe `yaml:"upgrades"`
+}
+
+type BuildRecipes struct {
+	Version  int                    `yaml:"version"`
+	Defaults BuildRecipe            `yaml:"defaults"`
+	Chains   map[string]BuildRecipe `yaml:"chains"`
+}
+
+// SourceInfo describes what KSYNC can sync of a source on a KYVE network
+type SourceInfo struct {
+	SourceId               string              `json:"source_id" yaml:"source_id"`
+	Title                  string              `json:"title" yaml:"title"`
+	ChainId                string              `json:"chain_id" yaml:"chain_id"`
+	BlockPoolId            *int64              `json:"block_pool_id" yaml:"block_pool_id"`
+	StatePoolId            *int64              `json:"state_pool_id" yaml:"state_pool_id"`
+	EarliestBlockHeight    *int64              `json:"earliest_block_height" yaml:"earliest_block_height"`
+	LatestBlockHeight      *int64              `json:"latest_block_height" yaml:"latest_block_height"`
+	EarliestSnapshotHeight *int64              `json:"earliest_snapshot_height" yaml:"earliest_snapshot_height"`
+	LatestSnapshotHeight   *int64              `json:"latest_snapshot_height" yaml:"latest_snapshot_height"`
+	SnapshotInterval       *int64              `json:"snapshot_interval" yaml:"snapshot_interval"`
+	Runtime                string              `json:"runtime" yaml:"runtime"`
+	Upgrades               []SourceInfoUpgrade `json:"upgrades" yaml:"upgrades"`
+}
+
+type SourceInfoUpgrade struct {
+	Name    string `json:"name" yaml:"name"`
+	Height  int64  `json:"height" yaml:"height"`
+	Version string `json:"version" yaml:"version"`
+}
+
+// PoolInspection is the health report of a KYVE pool over its most recent bundles
+type PoolInspection struct {
+	PoolId                int64            `json:"pool_id"`
+	Runtime               string           `json:"runtime"`
+	StartKey              string           `json:"start_key"`
+	CurrentKey            string           `json:"current_key"`
+	CurrentSummary        string           `json:"current_summary"`
+	TotalBundles          int64            `json:"total_bundles"`
+	InspectedBundles      int64            `json:"inspected_bundles"`
+	LatestBundleId        string           `json:"latest_bundle_id"`
+	LatestBundleAge       *int64           `json:"latest_bundle_age_seconds"`
+	AverageBundleInterval *float64         `json:"average_bundle_interval_seconds"`
+	BundlesPerHour        *float64         `json:"bundles_per_hour"`
+	PoolHeight            *int64           `json:"pool_height"`
+	SourceHeight          *int64           `json:"source_height"`
+	Lag                   *int64           `json:"lag"`
+	StorageProviders      map[string]int64 `json:"storage_providers"`
+	CompressionTypes      map[string]int64 `json:"compression_types"`
+	Anomalies             []string         `json:"anomalies"`
+}
+
+type KyveNetwork struct {
+	ChainId     string `yaml:"chain_id"`
+	Rest        string `yaml:"rest"`
+	Grpc        string `yaml:"grpc"`
+	Rpc         string `yaml:"rpc"`
+	RegistryKey string `yaml:"registry_key"`
+}
+
+type KyveNetworks struct {
+	Version  int           `yaml:"version"`
+	Networks []KyveNetwork `yaml:"networks"`
+}
+
 type Peer struct {
 	Id       string `json:"id"`
 	Address  string `json:"address"`
 	Provider string `json:"provider"`
 }
 
+type NodeCommand struct {
+	Binary string
+	Args   []string
+	Env    []string
+}
+
+type ProtocolVersion struct {
+	P2P   uint64
+	Block uint64
+	App   uint64
+}
+
+type PeerProbe struct {
+	Peer      Peer
+	Reachable bool
+	Latency   time.Duration
+	Error     string
+}
+
 type Endpoint struct {
 	Address  string `json:"address"`
 	Provider string `json:"provider"`
@@ -197,7 +304,11 @@ type ChainSchema struct {
 	} `json:"fees"`
 	Codebase struct {
 		GitRepoUrl string `json:"git_repo"`
-		Genesis    struct {
+		Consensus  struct {
+			Type    string `json:"type"`
+			Version string `json:"version"`
+		} `json:"consensus"`
+		Genesis struct {
 			GenesisUrl string `json:"genesis_url"`
 		} `json:"genesis"`
 	} `json:"codebase"`
@@ -212,11 +323,52 @@ type ChainSchema struct {
 }
 
 type Version struct {
-	Name               string `json:"name"`
-	RecommendedVersion string `json:"recommended_version"`
-	Tag                string `json:"tag"`
+	Name               string            `json:"name"`
+	RecommendedVersion string            `json:"recommended_version"`
+	Tag                string            `json:"tag"`
+	Binaries           map[string]string `json:"binaries"`
 }
 
 type VersionsSchema struct {
 	Versions []Version `json:"versions"`
 }
+
+type Attestation struct {
+	ChainId       string `json:"chain_id"`
+	BlockPoolId   int64  `json:"block_pool_id,omitempty"`
+	BlockRpc      string `json:"block_rpc,omitempty"`
+	StartHeight   int64  `json:"start_height"`
+	EndHeight     int64  `json:"end_height"`
+	EndBlockHash  string `json:"end_block_hash"`
+	KsyncVersion  string `json:"ksync_version"`
+	Timestamp     string `json:"timestamp"`
+	SignerAddress string `json:"signer_address"`
+	PubKey        []byte `json:"pub_key"`
+	Signature     []byte `json:"signature,omitempty"`
+}
+
+type SetupConfig struct {
+	Source         string   `yaml:"source"`
+	ChainId        string   `yaml:"chain_id"`
+	Mode           string   `yaml:"mode"`
+	PeerStrategy   string   `yaml:"peer_strategy"`
+	PeerCount      int64    `yaml:"peer_count"`
+	PeerProviders  []string `yaml:"peer_providers"`
+	Moniker        string   `yaml:"moniker"`
+	TargetHeight   int64    `yaml:"target_height"`
+	NonInteractive bool     `yaml:"non_interactive"`
+	Services       struct {
+		Generate  []string `yaml:"generate"`
+		OutputDir string   `yaml:"output_dir"`
+	} `yaml:"services"`
+	App struct {
+		Pruning          string `yaml:"pruning"`
+		SnapshotInterval int64  `yaml:"snapshot_interval"`
+		EnableApi        bool   `yaml:"enable_api"`
+	} `yaml:"app"`
+	Genesis struct {
+		File     string `yaml:"file"`
+		Url      string `yaml:"url"`
+		Checksum string `yaml:"checksum"`
+	} `yaml:"genesis"`
+}
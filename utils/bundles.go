@@ -1,52 +1,21 @@
 package utils
 
 import (
-	"encoding/base64"
+	"errors"
 	"fmt"
-	"github.com/KYVENetwork/cometbft/v34/libs/json"
 	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
 	"github.com/KYVENetwork/ksync/types"
 	"strings"
+	"time"
 )
 
 func GetPool(restEndpoint string, poolId int64) (*types.PoolResponse, error) {
-	data, err := GetFromUrl(fmt.Sprintf("%s/kyve/query/v1beta1/pool/%d", restEndpoint, poolId))
-	if err != nil {
-		return nil, fmt.Errorf("failed to query pool %d", poolId)
-	}
-
-	var poolResponse types.PoolResponse
-
-	if err = json.Unmarshal(data, &poolResponse); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal pool response: %w", err)
-	}
-
-	return &poolResponse, nil
+	return GetKyveQuerier(restEndpoint).GetPool(poolId)
 }
 
 func GetFinalizedBundlesPageWithOffset(restEndpoint string, poolId int64, paginationLimit, paginationOffset int64, paginationKey string, reverse bool) ([]types.FinalizedBundle, string, error) {
-	raw, err := GetFromUrl(fmt.Sprintf(
-		"%s/kyve/v1/bundles/%d?pagination.limit=%d&pagination.offset=%d&pagination.key=%s&pagination.reverse=%v",
-		restEndpoint,
-		poolId,
-		paginationLimit,
-		paginationOffset,
-		paginationKey,
-		reverse,
-	))
-	if err != nil {
-		return nil, "", err
-	}
-
-	var bundlesResponse types.FinalizedBundlesResponse
-
-	if err := json.Unmarshal(raw, &bundlesResponse); err != nil {
-		return nil, "", err
-	}
-
-	nextKey := base64.URLEncoding.EncodeToString(bundlesResponse.Pagination.NextKey)
-
-	return bundlesResponse.FinalizedBundles, nextKey, nil
+	return GetKyveQuerier(restEndpoint).GetFinalizedBundlesPage(poolId, paginationLimit, paginationOffset, paginationKey, reverse)
 }
 
 func GetFinalizedBundlesPage(restEndpoint string, poolId int64, paginationLimit int64, paginationKey string, reverse bool) ([]types.FinalizedBundle, string, error) {
@@ -54,28 +23,40 @@ func GetFinalizedBundlesPage(restEndpoint string, poolId int64, paginationLimit
 }
 
 func GetFinalizedBundleById(restEndpoint string, poolId int64, bundleId int64) (*types.FinalizedBundle, error) {
-	raw, err := GetFromUrl(fmt.Sprintf(
-		"%s/kyve/v1/bundles/%d/%d",
-		restEndpoint,
-		poolId,
-		bundleId,
-	))
-	if err != nil {
-		return nil, err
-	}
+	return GetKyveQuerier(restEndpoint).GetFinalizedBundleById(poolId, bundleId)
+}
 
-	var finalizedBundle types.FinalizedBundle
+// GetFinalizedBundleByIndex gets the finalized bundle which contains the data item with the given index
+func GetFinalizedBundleByIndex(restEndpoint string, poolId int64, index int64) (*types.FinalizedBundle, error) {
+	return GetKyveQuerier(restEndpoint).GetFinalizedBundleByIndex(poolId, index)
+}
 
-	if err := json.Unmarshal(raw, &finalizedBundle); err != nil {
-		return nil, err
+// GetDataFromFinalizedBundle downloads the data from the provided bundle, verify if the checksum on the KYVE
+// chain matches and finally decompresses it before returning. Since a storage provider can serve corrupted
+// data the download is retried if the checksum does not match or the data can not be decompressed
+func GetDataFromFinalizedBundle(bundle types.FinalizedBundle) (deflated []byte, err error) {
+	for retry := 0; ; retry++ {
+		deflated, err = getDataFromFinalizedBundle(bundle)
+		if err == nil {
+			return deflated, nil
+		}
+
+		// network errors are already retried when requesting the data
+		if errors.Is(err, ErrNetwork) {
+			return nil, err
+		}
+
+		policy := GetRetryPolicy(err)
+		if !policy.Retryable || retry >= policy.MaxRetries {
+			return nil, err
+		}
+
+		logger.Logger.Error().Msgf("%s, downloading bundle again in %d seconds", err, int(policy.Delay(retry).Seconds()))
+		time.Sleep(policy.Delay(retry))
 	}
-
-	return &finalizedBundle, nil
 }
 
-// GetDataFromFinalizedBundle downloads the data from the provided bundle, verify if the checksum on the KYVE
-// chain matches and finally decompresses it before returning
-func GetDataFromFinalizedBundle(bundle types.FinalizedBundle) ([]byte, error) {
+func getDataFromFinalizedBundle(bundle types.FinalizedBundle) ([]byte, error) {
 	// retrieve bundle from storage provider
 	data, err := RetrieveDataFromStorageProvider(bundle)
 	if err != nil {
@@ -84,13 +65,13 @@ func GetDataFromFinalizedBundle(bundle types.FinalizedBundle) ([]byte, error) {
 
 	// validate bundle with sha256 checksum
 	if CreateSha256Checksum(data) != bundle.DataHash {
-		return nil, fmt.Errorf("found different sha256 checksum on bundle with storage id %s: expected = %s found = %s", bundle.StorageId, CreateSha256Checksum(data), bundle.DataHash)
+		return nil, NewClassErrorf(ErrChecksumMismatch, "found different sha256 checksum on bundle with storage id %s: expected = %s found = %s", bundle.StorageId, bundle.DataHash, CreateSha256Checksum(data))
 	}
 
 	// decompress bundle
 	deflated, err := DecompressBundleFromStorageProvider(bundle, data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decompress bundle: %w", err)
+		return nil, NewClassErrorf(ErrDecompression, "failed to decompress bundle with storage id %s: %w", bundle.StorageId, err)
 	}
 
 	return deflated, nil
@@ -103,13 +84,13 @@ func RetrieveDataFromStorageProvider(bundle types.FinalizedBundle) ([]byte, erro
 
 	switch bundle.StorageProviderId {
 	case "1":
-		return GetFromUrl(fmt.Sprintf("%s/%s", RestEndpointArweave, bundle.StorageId))
+		return GetFromStorageUrl(fmt.Sprintf("%s/%s", RestEndpointArweave, bundle.StorageId))
 	case "2":
-		return GetFromUrl(fmt.Sprintf("%s/%s", RestEndpointBundlr, bundle.StorageId))
+		return GetFromStorageUrl(fmt.Sprintf("%s/%s", RestEndpointBundlr, bundle.StorageId))
 	case "3":
 		return GetFromUrl(fmt.Sprintf("%s/%s", RestEndpointKYVEStorage, bundle.StorageId))
 	case "4":
-		return GetFromUrl(fmt.Sprintf("%s/%s", RestEndpointTurboStorage, bundle.StorageId))
+		return GetFromStorageUrl(fmt.Sprintf("%s/%s", RestEndpointTurboStorage, bundle.StorageId))
 	default:
 		return nil, fmt.Errorf("bundle has an invalid storage provider id %s. canceling sync", bundle.StorageProviderId)
 	}
@@ -5,10 +5,6 @@ const (
 	ChainIdKaon     = "kaon-1"
 	ChainIdKorellia = "korellia-2"
 
-	RestEndpointMainnet  = "https://api.kyve.network"
-	RestEndpointKaon     = "https://api.kaon.kyve.network"
-	RestEndpointKorellia = "https://api.korellia.kyve.network"
-
 	RestEndpointArweave      = "https://arweave.net"
 	RestEndpointBundlr       = "https://arweave.net"
 	RestEndpointKYVEStorage  = "https://storage.kyve.network"
@@ -43,8 +39,10 @@ const (
 	BackoffMaxRetries           = 10
 	RequestTimeoutMS            = 100
 	RequestBlocksTimeoutMS      = 250
+	VerifyLogInterval           = 1000
 )
 
 const (
-	DefaultRegistryURL = "https://raw.githubusercontent.com/KYVENetwork/source-registry/main/.github/registry.yml"
+	RegistryURLTemplate = "https://raw.githubusercontent.com/KYVENetwork/source-registry/%s/.github/registry.yml"
+	DefaultRegistryRef  = "main"
 )
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// error classes of KSYNC, every error returned by a command can be checked against
+// them with errors.Is to decide if the failed command should be retried
+var (
+	ErrNetwork          = errors.New("network error")
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+	ErrDecompression    = errors.New("decompression error")
+	ErrDecode           = errors.New("decode error")
+	ErrBlockValidation  = errors.New("block validation error")
+	ErrAppExecution     = errors.New("app execution error")
+	ErrUpgradeRequired  = errors.New("upgrade required")
+)
+
+// exit codes of KSYNC, errors which do not belong to any class exit with ExitCodeError
+const (
+	ExitCodeError            = 1
+	ExitCodeNetwork          = 10
+	ExitCodeChecksumMismatch = 11
+	ExitCodeDecompression    = 12
+	ExitCodeDecode           = 13
+	ExitCodeBlockValidation  = 14
+	ExitCodeAppExecution     = 15
+	ExitCodeUpgradeRequired  = 16
+)
+
+const (
+	retryInitialBackoff = time.Second
+	retryMaxDelay       = 5 * time.Minute
+	downloadMaxRetries  = 3
+)
+
+// RetryPolicy describes if and how an operation which failed with an error of a class is retried.
+// The delay starts at InitialBackoff and doubles on every retry
+type RetryPolicy struct {
+	Retryable      bool
+	MaxRetries     int
+	InitialBackoff time.Duration
+}
+
+// Delay returns how long to wait before the given retry, starting at zero
+func (policy RetryPolicy) Delay(retry int) time.Duration {
+	delay := policy.InitialBackoff << retry
+	if delay <= 0 || delay > retryMaxDelay {
+		return retryMaxDelay
+	}
+	return delay
+}
+
+type errorClass struct {
+	err      error
+	exitCode int
+	policy   RetryPolicy
+}
+
+// errorClasses are checked in order, network errors are transient and corrupted downloads
+// can succeed from another gateway of the storage provider. Everything else is caused by
+// the data itself or the app and fails the same way if retried
+var errorClasses = []errorClass{
+	{ErrUpgradeRequired, ExitCodeUpgradeRequired, RetryPolicy{}},
+	{ErrAppExecution, ExitCodeAppExecution, RetryPolicy{}},
+	{ErrBlockValidation, ExitCodeBlockValidation, RetryPolicy{}},
+	{ErrChecksumMismatch, ExitCodeChecksumMismatch, RetryPolicy{Retryable: true, MaxRetries: downloadMaxRetries, InitialBackoff: retryInitialBackoff}},
+	{ErrDecompression, ExitCodeDecompression, RetryPolicy{Retryable: true, MaxRetries: downloadMaxRetries, InitialBackoff: retryInitialBackoff}},
+	{ErrDecode, ExitCodeDecode, RetryPolicy{}},
+	{ErrNetwork, ExitCodeNetwork, RetryPolicy{Retryable: true, MaxRetries: BackoffMaxRetries, InitialBackoff: retryInitialBackoff}},
+}
+
+func getErrorClass(err error) *errorClass {
+	for i := range errorClasses {
+		if errors.Is(err, errorClasses[i].err) {
+			return &errorClasses[i]
+		}
+	}
+	return nil
+}
+
+// GetExitCode returns the exit code KSYNC exits with for the error
+func GetExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if class := getErrorClass(err); class != nil {
+		return class.exitCode
+	}
+
+	return ExitCodeError
+}
+
+// GetRetryPolicy returns the retry policy of the class of the error, errors without a class
+// are not retried. Network errors where the server rejected the request are not retried either
+func GetRetryPolicy(err error) RetryPolicy {
+	var networkError *NetworkError
+	if errors.As(err, &networkError) && !networkError.Temporary() {
+		return RetryPolicy{}
+	}
+
+	if class := getErrorClass(err); class != nil {
+		return class.policy
+	}
+
+	return RetryPolicy{}
+}
+
+// GetErrorClass returns the name of the class of the error or an empty string
+func GetErrorClass(err error) string {
+	if class := getErrorClass(err); class != nil {
+		return class.err.Error()
+	}
+	return ""
+}
+
+// ClassError adds an error class to an error while keeping the original error
+// available for errors.Is and errors.As
+type ClassError struct {
+	Class error
+	Err   error
+}
+
+// NewClassError wraps the error with the class, the message of the error stays the same
+func NewClassError(class, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ClassError{Class: class, Err: err}
+}
+
+// NewClassErrorf creates a new error of the class, it supports %w like fmt.Errorf
+func NewClassErrorf(class error, format string, args ...any) error {
+	return &ClassError{Class: class, Err: fmt.Errorf(format, args...)}
+}
+
+func (e *ClassError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ClassError) Unwrap() []error {
+	return []error{e.Class, e.Err}
+}
+
+// NetworkError is returned if a request failed or the server responded
+// with an unexpected status code
+type NetworkError struct {
+	Url        string
+	StatusCode int
+	Err        error
+}
+
+func (e *NetworkError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("got status code %d", e.StatusCode)
+	}
+	return e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrNetwork}
+	}
+	return []error{ErrNetwork, e.Err}
+}
+
+// Temporary returns false if the server rejected the request, in this
+// case retrying the same request would fail again
+func (e *NetworkError) Temporary() bool {
+	if e.StatusCode >= 400 && e.StatusCode < 500 {
+		return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
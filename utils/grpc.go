@@ -0,0 +1,506 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"github.com/KYVENetwork/ksync/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// full method names of the KYVE query services, see kyve/query/v1beta1 in the KYVE chain
+const (
+	grpcMethodPool             = "/kyve.query.v1beta1.QueryPool/Pool"
+	grpcMethodFinalizedBundles = "/kyve.query.v1beta1.QueryBundles/FinalizedBundlesQuery"
+	grpcMethodFinalizedBundle  = "/kyve.query.v1beta1.QueryBundles/FinalizedBundleQuery"
+)
+
+const grpcRequestTimeout = 30 * time.Second
+
+// grpcQuerier queries the KYVE chain over gRPC. The generated query types are only published
+// as part of the KYVE chain module github.com/KYVENetwork/chain, which pulls in the cosmos-sdk
+// and requires the replace directives of the chain in our go.mod since they are not inherited
+// by dependents. For the three queries KSYNC needs the messages are instead encoded by hand
+// with the field numbers of the KYVE protobuf definitions and pinned by golden tests. Fields
+// are only decoded if they have the wire type of the definition, so schema changes result in
+// errors instead of zero values
+type grpcQuerier struct {
+	conn *grpc.ClientConn
+}
+
+// newGrpcQuerier creates a client for the endpoint. Endpoints with the scheme "https://"
+// or "grpcs://" use TLS, all others like "localhost:9090" are plaintext
+func newGrpcQuerier(endpoint string) (*grpcQuerier, error) {
+	creds := insecure.NewCredentials()
+
+	switch {
+	case strings.HasPrefix(endpoint, "https://"), strings.HasPrefix(endpoint, "grpcs://"):
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	for _, scheme := range []string{"https://", "grpcs://", "http://", "grpc://"} {
+		endpoint = strings.TrimPrefix(endpoint, scheme)
+	}
+
+	conn, err := grpc.NewClient(strings.TrimSuffix(endpoint, "/"), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+
+	return &grpcQuerier{conn: conn}, nil
+}
+
+// isGrpcTransportError returns true if the gRPC endpoint could not be reached at all,
+// in contrast to errors of single queries, e.g. if a query is not supported
+func isGrpcTransportError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+func (querier *grpcQuerier) invoke(method string, request, response wireMessage) error {
+	ctx, cancel := context.WithTimeout(context.Background(), grpcRequestTimeout)
+	defer cancel()
+
+	return querier.conn.Invoke(ctx, method, request, response, grpc.ForceCodec(wireCodec{}))
+}
+
+func (querier *grpcQuerier) GetPool(poolId int64) (*types.PoolResponse, error) {
+	response := &queryPoolResponse{}
+
+	if err := querier.invoke(grpcMethodPool, &queryPoolRequest{id: uint64(poolId)}, response); err != nil {
+		return nil, fmt.Errorf("failed to query pool %d: %w", poolId, err)
+	}
+
+	if response.pool.Pool.Data.Runtime == "" {
+		return nil, fmt.Errorf("pool response of pool %d has no runtime", poolId)
+	}
+
+	return &response.pool, nil
+}
+
+func (querier *grpcQuerier) GetFinalizedBundlesPage(poolId, limit, offset int64, paginationKey string, reverse bool) ([]types.FinalizedBundle, string, error) {
+	key, err := base64.URLEncoding.DecodeString(paginationKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode pagination key %s: %w", paginationKey, err)
+	}
+
+	request := &queryFinalizedBundlesRequest{
+		poolId:  uint64(poolId),
+		key:     key,
+		offset:  uint64(offset),
+		limit:   uint64(limit),
+		reverse: reverse,
+	}
+	response := &queryFinalizedBundlesResponse{}
+
+	if err := querier.invoke(grpcMethodFinalizedBundles, request, response); err != nil {
+		return nil, "", fmt.Errorf("failed to query finalized bundles of pool %d: %w", poolId, err)
+	}
+
+	return response.bundles, base64.URLEncoding.EncodeToString(response.nextKey), nil
+}
+
+func (querier *grpcQuerier) GetFinalizedBundleById(poolId, bundleId int64) (*types.FinalizedBundle, error) {
+	response := &finalizedBundle{}
+
+	if err := querier.invoke(grpcMethodFinalizedBundle, &queryFinalizedBundleRequest{poolId: uint64(poolId), id: uint64(bundleId)}, response); err != nil {
+		return nil, fmt.Errorf("failed to query finalized bundle %d of pool %d: %w", bundleId, poolId, err)
+	}
+
+	return &response.FinalizedBundle, nil
+}
+
+func (querier *grpcQuerier) GetFinalizedBundleByIndex(poolId, index int64) (*types.FinalizedBundle, error) {
+	request := &queryFinalizedBundlesRequest{
+		poolId: uint64(poolId),
+		index:  strconv.FormatInt(index, 10),
+	}
+	response := &queryFinalizedBundlesResponse{}
+
+	if err := querier.invoke(grpcMethodFinalizedBundles, request, response); err != nil {
+		return nil, fmt.Errorf("failed to query finalized bundle for index %d of pool %d: %w", index, poolId, err)
+	}
+
+	if len(response.bundles) != 1 {
+		return nil, fmt.Errorf("expected one finalized bundle for index %d, found %d", index, len(response.bundles))
+	}
+
+	return &response.bundles[0], nil
+}
+
+// wireMessage is a protobuf message which is encoded by hand
+type wireMessage interface {
+	marshal() []byte
+	unmarshal(data []byte) error
+}
+
+// wireCodec replaces the default proto codec of gRPC for wire messages
+type wireCodec struct{}
+
+func (wireCodec) Marshal(v any) ([]byte, error) {
+	message, ok := v.(wireMessage)
+	if !ok {
+		return nil, fmt.Errorf("failed to marshal %T: not a wire message", v)
+	}
+
+	return message.marshal(), nil
+}
+
+func (wireCodec) Unmarshal(data []byte, v any) error {
+	message, ok := v.(wireMessage)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal %T: not a wire message", v)
+	}
+
+	return message.unmarshal(data)
+}
+
+func (wireCodec) Name() string {
+	return "proto"
+}
+
+// kyve.query.v1beta1.QueryPoolRequest
+type queryPoolRequest struct {
+	id uint64
+}
+
+func (m *queryPoolRequest) marshal() []byte {
+	return appendVarintField(nil, 1, m.id)
+}
+
+func (m *queryPoolRequest) unmarshal([]byte) error {
+	return fmt.Errorf("request can not be unmarshalled")
+}
+
+// kyve.query.v1beta1.QueryPoolResponse with the nested kyve.query.v1beta1.PoolResponse
+// and kyve.pool.v1beta1.Pool
+type queryPoolResponse struct {
+	pool types.PoolResponse
+}
+
+func (m *queryPoolResponse) marshal() []byte {
+	return nil
+}
+
+func (m *queryPoolResponse) unmarshal(data []byte) error {
+	return walkFields(data, fieldSchema{1: protowire.BytesType}, func(_ protowire.Number, poolResponse []byte, _ uint64) error {
+		return walkFields(poolResponse, fieldSchema{1: protowire.VarintType, 2: protowire.BytesType}, func(num protowire.Number, pool []byte, id uint64) error {
+			if num == 1 {
+				m.pool.Pool.Id = int64(id)
+				return nil
+			}
+
+			return unmarshalPool(pool, &m.pool)
+		})
+	})
+}
+
+// UnmarshalPool decodes a kyve.pool.v1beta1.Pool like it is stored in the state of the KYVE chain
+func UnmarshalPool(data []byte) (*types.PoolResponse, error) {
+	poolResponse := &types.PoolResponse{}
+
+	if err := unmarshalPool(data, poolResponse); err != nil {
+		return nil, err
+	}
+
+	return poolResponse, nil
+}
+
+func unmarshalPool(data []byte, poolResponse *types.PoolResponse) error {
+	schema := fieldSchema{
+		1:  protowire.VarintType,
+		3:  protowire.BytesType,
+		5:  protowire.BytesType,
+		6:  protowire.BytesType,
+		7:  protowire.BytesType,
+		8:  protowire.BytesType,
+		10: protowire.VarintType,
+	}
+
+	return walkFields(data, schema, func(num protowire.Number, value []byte, number uint64) error {
+		pool := &poolResponse.Pool
+
+		switch num {
+		case 1:
+			pool.Id = int64(number)
+		case 3:
+			pool.Data.Runtime = string(value)
+		case 5:
+			pool.Data.Config = string(value)
+		case 6:
+			pool.Data.StartKey = string(value)
+		case 7:
+			pool.Data.CurrentKey = string(value)
+		case 8:
+			pool.Data.CurrentSummary = string(value)
+		case 10:
+			pool.Data.TotalBundles = int64(number)
+		}
+
+		return nil
+	})
+}
+
+// kyve.query.v1beta1.QueryFinalizedBundlesRequest with the nested
+// cosmos.base.query.v1beta1.PageRequest
+type queryFinalizedBundlesRequest struct {
+	poolId  uint64
+	index   string
+	key     []byte
+	offset  uint64
+	limit   uint64
+	reverse bool
+}
+
+func (m *queryFinalizedBundlesRequest) marshal() []byte {
+	var pagination []byte
+	if len(m.key) > 0 {
+		pagination = protowire.AppendTag(pagination, 1, protowire.BytesType)
+		pagination = protowire.AppendBytes(pagination, m.key)
+	}
+
+	pagination = appendVarintField(pagination, 2, m.offset)
+	pagination = appendVarintField(pagination, 3, m.limit)
+	pagination = appendVarintField(pagination, 5, protowire.EncodeBool(m.reverse))
+
+	var data []byte
+	if len(pagination) > 0 {
+		data = protowire.AppendTag(data, 1, protowire.BytesType)
+		data = protowire.AppendBytes(data, pagination)
+	}
+
+	data = appendVarintField(data, 2, m.poolId)
+
+	if m.index != "" {
+		data = protowire.AppendTag(data, 3, protowire.BytesType)
+		data = protowire.AppendString(data, m.index)
+	}
+
+	return data
+}
+
+func (m *queryFinalizedBundlesRequest) unmarshal([]byte) error {
+	return fmt.Errorf("request can not be unmarshalled")
+}
+
+// kyve.query.v1beta1.QueryFinalizedBundlesResponse with the nested
+// cosmos.base.query.v1beta1.PageResponse
+type queryFinalizedBundlesResponse struct {
+	bundles []types.FinalizedBundle
+	nextKey []byte
+}
+
+func (m *queryFinalizedBundlesResponse) marshal() []byte {
+	return nil
+}
+
+func (m *queryFinalizedBundlesResponse) unmarshal(data []byte) error {
+	m.bundles = make([]types.FinalizedBundle, 0)
+
+	return walkFields(data, fieldSchema{1: protowire.BytesType, 2: protowire.BytesType}, func(num protowire.Number, value []byte, _ uint64) error {
+		if num == 2 {
+			return walkFields(value, fieldSchema{1: protowire.BytesType}, func(_ protowire.Number, nextKey []byte, _ uint64) error {
+				m.nextKey = append([]byte{}, nextKey...)
+				return nil
+			})
+		}
+
+		bundle := &finalizedBundle{}
+		if err := bundle.unmarshal(value); err != nil {
+			return err
+		}
+
+		m.bundles = append(m.bundles, bundle.FinalizedBundle)
+		return nil
+	})
+}
+
+// kyve.query.v1beta1.QueryFinalizedBundleRequest
+type queryFinalizedBundleRequest struct {
+	poolId uint64
+	id     uint64
+}
+
+func (m *queryFinalizedBundleRequest) marshal() []byte {
+	return appendVarintField(appendVarintField(nil, 1, m.poolId), 2, m.id)
+}
+
+func (m *queryFinalizedBundleRequest) unmarshal([]byte) error {
+	return fmt.Errorf("request can not be unmarshalled")
+}
+
+// UnmarshalFinalizedBundle decodes a kyve.bundles.v1beta1.FinalizedBundle like it is stored in the
+// state of the KYVE chain. The query message kyve.query.v1beta1.FinalizedBundle uses the same field
+// numbers for all fields KSYNC needs
+func UnmarshalFinalizedBundle(data []byte) (*types.FinalizedBundle, error) {
+	bundle := &finalizedBundle{}
+
+	if err := bundle.unmarshal(data); err != nil {
+		return nil, err
+	}
+
+	return &bundle.FinalizedBundle, nil
+}
+
+// kyve.query.v1beta1.FinalizedBundle
+type finalizedBundle struct {
+	types.FinalizedBundle
+}
+
+func (m *finalizedBundle) marshal() []byte {
+	return nil
+}
+
+func (m *finalizedBundle) unmarshal(data []byte) error {
+	schema := fieldSchema{
+		1:  protowire.VarintType,
+		2:  protowire.VarintType,
+		3:  protowire.BytesType,
+		7:  protowire.BytesType,
+		8:  protowire.BytesType,
+		9:  protowire.BytesType,
+		10: protowire.BytesType,
+		11: protowire.BytesType,
+		12: protowire.VarintType,
+		13: protowire.VarintType,
+	}
+
+	return walkFields(data, schema, func(num protowire.Number, value []byte, number uint64) error {
+		switch num {
+		case 1:
+			m.PoolId = strconv.FormatUint(number, 10)
+		case 2:
+			m.Id = strconv.FormatUint(number, 10)
+		case 3:
+			m.StorageId = string(value)
+		case 7:
+			m.ToKey = string(value)
+		case 8:
+			m.BundleSummary = string(value)
+		case 9:
+			m.DataHash = string(value)
+		case 10:
+			finalizedAt, err := unmarshalFinalizedAt(value)
+			if err != nil {
+				return fmt.Errorf("failed to decode finalized at: %w", err)
+			}
+			m.FinalizedAt = finalizedAt
+		case 11:
+			m.FromKey = string(value)
+		case 12:
+			m.StorageProviderId = strconv.FormatUint(number, 10)
+		case 13:
+			m.CompressionId = strconv.FormatUint(number, 10)
+		}
+
+		return nil
+	})
+}
+
+// unmarshalFinalizedAt decodes the finalized at message of a bundle. The query message encodes
+// height and timestamp as strings while the message in the bundles store uses uint64
+func unmarshalFinalizedAt(data []byte) (*types.FinalizedAt, error) {
+	finalizedAt := &types.FinalizedAt{}
+
+	err := walkFields(data, fieldSchema{1: protowire.BytesType, 2: protowire.BytesType}, func(num protowire.Number, value []byte, _ uint64) error {
+		if num == 1 {
+			finalizedAt.Height = string(value)
+		} else {
+			finalizedAt.Timestamp = string(value)
+		}
+		return nil
+	})
+	if err == nil {
+		return finalizedAt, nil
+	}
+
+	err = walkFields(data, fieldSchema{1: protowire.VarintType, 2: protowire.VarintType}, func(num protowire.Number, _ []byte, number uint64) error {
+		if num == 1 {
+			finalizedAt.Height = strconv.FormatUint(number, 10)
+		} else {
+			finalizedAt.Timestamp = strconv.FormatUint(number, 10)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return finalizedAt, nil
+}
+
+// fieldSchema maps the field numbers of a message which should be decoded to their wire type
+type fieldSchema map[protowire.Number]protowire.Type
+
+// walkFields calls fn for every field of the encoded message which is part of the schema.
+// Length delimited values are passed as bytes, varints as number and all other fields are
+// skipped. Fields of the schema with a different wire type fail the decoding
+func walkFields(data []byte, schema fieldSchema, fn func(num protowire.Number, value []byte, number uint64) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("failed to decode field tag: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		expected, found := schema[num]
+		if found && expected != typ {
+			return fmt.Errorf("field %d has wire type %d, expected %d", num, typ, expected)
+		}
+
+		switch {
+		case found && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return fmt.Errorf("failed to decode field %d: %w", num, protowire.ParseError(n))
+			}
+			data = data[n:]
+
+			if err := fn(num, value, 0); err != nil {
+				return err
+			}
+		case found && typ == protowire.VarintType:
+			number, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return fmt.Errorf("failed to decode field %d: %w", num, protowire.ParseError(n))
+			}
+			data = data[n:]
+
+			if err := fn(num, nil, number); err != nil {
+				return err
+			}
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, data)
+			if n < 0 {
+				return fmt.Errorf("failed to skip field %d: %w", num, protowire.ParseError(n))
+			}
+			data = data[n:]
+		}
+	}
+
+	return nil
+}
+
+// appendVarintField appends the field unless it has the default value
+// zero, which is omitted like proto3 does for scalar values
+func appendVarintField(data []byte, num protowire.Number, value uint64) []byte {
+	if value == 0 {
+		return data
+	}
+
+	data = protowire.AppendTag(data, num, protowire.VarintType)
+	return protowire.AppendVarint(data, value)
+}
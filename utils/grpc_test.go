@@ -0,0 +1,333 @@
+package utils
+
+import (
+	"encoding/hex"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/types"
+	"google.golang.org/grpc"
+)
+
+// The golden messages are encoded field by field with the field numbers of the KYVE protobuf
+// definitions in proto/kyve/query/v1beta1, proto/kyve/pool/v1beta1 and proto/kyve/bundles/v1beta1
+// of the KYVE chain. Every message contains fields KSYNC does not decode to ensure they are skipped
+
+func decodeGolden(t *testing.T, golden string) []byte {
+	t.Helper()
+
+	data, err := hex.DecodeString(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestMarshalRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		message wireMessage
+		golden  string
+	}{
+		{
+			name:    "QueryPoolRequest",
+			message: &queryPoolRequest{id: 3},
+			golden: "" +
+				"0803", // id = 3
+		},
+		{
+			name:    "QueryFinalizedBundleRequest",
+			message: &queryFinalizedBundleRequest{poolId: 3, id: 1000},
+			golden: "" +
+				"0803" + // pool_id = 3
+				"10e807", // id = 1000
+		},
+		{
+			name:    "QueryFinalizedBundlesRequest with pagination",
+			message: &queryFinalizedBundlesRequest{poolId: 3, key: []byte{1, 2}, offset: 5, limit: 1000, reverse: true},
+			golden: "" +
+				"0a0b" + // pagination
+				"0a020102" + // pagination.key = 0x0102
+				"1005" + // pagination.offset = 5
+				"18e807" + // pagination.limit = 1000
+				"2801" + // pagination.reverse = true
+				"1003", // pool_id = 3
+		},
+		{
+			name:    "QueryFinalizedBundlesRequest with index",
+			message: &queryFinalizedBundlesRequest{poolId: 3, index: "42"},
+			golden: "" +
+				"1003" + // pool_id = 3
+				"1a023432", // index = "42"
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if data := hex.EncodeToString(tt.message.marshal()); data != tt.golden {
+				t.Fatalf("expected %s, found %s", tt.golden, data)
+			}
+		})
+	}
+}
+
+func TestUnmarshalQueryPoolResponse(t *testing.T) {
+	golden := "" +
+		"0a59" + // pool
+		"0801" + // pool.id = 1
+		"1255" + // pool.data
+		"0801" + // id = 1
+		"12074f736d6f736973" + // name = "Osmosis", not decoded
+		"1a12406b7976656a732f74656e6465726d696e74" + // runtime = "@kyvejs/tendermint"
+		"2a177b226e6574776f726b223a226f736d6f7369732d31227d" + // config = {"network":"osmosis-1"}
+		"320131" + // start_key = "1"
+		"3a0734323030303030" + // current_key = "4200000"
+		"420773756d6d617279" + // current_summary = "summary"
+		"48bfac8002" + // current_index = 4199999, not decoded
+		"50e820" // total_bundles = 4200
+
+	response := &queryPoolResponse{}
+	if err := response.unmarshal(decodeGolden(t, golden)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := types.PoolResponse{}
+	expected.Pool.Id = 1
+	expected.Pool.Data.Runtime = "@kyvejs/tendermint"
+	expected.Pool.Data.Config = `{"network":"osmosis-1"}`
+	expected.Pool.Data.StartKey = "1"
+	expected.Pool.Data.CurrentKey = "4200000"
+	expected.Pool.Data.CurrentSummary = "summary"
+	expected.Pool.Data.TotalBundles = 4200
+
+	if !reflect.DeepEqual(response.pool, expected) {
+		t.Fatalf("expected %+v, found %+v", expected, response.pool)
+	}
+}
+
+func TestUnmarshalQueryFinalizedBundlesResponse(t *testing.T) {
+	golden := "" +
+		"0a57" + // finalized_bundles
+		"0801" + // pool_id = 1
+		"10e807" + // id = 1000
+		"1a0c73746f726167652d31303030" + // storage_id = "storage-1000"
+		"220d6b7976653175706c6f61646572" + // uploader = "kyve1uploader", not decoded
+		"3a0434323030" + // to_key = "4200"
+		"420773756d6d617279" + // bundle_summary = "summary"
+		"4a0461316232" + // data_hash = "a1b2"
+		"52140a06313233343536120a31373030303030303030" + // finalized_at = {height: "123456", timestamp: "1700000000"}
+		"5a0434313031" + // from_key = "4101"
+		"6002" + // storage_provider_id = 2
+		"6801" + // compression_id = 1
+		"0a29" + // finalized_bundles
+		"0801" + // pool_id = 1
+		"10e907" + // id = 1001
+		"1a0c73746f726167652d31303031" + // storage_id = "storage-1001"
+		"3a0434333030" + // to_key = "4300"
+		"4a0463336434" + // data_hash = "c3d4"
+		"5a0434323031" + // from_key = "4201"
+		"6003" + // storage_provider_id = 3
+		"6801" + // compression_id = 1
+		"1208" + // pagination
+		"0a03000102" + // pagination.next_key = 0x000102
+		"10e820" // pagination.total = 4200, not decoded
+
+	response := &queryFinalizedBundlesResponse{}
+	if err := response.unmarshal(decodeGolden(t, golden)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []types.FinalizedBundle{
+		{
+			PoolId:            "1",
+			Id:                "1000",
+			StorageId:         "storage-1000",
+			StorageProviderId: "2",
+			CompressionId:     "1",
+			FromKey:           "4101",
+			ToKey:             "4200",
+			DataHash:          "a1b2",
+			BundleSummary:     "summary",
+			FinalizedAt:       &types.FinalizedAt{Height: "123456", Timestamp: "1700000000"},
+		},
+		{
+			PoolId:            "1",
+			Id:                "1001",
+			StorageId:         "storage-1001",
+			StorageProviderId: "3",
+			CompressionId:     "1",
+			FromKey:           "4201",
+			ToKey:             "4300",
+			DataHash:          "c3d4",
+		},
+	}
+
+	if !reflect.DeepEqual(response.bundles, expected) {
+		t.Fatalf("expected %+v, found %+v", expected, response.bundles)
+	}
+
+	if hex.EncodeToString(response.nextKey) != "000102" {
+		t.Fatalf("expected next key 000102, found %x", response.nextKey)
+	}
+}
+
+func TestUnmarshalFinalizedBundleFromStore(t *testing.T) {
+	// in the bundles store the finalized at message encodes height and timestamp as uint64
+	golden := "" +
+		"0801" + // pool_id = 1
+		"10e807" + // id = 1000
+		"1a0c73746f726167652d31303030" + // storage_id = "storage-1000"
+		"3a0434323030" + // to_key = "4200"
+		"4a0461316232" + // data_hash = "a1b2"
+		"520a" + // finalized_at
+		"08c0c407" + // finalized_at.height = 123456
+		"1080e2cfaa06" + // finalized_at.timestamp = 1700000000
+		"5a0434313031" + // from_key = "4101"
+		"6002" + // storage_provider_id = 2
+		"6801" // compression_id = 1
+
+	bundle, err := UnmarshalFinalizedBundle(decodeGolden(t, golden))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &types.FinalizedBundle{
+		PoolId:            "1",
+		Id:                "1000",
+		StorageId:         "storage-1000",
+		StorageProviderId: "2",
+		CompressionId:     "1",
+		FromKey:           "4101",
+		ToKey:             "4200",
+		DataHash:          "a1b2",
+		FinalizedAt:       &types.FinalizedAt{Height: "123456", Timestamp: "1700000000"},
+	}
+
+	if !reflect.DeepEqual(bundle, expected) {
+		t.Fatalf("expected %+v, found %+v", expected, bundle)
+	}
+}
+
+func TestUnmarshalRejectsInvalidMessages(t *testing.T) {
+	tests := []struct {
+		name    string
+		message wireMessage
+		golden  string
+	}{
+		{
+			name:    "runtime with varint wire type",
+			message: &queryPoolResponse{},
+			golden: "" +
+				"0a06" + // pool
+				"1204" + // pool.data
+				"0801" + // id = 1
+				"1801", // runtime = 1
+		},
+		{
+			name:    "storage provider id with bytes wire type",
+			message: &finalizedBundle{},
+			golden: "" +
+				"0801" + // pool_id = 1
+				"620132", // storage_provider_id = "2"
+		},
+		{
+			name:    "truncated bundle",
+			message: &queryFinalizedBundlesResponse{},
+			golden: "" +
+				"0a57" + // finalized_bundles with 87 bytes
+				"0801", // pool_id = 1
+		},
+		{
+			name:    "truncated varint",
+			message: &finalizedBundle{},
+			golden: "" +
+				"10e8", // id with missing last byte
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.message.unmarshal(decodeGolden(t, tt.golden)); err == nil {
+				t.Fatal("expected invalid message to fail the decoding")
+			}
+		})
+	}
+}
+
+// rawFrame is sent and received as is by the test server
+type rawFrame struct {
+	data []byte
+}
+
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	return v.(*rawFrame).data, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	v.(*rawFrame).data = append([]byte{}, data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// newGoldenServer starts a gRPC server which responds to every method with the
+// golden response and records the method and request of the last call
+func newGoldenServer(t *testing.T, response string) (string, *string, *[]byte) {
+	t.Helper()
+
+	var method string
+	var request []byte
+
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+		method, _ = grpc.MethodFromServerStream(stream)
+
+		frame := &rawFrame{}
+		if err := stream.RecvMsg(frame); err != nil {
+			return err
+		}
+		request = frame.data
+
+		return stream.SendMsg(&rawFrame{data: decodeGolden(t, response)})
+	}))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String(), &method, &request
+}
+
+func TestGrpcQuerierGetFinalizedBundlesPage(t *testing.T) {
+	endpoint, method, request := newGoldenServer(t, "1205"+"0a03000102")
+
+	querier, err := newGrpcQuerier(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundles, nextKey, err := querier.GetFinalizedBundlesPage(3, 1000, 5, "AQI=", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *method != grpcMethodFinalizedBundles {
+		t.Fatalf("expected method %s, found %s", grpcMethodFinalizedBundles, *method)
+	}
+
+	if golden := "0a0b0a020102100518e80728011003"; hex.EncodeToString(*request) != golden {
+		t.Fatalf("expected request %s, found %x", golden, *request)
+	}
+
+	if len(bundles) != 0 || nextKey != "AAEC" {
+		t.Fatalf("expected no bundles and next key AAEC, found %d bundles and next key %s", len(bundles), nextKey)
+	}
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/types"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const KyveNetworksVersion = 1
+
+//go:embed networks.yml
+var defaultKyveNetworks []byte
+
+// GetKyveNetworks returns the KYVE networks shipped with KSYNC patched with
+// the local networks from "$HOME/.ksync/networks.yml"
+func GetKyveNetworks() ([]types.KyveNetwork, error) {
+	networks, err := loadKyveNetworks(defaultKyveNetworks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load default networks: %w", err)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return networks, nil
+	}
+
+	overridePath := filepath.Join(home, ".ksync", "networks.yml")
+
+	data, err := os.ReadFile(overridePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read networks from %s: %w", overridePath, err)
+		}
+		return networks, nil
+	}
+
+	overrides, err := loadKyveNetworks(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load networks from %s: %w", overridePath, err)
+	}
+
+Overrides:
+	for _, override := range overrides {
+		for i := range networks {
+			if networks[i].ChainId == override.ChainId {
+				networks[i] = mergeKyveNetwork(networks[i], override)
+				continue Overrides
+			}
+		}
+
+		networks = append(networks, override)
+	}
+
+	return networks, nil
+}
+
+// GetKyveNetwork returns the KYVE network with the given chain id
+func GetKyveNetwork(chainId string) (types.KyveNetwork, error) {
+	networks, err := GetKyveNetworks()
+	if err != nil {
+		return types.KyveNetwork{}, err
+	}
+
+	chainIds := make([]string, 0, len(networks))
+
+	for _, network := range networks {
+		if network.ChainId == chainId {
+			return network, nil
+		}
+
+		chainIds = append(chainIds, fmt.Sprintf("\"%s\"", network.ChainId))
+	}
+
+	return types.KyveNetwork{}, fmt.Errorf("flag --chain-id has to be one of %s, networks can be added in \"$HOME/.ksync/networks.yml\"", strings.Join(chainIds, ", "))
+}
+
+// ChainIdUsage returns the usage of the --chain-id flag with the chain ids of the
+// networks shipped with KSYNC
+func ChainIdUsage() string {
+	networks, err := loadKyveNetworks(defaultKyveNetworks)
+	if err != nil {
+		return "KYVE chain id"
+	}
+
+	chainIds := make([]string, 0, len(networks))
+	for _, network := range networks {
+		chainIds = append(chainIds, fmt.Sprintf("\"%s\"", network.ChainId))
+	}
+
+	return fmt.Sprintf("KYVE chain id [%s], more networks can be added in \"$HOME/.ksync/networks.yml\"", strings.Join(chainIds, ","))
+}
+
+// GetChainRest returns the rest endpoint of the KYVE chain, which is either
+// --chain-rest or the rest endpoint of the network of --chain-id
+func GetChainRest() (string, error) {
+	if flags.ChainRest != "" {
+		return strings.TrimSuffix(flags.ChainRest, "/"), nil
+	}
+
+	network, err := GetKyveNetwork(flags.ChainId)
+	if err != nil {
+		return "", err
+	}
+
+	if network.Rest == "" {
+		return "", fmt.Errorf("network %s has no rest endpoint, provide one with --chain-rest", flags.ChainId)
+	}
+
+	return network.Rest, nil
+}
+
+func loadKyveNetworks(data []byte) ([]types.KyveNetwork, error) {
+	var networks types.KyveNetworks
+	if err := yaml.UnmarshalStrict(data, &networks); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal networks: %w", err)
+	}
+
+	if networks.Version != KyveNetworksVersion {
+		return nil, fmt.Errorf("unsupported networks version %d, expected %d", networks.Version, KyveNetworksVersion)
+	}
+
+	for i, network := range networks.Networks {
+		if network.ChainId == "" {
+			return nil, fmt.Errorf("network %d has no chain id", i)
+		}
+
+		networks.Networks[i].Rest = strings.TrimSuffix(network.Rest, "/")
+		networks.Networks[i].Rpc = strings.TrimSuffix(network.Rpc, "/")
+
+		if network.RegistryKey == "" {
+			networks.Networks[i].RegistryKey = network.ChainId
+		}
+	}
+
+	return networks.Networks, nil
+}
+
+// mergeKyveNetwork patches all fields of the network which are set in the override
+func mergeKyveNetwork(network, override types.KyveNetwork) types.KyveNetwork {
+	if override.Rest != "" {
+		network.Rest = override.Rest
+	}
+
+	if override.Grpc != "" {
+		network.Grpc = override.Grpc
+	}
+
+	if override.Rpc != "" {
+		network.Rpc = override.Rpc
+	}
+
+	// the registry key defaults to the chain id, so it is only patched
+	// if it was explicitly set to something else
+	if override.RegistryKey != override.ChainId {
+		network.RegistryKey = override.RegistryKey
+	}
+
+	return network
+}
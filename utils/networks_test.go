@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/types"
+)
+
+var testDefaultNetworks = []types.KyveNetwork{
+	{ChainId: "kyve-1", Rest: "https://api.kyve.network", Rpc: "https://rpc.kyve.network", RegistryKey: "kyve-1"},
+	{ChainId: "kaon-1", Rest: "https://api.kaon.kyve.network", Rpc: "https://rpc.kaon.kyve.network", RegistryKey: "kaon-1"},
+	{ChainId: "korellia-2", Rest: "https://api.korellia.kyve.network", Rpc: "https://rpc.korellia.kyve.network", RegistryKey: "korellia-2"},
+}
+
+// withNetworks returns the default networks with the network of the chain id
+// replaced and the given networks appended
+func withNetworks(replaced types.KyveNetwork, appended ...types.KyveNetwork) []types.KyveNetwork {
+	networks := make([]types.KyveNetwork, 0, len(testDefaultNetworks)+len(appended))
+	for _, network := range testDefaultNetworks {
+		if network.ChainId == replaced.ChainId {
+			network = replaced
+		}
+		networks = append(networks, network)
+	}
+	return append(networks, appended...)
+}
+
+func TestGetKyveNetworks(t *testing.T) {
+	tests := []struct {
+		name        string
+		overrides   string
+		expected    []types.KyveNetwork
+		expectedErr string
+	}{
+		{
+			name:     "no local networks",
+			expected: testDefaultNetworks,
+		},
+		{
+			name:      "patch single field",
+			overrides: "version: 1\nnetworks:\n  - chain_id: kaon-1\n    grpc: grpc.kaon.kyve.network:443\n",
+			expected:  withNetworks(types.KyveNetwork{ChainId: "kaon-1", Rest: "https://api.kaon.kyve.network", Grpc: "grpc.kaon.kyve.network:443", Rpc: "https://rpc.kaon.kyve.network", RegistryKey: "kaon-1"}),
+		},
+		{
+			name:      "patch endpoints with trailing slash",
+			overrides: "version: 1\nnetworks:\n  - chain_id: kyve-1\n    rest: http://localhost:1317/\n    rpc: http://localhost:26657/\n",
+			expected:  withNetworks(types.KyveNetwork{ChainId: "kyve-1", Rest: "http://localhost:1317", Rpc: "http://localhost:26657", RegistryKey: "kyve-1"}),
+		},
+		{
+			name:      "registry key differs from chain id",
+			overrides: "version: 1\nnetworks:\n  - chain_id: korellia-2\n    registry_key: kaon-1\n",
+			expected:  withNetworks(types.KyveNetwork{ChainId: "korellia-2", Rest: "https://api.korellia.kyve.network", Rpc: "https://rpc.korellia.kyve.network", RegistryKey: "kaon-1"}),
+		},
+		{
+			name:      "registry key equals chain id",
+			overrides: "version: 1\nnetworks:\n  - chain_id: kaon-1\n    registry_key: kaon-1\n",
+			expected:  testDefaultNetworks,
+		},
+		{
+			name:      "append devnet",
+			overrides: "version: 1\nnetworks:\n  - chain_id: kyve-local\n    rest: http://localhost:1317\n    registry_key: kaon-1\n  - chain_id: kyve-local-2\n    rest: http://localhost:2317\n",
+			expected: withNetworks(types.KyveNetwork{},
+				types.KyveNetwork{ChainId: "kyve-local", Rest: "http://localhost:1317", RegistryKey: "kaon-1"},
+				types.KyveNetwork{ChainId: "kyve-local-2", Rest: "http://localhost:2317", RegistryKey: "kyve-local-2"},
+			),
+		},
+		{
+			name:        "unsupported version",
+			overrides:   "version: 2\nnetworks:\n  - chain_id: kyve-local\n",
+			expectedErr: "unsupported networks version 2, expected 1",
+		},
+		{
+			name:        "missing version",
+			overrides:   "networks:\n  - chain_id: kyve-local\n",
+			expectedErr: "unsupported networks version 0, expected 1",
+		},
+		{
+			name:        "network without chain id",
+			overrides:   "version: 1\nnetworks:\n  - rest: http://localhost:1317\n",
+			expectedErr: "network 0 has no chain id",
+		},
+		{
+			name:        "unknown field",
+			overrides:   "version: 1\nnetworks:\n  - chain_id: kyve-local\n    api: http://localhost:1317\n",
+			expectedErr: "field api not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+
+			if tt.overrides != "" {
+				if err := os.MkdirAll(filepath.Join(home, ".ksync"), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(home, ".ksync", "networks.yml"), []byte(tt.overrides), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			networks, err := GetKyveNetworks()
+
+			if tt.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+					t.Fatalf("expected error %q, found %v", tt.expectedErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(networks, tt.expected) {
+				t.Fatalf("expected networks %+v, found %+v", tt.expected, networks)
+			}
+		})
+	}
+}
+
+func TestGetKyveNetwork(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := os.MkdirAll(filepath.Join(home, ".ksync"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, ".ksync", "networks.yml"), []byte("version: 1\nnetworks:\n  - chain_id: kyve-local\n    rest: http://localhost:1317\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	network, err := GetKyveNetwork("kyve-local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network.Rest != "http://localhost:1317" {
+		t.Fatalf("expected local network, found %+v", network)
+	}
+
+	if _, err := GetKyveNetwork("kyve-2"); err == nil || !strings.Contains(err.Error(), `"kyve-local"`) {
+		t.Fatalf("expected unknown chain id to fail with the available chain ids, found %v", err)
+	}
+}
+
+func TestMergeKyveNetwork(t *testing.T) {
+	network := types.KyveNetwork{ChainId: "kyve-1", Rest: "https://api.kyve.network", Grpc: "grpc.kyve.network:443", Rpc: "https://rpc.kyve.network", RegistryKey: "kyve"}
+
+	tests := []struct {
+		name     string
+		override types.KyveNetwork
+		expected types.KyveNetwork
+	}{
+		{
+			name:     "registry key defaulted to the chain id is not patched",
+			override: types.KyveNetwork{ChainId: "kyve-1", Rest: "http://localhost:1317", RegistryKey: "kyve-1"},
+			expected: types.KyveNetwork{ChainId: "kyve-1", Rest: "http://localhost:1317", Grpc: "grpc.kyve.network:443", Rpc: "https://rpc.kyve.network", RegistryKey: "kyve"},
+		},
+		{
+			name:     "registry key is patched",
+			override: types.KyveNetwork{ChainId: "kyve-1", RegistryKey: "kaon-1"},
+			expected: types.KyveNetwork{ChainId: "kyve-1", Rest: "https://api.kyve.network", Grpc: "grpc.kyve.network:443", Rpc: "https://rpc.kyve.network", RegistryKey: "kaon-1"},
+		},
+		{
+			name:     "all endpoints are patched",
+			override: types.KyveNetwork{ChainId: "kyve-1", Rest: "http://localhost:1317", Grpc: "localhost:9090", Rpc: "http://localhost:26657", RegistryKey: "kyve-1"},
+			expected: types.KyveNetwork{ChainId: "kyve-1", Rest: "http://localhost:1317", Grpc: "localhost:9090", Rpc: "http://localhost:26657", RegistryKey: "kyve"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if merged := mergeKyveNetwork(network, tt.override); merged != tt.expected {
+				t.Fatalf("expected network %+v, found %+v", tt.expected, merged)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"github.com/KYVENetwork/cometbft/v34/libs/json"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/logger"
+	"github.com/KYVENetwork/ksync/types"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	queriers   = make(map[string]types.KyveQuerier)
+	queriersMu sync.Mutex
+)
+
+// GetKyveQuerier returns the querier for the KYVE chain with the given rest endpoint. If
+// a gRPC endpoint is provided or configured for the network of the rest endpoint queries
+// are made over gRPC and only fall back to REST if they fail, e.g. because the gRPC
+// endpoint does not support the query
+func GetKyveQuerier(restEndpoint string) types.KyveQuerier {
+	queriersMu.Lock()
+	defer queriersMu.Unlock()
+
+	key := fmt.Sprintf("%s|%s", restEndpoint, flags.ChainGrpc)
+	if querier, found := queriers[key]; found {
+		return querier
+	}
+
+	grpcEndpoint := flags.ChainGrpc
+	if grpcEndpoint == "" {
+		grpcEndpoint = getNetworkGrpc(restEndpoint)
+	}
+
+	var querier types.KyveQuerier = &restQuerier{endpoint: restEndpoint}
+
+	if grpcEndpoint != "" {
+		grpc, err := newGrpcQuerier(grpcEndpoint)
+		if err != nil {
+			logger.Logger.Warn().Msgf("failed to create gRPC client for %s, falling back to REST: %s", grpcEndpoint, err)
+		} else {
+			querier = &fallbackQuerier{primary: grpc, fallback: querier}
+		}
+	}
+
+	queriers[key] = querier
+	return querier
+}
+
+// getNetworkGrpc returns the gRPC endpoint of the network with the given rest endpoint
+func getNetworkGrpc(restEndpoint string) string {
+	networks, err := GetKyveNetworks()
+	if err != nil {
+		return ""
+	}
+
+	for _, network := range networks {
+		if network.Rest == strings.TrimSuffix(restEndpoint, "/") {
+			return network.Grpc
+		}
+	}
+
+	return ""
+}
+
+// restQuerier queries the KYVE chain over the REST API
+type restQuerier struct {
+	endpoint string
+}
+
+func (querier *restQuerier) GetPool(poolId int64) (*types.PoolResponse, error) {
+	data, err := GetFromUrl(fmt.Sprintf("%s/kyve/query/v1beta1/pool/%d", querier.endpoint, poolId))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pool %d: %w", poolId, err)
+	}
+
+	var poolResponse types.PoolResponse
+
+	if err = json.Unmarshal(data, &poolResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal pool response: %w", err)
+	}
+
+	// every pool has a runtime, if it is missing the response has a different schema
+	// and we would continue with zero values otherwise
+	if poolResponse.Pool.Data.Runtime == "" {
+		return nil, fmt.Errorf("pool response of pool %d has no runtime", poolId)
+	}
+
+	return &poolResponse, nil
+}
+
+func (querier *restQuerier) GetFinalizedBundlesPage(poolId, limit, offset int64, paginationKey string, reverse bool) ([]types.FinalizedBundle, string, error) {
+	raw, err := GetFromUrl(fmt.Sprintf(
+		"%s/kyve/v1/bundles/%d?pagination.limit=%d&pagination.offset=%d&pagination.key=%s&pagination.reverse=%v",
+		querier.endpoint,
+		poolId,
+		limit,
+		offset,
+		paginationKey,
+		reverse,
+	))
+	if err != nil {
+		return nil, "", err
+	}
+
+	var bundlesResponse types.FinalizedBundlesResponse
+
+	if err := json.Unmarshal(raw, &bundlesResponse); err != nil {
+		return nil, "", err
+	}
+
+	nextKey := base64.URLEncoding.EncodeToString(bundlesResponse.Pagination.NextKey)
+
+	return bundlesResponse.FinalizedBundles, nextKey, nil
+}
+
+func (querier *restQuerier) GetFinalizedBundleById(poolId, bundleId int64) (*types.FinalizedBundle, error) {
+	raw, err := GetFromUrl(fmt.Sprintf(
+		"%s/kyve/v1/bundles/%d/%d",
+		querier.endpoint,
+		poolId,
+		bundleId,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var finalizedBundle types.FinalizedBundle
+
+	if err := json.Unmarshal(raw, &finalizedBundle); err != nil {
+		return nil, err
+	}
+
+	return &finalizedBundle, nil
+}
+
+func (querier *restQuerier) GetFinalizedBundleByIndex(poolId, index int64) (*types.FinalizedBundle, error) {
+	raw, err := GetFromUrl(fmt.Sprintf(
+		"%s/kyve/v1/bundles/%d?index=%d",
+		querier.endpoint,
+		poolId,
+		index,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get finalized bundle for index %d: %w", index, err)
+	}
+
+	var bundlesResponse types.FinalizedBundlesResponse
+
+	if err := json.Unmarshal(raw, &bundlesResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal finalized bundles response: %w", err)
+	}
+
+	if len(bundlesResponse.FinalizedBundles) != 1 {
+		return nil, fmt.Errorf("expected one finalized bundle for index %d, found %d", index, len(bundlesResponse.FinalizedBundles))
+	}
+
+	return &bundlesResponse.FinalizedBundles[0], nil
+}
+
+// fallbackQuerier makes every query with the primary querier first and only uses the
+// fallback querier if the primary one fails. After the first transport failure of the
+// primary querier all further queries of the session go to the fallback querier directly,
+// so an unreachable gRPC endpoint does not delay every single query
+type fallbackQuerier struct {
+	primary  types.KyveQuerier
+	fallback types.KyveQuerier
+
+	primaryDown atomic.Bool
+}
+
+func (querier *fallbackQuerier) GetPool(poolId int64) (*types.PoolResponse, error) {
+	if !querier.primaryDown.Load() {
+		poolResponse, err := querier.primary.GetPool(poolId)
+		if err == nil {
+			return poolResponse, nil
+		}
+		querier.onPrimaryError(fmt.Sprintf("pool %d", poolId), err)
+	}
+
+	return querier.fallback.GetPool(poolId)
+}
+
+func (querier *fallbackQuerier) GetFinalizedBundlesPage(poolId, limit, offset int64, paginationKey string, reverse bool) ([]types.FinalizedBundle, string, error) {
+	if !querier.primaryDown.Load() {
+		bundles, nextKey, err := querier.primary.GetFinalizedBundlesPage(poolId, limit, offset, paginationKey, reverse)
+		if err == nil {
+			return bundles, nextKey, nil
+		}
+		querier.onPrimaryError(fmt.Sprintf("finalized bundles of pool %d", poolId), err)
+	}
+
+	return querier.fallback.GetFinalizedBundlesPage(poolId, limit, offset, paginationKey, reverse)
+}
+
+func (querier *fallbackQuerier) GetFinalizedBundleById(poolId, bundleId int64) (*types.FinalizedBundle, error) {
+	if !querier.primaryDown.Load() {
+		bundle, err := querier.primary.GetFinalizedBundleById(poolId, bundleId)
+		if err == nil {
+			return bundle, nil
+		}
+		querier.onPrimaryError(fmt.Sprintf("finalized bundle %d of pool %d", bundleId, poolId), err)
+	}
+
+	return querier.fallback.GetFinalizedBundleById(poolId, bundleId)
+}
+
+func (querier *fallbackQuerier) GetFinalizedBundleByIndex(poolId, index int64) (*types.FinalizedBundle, error) {
+	if !querier.primaryDown.Load() {
+		bundle, err := querier.primary.GetFinalizedBundleByIndex(poolId, index)
+		if err == nil {
+			return bundle, nil
+		}
+		querier.onPrimaryError(fmt.Sprintf("finalized bundle for index %d of pool %d", index, poolId), err)
+	}
+
+	return querier.fallback.GetFinalizedBundleByIndex(poolId, index)
+}
+
+func (querier *fallbackQuerier) onPrimaryError(query string, err error) {
+	if !isGrpcTransportError(err) {
+		logger.Logger.Warn().Msgf("failed to query %s over gRPC, falling back to REST: %s", query, strings.TrimSpace(err.Error()))
+		return
+	}
+
+	if !querier.primaryDown.Swap(true) {
+		logger.Logger.Warn().Msgf("failed to reach gRPC endpoint, using REST for all further queries: %s", strings.TrimSpace(err.Error()))
+	}
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type countingQuerier struct {
+	types.KyveQuerier
+	err   error
+	calls int
+}
+
+func (querier *countingQuerier) GetPool(poolId int64) (*types.PoolResponse, error) {
+	querier.calls++
+	if querier.err != nil {
+		return nil, querier.err
+	}
+	return &types.PoolResponse{}, nil
+}
+
+func TestFallbackQuerier(t *testing.T) {
+	tests := []struct {
+		name                  string
+		primaryErr            error
+		expectedPrimaryCalls  int
+		expectedFallbackCalls int
+	}{
+		{name: "primary succeeds", expectedPrimaryCalls: 3},
+		{name: "unavailable sticks to fallback", primaryErr: fmt.Errorf("failed to query pool 0: %w", status.Error(codes.Unavailable, "connection refused")), expectedPrimaryCalls: 1, expectedFallbackCalls: 3},
+		{name: "deadline exceeded sticks to fallback", primaryErr: status.Error(codes.DeadlineExceeded, "context deadline exceeded"), expectedPrimaryCalls: 1, expectedFallbackCalls: 3},
+		{name: "query error keeps primary", primaryErr: status.Error(codes.NotFound, "pool not found"), expectedPrimaryCalls: 3, expectedFallbackCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &countingQuerier{err: tt.primaryErr}
+			fallback := &countingQuerier{}
+			querier := &fallbackQuerier{primary: primary, fallback: fallback}
+
+			for i := 0; i < 3; i++ {
+				if _, err := querier.GetPool(0); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if primary.calls != tt.expectedPrimaryCalls {
+				t.Fatalf("expected %d primary calls, found %d", tt.expectedPrimaryCalls, primary.calls)
+			}
+
+			if fallback.calls != tt.expectedFallbackCalls {
+				t.Fatalf("expected %d fallback calls, found %d", tt.expectedFallbackCalls, fallback.calls)
+			}
+		})
+	}
+}
+
+func TestIsGrpcTransportError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoint := listener.Addr().String()
+	_ = listener.Close()
+
+	querier, err := newGrpcQuerier(endpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := querier.GetPool(0); !isGrpcTransportError(err) {
+		t.Fatalf("expected unreachable endpoint to be a transport error, found %v", err)
+	}
+
+	if isGrpcTransportError(fmt.Errorf("pool response of pool 0 has no runtime")) {
+		t.Fatal("expected decode error not to be a transport error")
+	}
+}
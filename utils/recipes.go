@@ -0,0 +1,145 @@
+package utils
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/types"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const BuildRecipesVersion = 1
+
+//go:embed recipes.yml
+var defaultBuildRecipes []byte
+
+// getDefaultBuildRecipes parses the build recipes shipped with KSYNC only once,
+// the result is shared and must therefore not be changed
+var getDefaultBuildRecipes = sync.OnceValues(func() (*types.BuildRecipes, error) {
+	return loadBuildRecipes(defaultBuildRecipes)
+})
+
+// GetBuildRecipe returns the build recipe for the given chain and upgrade. The recipe
+// is merged from the defaults, the chain recipe and the upgrade override. The local
+// recipes from "$HOME/.ksync/recipes.yml" or --build-recipes take precedence over the
+// recipes shipped with KSYNC
+func GetBuildRecipe(chainId string, upgrade types.Upgrade) (types.BuildRecipe, error) {
+	defaults, err := getDefaultBuildRecipes()
+	if err != nil {
+		return types.BuildRecipe{}, fmt.Errorf("failed to load default build recipes: %w", err)
+	}
+
+	// the local recipes are merged into a copy of the chains of the defaults
+	recipes := *defaults
+	recipes.Chains = make(map[string]types.BuildRecipe, len(defaults.Chains))
+	for id, chainRecipe := range defaults.Chains {
+		recipes.Chains[id] = chainRecipe
+	}
+
+	overridePath := flags.BuildRecipes
+	if overridePath == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			overridePath = filepath.Join(home, ".ksync", "recipes.yml")
+		}
+	}
+
+	if data, err := os.ReadFile(overridePath); err == nil {
+		overrides, err := loadBuildRecipes(data)
+		if err != nil {
+			return types.BuildRecipe{}, fmt.Errorf("failed to load build recipes from %s: %w", overridePath, err)
+		}
+
+		recipes.Defaults = mergeBuildRecipe(recipes.Defaults, overrides.Defaults)
+
+		for id, chainRecipe := range overrides.Chains {
+			recipes.Chains[id] = mergeBuildRecipe(recipes.Chains[id], chainRecipe)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) || flags.BuildRecipes != "" {
+		return types.BuildRecipe{}, fmt.Errorf("failed to read build recipes from %s: %w", overridePath, err)
+	}
+
+	chainRecipe := recipes.Chains[chainId]
+	recipe := mergeBuildRecipe(recipes.Defaults, chainRecipe)
+
+	if upgradeRecipe, found := chainRecipe.Upgrades[upgrade.Name]; found {
+		recipe = mergeBuildRecipe(recipe, upgradeRecipe)
+	}
+
+	if upgradeRecipe, found := chainRecipe.Upgrades[upgrade.Version]; found {
+		recipe = mergeBuildRecipe(recipe, upgradeRecipe)
+	}
+
+	recipe.Upgrades = nil
+	return recipe, nil
+}
+
+func loadBuildRecipes(data []byte) (*types.BuildRecipes, error) {
+	var recipes types.BuildRecipes
+	if err := yaml.UnmarshalStrict(data, &recipes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal build recipes: %w", err)
+	}
+
+	if recipes.Version != BuildRecipesVersion {
+		return nil, fmt.Errorf("unsupported build recipes version %d, expected %d", recipes.Version, BuildRecipesVersion)
+	}
+
+	if recipes.Chains == nil {
+		recipes.Chains = make(map[string]types.BuildRecipe)
+	}
+
+	return &recipes, nil
+}
+
+// mergeBuildRecipe overrides all fields of the base recipe
+// which are set in the override recipe
+func mergeBuildRecipe(base, override types.BuildRecipe) types.BuildRecipe {
+	if override.Subfolder != "" {
+		base.Subfolder = override.Subfolder
+	}
+	if override.BuildCmd != "" {
+		base.BuildCmd = override.BuildCmd
+	}
+	if override.BuildArgs != nil {
+		base.BuildArgs = override.BuildArgs
+	}
+	if override.BinaryPath != "" {
+		base.BinaryPath = override.BinaryPath
+	}
+	if override.GoVersion != "" {
+		base.GoVersion = override.GoVersion
+	}
+	if override.LibwasmVersion != "" {
+		base.LibwasmVersion = override.LibwasmVersion
+	}
+	if override.LibwasmPath != "" {
+		base.LibwasmPath = override.LibwasmPath
+	}
+
+	if len(override.Env) > 0 {
+		env := make(map[string]string)
+		for key, value := range base.Env {
+			env[key] = value
+		}
+		for key, value := range override.Env {
+			env[key] = value
+		}
+		base.Env = env
+	}
+
+	if len(override.Upgrades) > 0 {
+		upgrades := make(map[string]types.BuildRecipe)
+		for key, value := range base.Upgrades {
+			upgrades[key] = value
+		}
+		for key, value := range override.Upgrades {
+			upgrades[key] = mergeBuildRecipe(upgrades[key], value)
+		}
+		base.Upgrades = upgrades
+	}
+
+	return base
+}
@@ -0,0 +1,260 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/KYVENetwork/ksync/flags"
+	"github.com/KYVENetwork/ksync/types"
+)
+
+const testBuildRecipes = `version: 1
+defaults:
+  env:
+    CGO_ENABLED: "1"
+chains:
+  test-1:
+    subfolder: app
+    build_cmd: install
+    env:
+      LEDGER_ENABLED: "false"
+    upgrades:
+      v2:
+        go_version: "1.21"
+        env:
+          CGO_ENABLED: "0"
+      v2.0.1:
+        go_version: "1.22"
+        build_args:
+          - FOO=bar
+  dydx-mainnet-1:
+    build_cmd: install
+`
+
+func setBuildRecipesFlag(t *testing.T, path string) {
+	t.Helper()
+
+	buildRecipes := flags.BuildRecipes
+	t.Cleanup(func() {
+		flags.BuildRecipes = buildRecipes
+	})
+	flags.BuildRecipes = path
+}
+
+func TestGetBuildRecipe(t *testing.T) {
+	defaults, err := getDefaultBuildRecipes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recipesPath := filepath.Join(t.TempDir(), "recipes.yml")
+	if err := os.WriteFile(recipesPath, []byte(testBuildRecipes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	setBuildRecipesFlag(t, recipesPath)
+
+	tests := []struct {
+		name     string
+		chainId  string
+		upgrade  types.Upgrade
+		expected types.BuildRecipe
+	}{
+		{
+			name:    "defaults only",
+			chainId: "other-1",
+			upgrade: types.Upgrade{Name: "v2", Version: "v2.0.0"},
+			expected: types.BuildRecipe{
+				BuildCmd:  defaults.Defaults.BuildCmd,
+				BuildArgs: defaults.Defaults.BuildArgs,
+				Env:       map[string]string{"CGO_ENABLED": "1"},
+			},
+		},
+		{
+			name:    "override by chain",
+			chainId: "test-1",
+			upgrade: types.Upgrade{Name: "v1", Version: "v1.0.0"},
+			expected: types.BuildRecipe{
+				Subfolder: "app",
+				BuildCmd:  "install",
+				BuildArgs: defaults.Defaults.BuildArgs,
+				Env:       map[string]string{"CGO_ENABLED": "1", "LEDGER_ENABLED": "false"},
+			},
+		},
+		{
+			name:    "override by upgrade name",
+			chainId: "test-1",
+			upgrade: types.Upgrade{Name: "v2", Version: "v2.0.0"},
+			expected: types.BuildRecipe{
+				Subfolder: "app",
+				BuildCmd:  "install",
+				BuildArgs: defaults.Defaults.BuildArgs,
+				Env:       map[string]string{"CGO_ENABLED": "0", "LEDGER_ENABLED": "false"},
+				GoVersion: "1.21",
+			},
+		},
+		{
+			name:    "override by version over upgrade name",
+			chainId: "test-1",
+			upgrade: types.Upgrade{Name: "v2", Version: "v2.0.1"},
+			expected: types.BuildRecipe{
+				Subfolder: "app",
+				BuildCmd:  "install",
+				BuildArgs: []string{"FOO=bar"},
+				Env:       map[string]string{"CGO_ENABLED": "0", "LEDGER_ENABLED": "false"},
+				GoVersion: "1.22",
+			},
+		},
+		{
+			name:    "local override of a shipped chain recipe",
+			chainId: "dydx-mainnet-1",
+			upgrade: types.Upgrade{Name: "v5", Version: "v5.0.0"},
+			expected: types.BuildRecipe{
+				Subfolder: defaults.Chains["dydx-mainnet-1"].Subfolder,
+				BuildCmd:  "install",
+				BuildArgs: defaults.Defaults.BuildArgs,
+				Env:       map[string]string{"CGO_ENABLED": "1"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipe, err := GetBuildRecipe(tt.chainId, tt.upgrade)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(recipe, tt.expected) {
+				t.Fatalf("expected recipe %+v, found %+v", tt.expected, recipe)
+			}
+		})
+	}
+}
+
+func TestGetBuildRecipeKeepsShippedRecipes(t *testing.T) {
+	recipesPath := filepath.Join(t.TempDir(), "recipes.yml")
+	if err := os.WriteFile(recipesPath, []byte(testBuildRecipes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	setBuildRecipesFlag(t, recipesPath)
+
+	withOverride, err := GetBuildRecipe("dydx-mainnet-1", types.Upgrade{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// without the local recipes the shipped recipes have to apply again
+	t.Setenv("HOME", t.TempDir())
+	flags.BuildRecipes = ""
+
+	withoutOverride, err := GetBuildRecipe("dydx-mainnet-1", types.Upgrade{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if withoutOverride.BuildCmd == withOverride.BuildCmd {
+		t.Fatalf("expected local recipes not to change the shipped recipes, found build cmd %s", withoutOverride.BuildCmd)
+	}
+
+	if recipe, err := GetBuildRecipe("test-1", types.Upgrade{}); err != nil || recipe.Subfolder != "" {
+		t.Fatalf("expected no recipe for test-1 without local recipes, found %+v (%v)", recipe, err)
+	}
+}
+
+func TestGetBuildRecipeInvalidLocalRecipes(t *testing.T) {
+	tests := []struct {
+		name        string
+		recipes     string
+		expectedErr string
+	}{
+		{name: "unknown field", recipes: "version: 1\ndefaults:\n  build_command: install\n", expectedErr: "field build_command not found"},
+		{name: "unsupported version", recipes: "version: 2\n", expectedErr: "unsupported build recipes version 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipesPath := filepath.Join(t.TempDir(), "recipes.yml")
+			if err := os.WriteFile(recipesPath, []byte(tt.recipes), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			setBuildRecipesFlag(t, recipesPath)
+
+			if _, err := GetBuildRecipe("test-1", types.Upgrade{}); err == nil || !strings.Contains(err.Error(), tt.expectedErr) {
+				t.Fatalf("expected error %q, found %v", tt.expectedErr, err)
+			}
+		})
+	}
+
+	setBuildRecipesFlag(t, filepath.Join(t.TempDir(), "missing.yml"))
+	if _, err := GetBuildRecipe("test-1", types.Upgrade{}); err == nil {
+		t.Fatal("expected missing build recipes of --build-recipes to fail")
+	}
+}
+
+func TestMergeBuildRecipe(t *testing.T) {
+	base := types.BuildRecipe{
+		Subfolder:      "app",
+		BuildCmd:       "build",
+		BuildArgs:      []string{"A=1"},
+		Env:            map[string]string{"A": "1", "B": "1"},
+		BinaryPath:     "build",
+		GoVersion:      "1.21",
+		LibwasmVersion: "v1.5.0",
+		LibwasmPath:    "{modcache}/libwasmvm.so",
+		Upgrades: map[string]types.BuildRecipe{
+			"v2": {GoVersion: "1.22", BuildCmd: "install"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		override types.BuildRecipe
+		expected types.BuildRecipe
+	}{
+		{name: "empty override", override: types.BuildRecipe{}, expected: base},
+		{
+			name:     "single field",
+			override: types.BuildRecipe{GoVersion: "1.23"},
+			expected: func() types.BuildRecipe { r := base; r.GoVersion = "1.23"; return r }(),
+		},
+		{
+			name:     "build args are replaced",
+			override: types.BuildRecipe{BuildArgs: []string{"B=2"}},
+			expected: func() types.BuildRecipe { r := base; r.BuildArgs = []string{"B=2"}; return r }(),
+		},
+		{
+			name:     "env is merged by key",
+			override: types.BuildRecipe{Env: map[string]string{"B": "2", "C": "2"}},
+			expected: func() types.BuildRecipe {
+				r := base
+				r.Env = map[string]string{"A": "1", "B": "2", "C": "2"}
+				return r
+			}(),
+		},
+		{
+			name:     "upgrades are merged by field",
+			override: types.BuildRecipe{Upgrades: map[string]types.BuildRecipe{"v2": {GoVersion: "1.23"}, "v3": {Subfolder: "v3"}}},
+			expected: func() types.BuildRecipe {
+				r := base
+				r.Upgrades = map[string]types.BuildRecipe{"v2": {GoVersion: "1.23", BuildCmd: "install"}, "v3": {Subfolder: "v3"}}
+				return r
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if recipe := mergeBuildRecipe(base, tt.override); !reflect.DeepEqual(recipe, tt.expected) {
+				t.Fatalf("expected recipe %+v, found %+v", tt.expected, recipe)
+			}
+
+			// the maps of the base recipe are shared and must not be changed
+			if len(base.Env) != 2 || base.Env["B"] != "1" || base.Upgrades["v2"].GoVersion != "1.22" || len(base.Upgrades) != 1 {
+				t.Fatalf("expected base recipe to be unchanged, found %+v", base)
+			}
+		})
+	}
+}
@@ -5,13 +5,14 @@ import (
 	"compress/gzip"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/KYVENetwork/ksync/logger"
 	"github.com/KYVENetwork/ksync/metrics"
 	"io"
-	"math"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	runtimeDebug "runtime/debug"
 	"strconv"
@@ -43,7 +44,7 @@ func GetFromUrlWithErr(url string) ([]byte, error) {
 	// Create a new GET request
 	request, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, err
+		return nil, &NetworkError{Url: url, Err: err}
 	}
 
 	// Set the User-Agent header
@@ -61,48 +62,76 @@ func GetFromUrlWithErr(url string) ([]byte, error) {
 	// Perform the request
 	response, err := httpClient.Do(request)
 	if err != nil {
-		return nil, err
+		return nil, &NetworkError{Url: url, Err: err}
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != 200 {
-		return nil, fmt.Errorf("got status code %d", response.StatusCode)
+		return nil, &NetworkError{Url: url, StatusCode: response.StatusCode}
 	}
 
 	data, err := io.ReadAll(response.Body)
 	if err != nil {
-		return nil, err
+		return nil, &NetworkError{Url: url, Err: err}
 	}
 
 	return data, nil
 }
 
 // GetFromUrl tries to fetch data from url with exponential backoff, we usually
-// always want a request to succeed so it is implemented by default
-func GetFromUrl(url string) (data []byte, err error) {
-	for i := 0; i < BackoffMaxRetries; i++ {
+// always want a request to succeed so it is implemented by default. Requests
+// which the server rejected are not retried
+func GetFromUrl(url string) ([]byte, error) {
+	return getFromUrl(url, false)
+}
+
+// GetFromStorageUrl is like GetFromUrl but also retries if the data was not found. Gateways
+// of storage providers like Arweave, Bundlr and Turbo can respond with 404 for a while after
+// the data got uploaded, so just finalized bundles might not be available yet
+func GetFromStorageUrl(url string) ([]byte, error) {
+	return getFromUrl(url, true)
+}
+
+// sleep waits between retries, tests replace it to not wait for the backoff
+var sleep = time.Sleep
+
+func getFromUrl(url string, retryNotFound bool) (data []byte, err error) {
+	for retry := 0; ; retry++ {
 		data, err = GetFromUrlWithErr(url)
-		if err != nil {
-			metrics.IncreaseFailedRequests()
-			delaySec := math.Pow(2, float64(i))
+		if err == nil {
+			metrics.IncreaseSuccessfulRequests()
+
+			// only log success message if there were errors previously
+			if retry > 0 {
+				logger.Logger.Info().Msgf("successfully fetched data from url %s", url)
+			}
+			return
+		}
 
-			logger.Logger.Error().Msgf("failed to fetch from url \"%s\" with error \"%s\", retrying in %d seconds", url, err, int(delaySec))
-			time.Sleep(time.Duration(delaySec) * time.Second)
+		metrics.IncreaseFailedRequests()
 
-			continue
+		policy := GetRetryPolicy(err)
+
+		var networkError *NetworkError
+		if retryNotFound && errors.As(err, &networkError) && networkError.StatusCode == http.StatusNotFound {
+			policy = GetRetryPolicy(ErrNetwork)
 		}
 
-		metrics.IncreaseSuccessfulRequests()
+		if !policy.Retryable {
+			logger.Logger.Error().Msgf("failed to fetch from url \"%s\" with error \"%s\"", url, err)
+			return
+		}
 
-		// only log success message if there were errors previously
-		if i > 0 {
-			logger.Logger.Info().Msgf("successfully fetched data from url %s", url)
+		if retry >= policy.MaxRetries {
+			logger.Logger.Error().Msgf("failed to fetch from url \"%s\" within maximum retry limit of %d with error \"%s\"", url, policy.MaxRetries, err)
+			return
 		}
-		return
-	}
 
-	logger.Logger.Error().Msgf("failed to fetch data from url within maximum retry limit of %d", BackoffMaxRetries)
-	return
+		delay := policy.Delay(retry)
+
+		logger.Logger.Error().Msgf("failed to fetch from url \"%s\" with error \"%s\", retrying in %d seconds", url, err, int(delay.Seconds()))
+		sleep(delay)
+	}
 }
 
 func CreateSha256Checksum(input []byte) (hash string) {
@@ -188,3 +217,20 @@ func GetUserConfirmationInput() (bool, error) {
 
 	return true, nil
 }
+
+// GetKsyncDir returns the KSYNC home directory "$HOME/.ksync" joined with the
+// given sub directories and creates it if it does not exist yet
+func GetKsyncDir(elem ...string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+
+	dir := filepath.Join(append([]string{home, ".ksync"}, elem...)...)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	return dir, nil
+}
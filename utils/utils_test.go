@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newNotFoundServer responds with 404 to the first requests and serves data afterward
+func newNotFoundServer(t *testing.T, notFound int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= notFound {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte("data"))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func TestGetFromUrlDoesNotRetryNotFound(t *testing.T) {
+	server, requests := newNotFoundServer(t, 1)
+
+	_, err := GetFromUrl(server.URL)
+
+	var networkError *NetworkError
+	if !errors.As(err, &networkError) || networkError.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected network error with status code 404, found %v", err)
+	}
+
+	if requests.Load() != 1 {
+		t.Fatalf("expected 1 request, found %d", requests.Load())
+	}
+}
+
+func TestGetFromStorageUrlRetriesNotFound(t *testing.T) {
+	server, requests := newNotFoundServer(t, 1)
+
+	data, err := GetFromStorageUrl(server.URL)
+	if err != nil {
+		t.Fatalf("expected data after retry, found %s", err)
+	}
+
+	if string(data) != "data" || requests.Load() != 2 {
+		t.Fatalf("expected data after 2 requests, found %q after %d requests", data, requests.Load())
+	}
+}
+
+func TestGetFromUrlRetryLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		get        func(url string) ([]byte, error)
+	}{
+		{name: "server error", statusCode: http.StatusServiceUnavailable, get: GetFromUrl},
+		{name: "too many requests", statusCode: http.StatusTooManyRequests, get: GetFromUrl},
+		{name: "not found on storage provider", statusCode: http.StatusNotFound, get: GetFromStorageUrl},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(tt.statusCode)
+			}))
+			t.Cleanup(server.Close)
+
+			var delays []time.Duration
+			sleep = func(delay time.Duration) { delays = append(delays, delay) }
+			t.Cleanup(func() { sleep = time.Sleep })
+
+			if _, err := tt.get(server.URL); !errors.Is(err, ErrNetwork) {
+				t.Fatalf("expected network error, found %v", err)
+			}
+
+			policy := GetRetryPolicy(ErrNetwork)
+
+			if int(requests.Load()) != policy.MaxRetries+1 {
+				t.Fatalf("expected %d requests, found %d", policy.MaxRetries+1, requests.Load())
+			}
+
+			// there is no sleep after the last attempt
+			if len(delays) != policy.MaxRetries {
+				t.Fatalf("expected %d delays, found %d", policy.MaxRetries, len(delays))
+			}
+
+			for retry, delay := range delays {
+				if delay != policy.Delay(retry) {
+					t.Fatalf("expected delay %s before retry %d, found %s", policy.Delay(retry), retry, delay)
+				}
+			}
+		})
+	}
+}
+
+func TestGetPoolKeepsNetworkError(t *testing.T) {
+	server, _ := newNotFoundServer(t, 1)
+
+	_, err := (&restQuerier{endpoint: server.URL}).GetPool(0)
+
+	if !errors.Is(err, ErrNetwork) || GetExitCode(err) != ExitCodeNetwork {
+		t.Fatalf("expected network error, found %v", err)
+	}
+
+	if GetRetryPolicy(err).Retryable {
+		t.Fatal("expected rejected request not to be retryable")
+	}
+}